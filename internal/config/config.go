@@ -16,10 +16,12 @@ type Config struct {
 	Redis       RedisConfig
 	IndexTask   IndexTaskConf
 	VectorStore VectorStoreConf
+	Query       QueryConf
 	Cleaner     CleanerConf
 	Validation  ValidationConfig
 	TokenLimit  TokenLimitConf
 	HealthCheck HealthCheckConf
+	Usage       UsageConf
 }
 
 // TokenLimitConf token限流配置
@@ -28,6 +30,21 @@ type TokenLimitConf struct {
 	Enabled         bool `json:"enabled" yaml:"enabled"`
 }
 
+// UsageConf 嵌入/重排模型用量统计与配额配置
+type UsageConf struct {
+	Enabled                  bool            `json:",default=true"`
+	FlushInterval            time.Duration   `json:",default=5s"`   // 用量记录批量写入间隔
+	BufferSize               int             `json:",default=1000"` // 待写入记录缓冲区大小
+	DefaultMonthlyTokenQuota int64           `json:",optional"`     // 每个用户每月 token 配额，0 表示不限制
+	UserQuotas               []UserQuotaConf `json:",optional"`     // 指定用户的配额，优先于默认配额
+}
+
+// UserQuotaConf 用户每月 token 配额
+type UserQuotaConf struct {
+	UserID        string
+	MonthlyTokens int64 // 0 表示不限制
+}
+
 // HealthCheckConf 探活接口配置
 type HealthCheckConf struct {
 	Enabled bool          `json:"enabled" yaml:"enabled"`
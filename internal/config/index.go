@@ -23,6 +23,85 @@ type EmbeddingTaskConf struct {
 	MaxTokensPerChunk     int
 	EnableMarkdownParsing bool `json:",default=false"` // 是否启用markdown文件解析
 	EnableOpenAPIParsing  bool `json:",default=false"` // 是否启用OpenAPI文档解析
+	// 是否按结构切分 YAML、JSON、TOML、Protobuf、GraphQL 文件，分块携带键路径
+	EnableStructuredParsing bool `json:",default=true"`
+	GeneratedCode           GeneratedCodeConf
+	Redaction               RedactionConf
+	ChunkRules              []ChunkRuleConf `json:",optional"` // 覆盖内置的语言切块规则
+	ContextHeader           ContextHeaderConf
+	ChunkMerge              ChunkMergeConf
+	Summary                 SummaryConf
+}
+
+// SummaryConf 分块摘要配置，调用 OpenAI 兼容的对话接口为函数、类分块生成一段自然语言描述，
+// 摘要单独存储并参与嵌入，只对开启摘要的代码库生效
+type SummaryConf struct {
+	Enabled         bool          `json:",default=false"`
+	APIBase         string        `json:",optional"` // chat/completions 接口地址
+	APIKey          string        `json:",optional"`
+	Model           string        `json:",optional"`
+	MaxConcurrency  int           `json:",default=4"`    // 所有任务共享的最大并发请求数
+	Timeout         time.Duration `json:",default=30s"`  // 单次请求超时时间
+	MaxTokens       int           `json:",default=200"`  // 摘要最大 token 数
+	MinTokens       int           `json:",default=32"`   // 低于该 token 数的分块不生成摘要
+	CacheExpiration time.Duration `json:",default=720h"` // 按分块内容哈希缓存摘要的过期时间
+	Prompt          string        `json:",optional"`     // 系统提示词，为空时使用内置提示词
+	Hierarchy       HierarchySummaryConf
+}
+
+// HierarchySummaryConf 层级摘要配置，后台任务由分块到文件、目录、代码库逐层生成摘要，
+// 使用 SummaryConf 中的接口、模型和并发限制
+type HierarchySummaryConf struct {
+	Enabled         bool          `json:",default=false"`
+	MaxInputTokens  int           `json:",default=6000"` // 单次请求输入的最大 token 数，超出部分截断
+	Timeout         time.Duration `json:",default=2h"`   // 单个代码库的任务超时时间
+	FilePrompt      string        `json:",optional"`     // 文件摘要的系统提示词，为空时使用内置提示词
+	DirectoryPrompt string        `json:",optional"`     // 目录和代码库摘要的系统提示词，为空时使用内置提示词
+}
+
+// ChunkMergeConf 小分块合并配置，同一文件同一作用域内相邻的小分块合并为一个分块
+type ChunkMergeConf struct {
+	Enabled      bool `json:",default=true"`
+	SmallTokens  int  `json:",default=64"`  // 低于该 token 数的分块参与合并
+	TargetTokens int  `json:",default=256"` // 合并后分块的 token 数上限
+}
+
+// ContextHeaderConf 嵌入文本的上下文头配置，上下文头只参与生成向量，存储的分块内容仍为原始代码
+type ContextHeaderConf struct {
+	Enabled  bool   `json:",default=false"`
+	Template string `json:",optional"` // text/template 模板，可用字段见 embedding.ChunkContext，为空时使用内置模板
+}
+
+// ChunkRuleConf 语言切块规则覆盖，未设置的字段沿用 internal/embedding/chunkrules 中的内置规则
+type ChunkRuleConf struct {
+	Language  string
+	Kinds     []string   `json:",optional"` // 单独成块的语法节点类型
+	MinTokens int        `json:",optional"` // 低于该 token 数的节点不单独成块
+	Merge     [][]string `json:",optional"` // 连续出现时合并为一个分块的节点类型分组
+}
+
+// RedactionConf 分块内容发送到嵌入模型前的敏感信息脱敏配置
+type RedactionConf struct {
+	Enabled          bool                `json:",default=true"`
+	EntropyThreshold float64             `json:",default=3.5"` // 通用密钥赋值规则的最小香农熵
+	MinSecretLength  int                 `json:",default=20"`  // 高熵字符串的最小长度
+	DisabledRules    []string            `json:",optional"`    // 禁用的内置规则名称
+	CustomRules      []RedactionRuleConf `json:",optional"`    // 自定义正则规则
+}
+
+// RedactionRuleConf 自定义脱敏规则
+type RedactionRuleConf struct {
+	Name       string
+	Pattern    string
+	Group      int     `json:",optional"` // 需要替换的捕获组，0 表示整个匹配
+	MinEntropy float64 `json:",optional"` // 大于 0 时过滤熵值低于该值的命中
+}
+
+// GeneratedCodeConf 生成代码、压缩代码和第三方代码检测配置
+type GeneratedCodeConf struct {
+	Enabled          bool   `json:",default=true"`
+	Action           string `json:",default=downweight,options=skip|downweight"` // skip: 不做嵌入；downweight: 查询时降权
+	MaxAvgLineLength int    `json:",default=200"`                                // 平均行长度超过该值视为压缩代码
 }
 
 type GraphTaskConf struct {
@@ -39,6 +118,9 @@ type FileValidationConf struct {
 	CheckContent   bool     `json:",default=false"`
 	SkipPatterns   []string `json:",default=[]"`
 	LogLevel       string   `json:",default=\"info\""`
+	// 是否按上传文件中的 .gitignore/.ignore/.embedderignore 规则忽略文件
+	RespectIgnoreFiles bool     `json:",default=true"`
+	IgnoreFiles        []string `json:",optional"` // 忽略规则文件名，按优先级从低到高，为空时使用默认值
 }
 
 // ValidationConfig 验证配置
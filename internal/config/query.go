@@ -0,0 +1,20 @@
+package config
+
+// QueryConf 检索查询预处理配置
+type QueryConf struct {
+	// 预处理阶段，按顺序执行：normalize(规范化空白和全角字符)、detect_code(识别代码类查询并选择检索模式)、
+	// split_identifiers(拆分驼峰和下划线标识符)、glossary(按术语表扩展同义词)，为空时使用全部阶段
+	Stages   []string       `json:",optional"`
+	Glossary []GlossaryConf `json:",optional"`
+	// 术语表每次查询最多追加的词数
+	MaxExpansions int `json:",default=8"`
+	// 混合检索中向量检索的权重，取值[0,1]，其余为关键词检索的权重
+	HybridAlpha float32 `json:",default=0.5"`
+	// 关键词检索的 BM25 分数 s 按 s/(s+KeywordScoreScale) 转换到[0,1)，BM25 分数等于该值时为 0.5
+	KeywordScoreScale float32 `json:",default=5"`
+}
+
+// GlossaryConf 一组同义术语（如中英文混用的领域词），查询包含其中任一术语时追加其余术语
+type GlossaryConf struct {
+	Terms []string
+}
@@ -13,8 +13,31 @@ type VectorStoreConf struct {
 	// 具体实现配置
 	Weaviate        WeaviateConf // Weaviate配置
 	FetchSourceCode bool         `json:",default=false"` // 是否获取源码
-	StoreSourceCode bool         `json:",default=false"` // 是否存储源码
+	StoreSourceCode bool         `json:",default=false"` // 是否存储源码，notebook 分块和层级摘要总是存储
+	StoreDocstring  bool         `json:",default=true"`  // 是否存储文档注释，用于按文档关键词检索
 	BaseURL         string       `json:",optional"`      // 获取代码内容的基础URL
+	// 生成/第三方代码在查询结果中的分数权重，取值(0,1]，1表示不降权
+	GeneratedCodeWeight float32 `json:",default=0.5"`
+	// 模型迁移期间仍服务于未切换租户查询的旧嵌入模型，每个模型对应一个命名向量槽位
+	PreviousEmbedders []EmbedderConf `json:",optional"`
+	Migration         VectorMigrationConf
+	// 检索结果后处理的默认值，可按请求覆盖
+	Result QueryResultConf
+}
+
+// QueryResultConf 检索结果后处理配置
+type QueryResultConf struct {
+	MergeAdjacent bool    `json:",default=true"` // 合并同一文件中行范围重叠或相邻的结果
+	Diversity     float32 `json:",default=0"`    // MMR 多样性权重，取值[0,1]，0 表示只按相关性排序
+	MaxPerFile    int     `json:",default=0"`    // 每个文件最多返回的结果数，0 表示不限制
+}
+
+// VectorMigrationConf 向量槽位迁移（重新嵌入）任务配置
+type VectorMigrationConf struct {
+	PageSize int           `json:",default=200"` // 每次从向量库读取的对象数
+	Timeout  time.Duration `json:",default=2h"`  // 单个代码库的迁移超时时间
+	// 旧版本单向量类中向量对应的嵌入模型（Embedder 或 PreviousEmbedders 中的 Model），为空表示与当前模型相同
+	LegacyModel string `json:",optional"`
 }
 
 // WeaviateConf Weaviate向量数据库配置
@@ -30,19 +53,70 @@ type WeaviateConf struct {
 // EmbedderConf 嵌入模型配置
 type EmbedderConf struct {
 	// 通用配置
-	Timeout       time.Duration
-	MaxRetries    int
-	BatchSize     int
-	Model         string // 模型名称（如text-embedding-ada-002）
-	APIKey        string // API密钥
-	APIBase       string // API基础URL
+	Timeout        time.Duration
+	MaxRetries     int
+	BatchSize      int
+	MaxBatchTokens int    `json:",optional"`  // 单批最大token数，0表示仅按BatchSize分批
+	MaxConcurrency int    `json:",default=4"` // 并发请求的批次数
+	Model          string // 模型名称（如text-embedding-ada-002）
+	// 模型版本，与模型名称共同决定命名向量槽位；同名模型权重变化时需要修改
+	ModelVersion  string `json:",optional"`
+	APIKey        string `json:",optional"` // API密钥
+	APIBase       string `json:",optional"` // API基础URL
 	StripNewLines bool
+	// 嵌入服务提供方：openai(OpenAI兼容接口)、ollama、tei(HuggingFace Text Embeddings Inference)、hash(本地确定性哈希，仅用于测试)
+	Provider       string `json:",default=openai,options=openai|ollama|tei|hash"`
+	Dimensions     int    `json:",optional"` // 向量维度，为0时使用提供方对该模型的默认值
+	MaxInputTokens int    `json:",optional"` // 单条输入最大token数，为0时使用提供方对该模型的默认值
+	// 多副本端点，配置后忽略 APIBase，按权重负载均衡并在故障时切换
+	Endpoints []EmbedderEndpointConf `json:",optional"`
+	Breaker   EndpointBreakerConf
+	// 与模型一致的分词器，用于切分分块和按 token 数打包请求
+	Tokenizer TokenizerConf
+}
+
+// TokenizerConf 分词器配置
+type TokenizerConf struct {
+	// cl100k、o200k(OpenAI tiktoken)、huggingface(从本地 tokenizer.json 加载，用于 BGE、Jina、Qwen 等模型)
+	Type string `json:",default=cl100k,options=cl100k|o200k|huggingface"`
+	Path string `json:",optional"` // huggingface 类型的 tokenizer.json 本地路径
+}
+
+// EmbedderEndpointConf 嵌入服务端点配置
+type EmbedderEndpointConf struct {
+	APIBase        string
+	APIKey         string `json:",optional"`  // 为空时使用 EmbedderConf.APIKey
+	Weight         int    `json:",default=1"` // 负载均衡权重
+	MaxConcurrency int    `json:",optional"`  // 最大并发请求数，0 表示不限制
+}
+
+// EndpointBreakerConf 端点熔断配置
+type EndpointBreakerConf struct {
+	FailureThreshold int           `json:",default=5"`   // 连续失败多少次后熔断
+	OpenDuration     time.Duration `json:",default=30s"` // 熔断持续时间，之后放行一个探测请求
 }
 
 type RerankerConf struct {
-	Timeout    time.Duration
-	MaxRetries int
-	Model      string // 模型名称（如text-embedding-ada-002）
-	APIKey     string // API密钥
-	APIBase    string // API基础URL
+	Timeout    time.Duration `json:",default=30s"`
+	MaxRetries int           `json:",optional"`
+	Model      string        `json:",optional"` // 模型名称（如text-embedding-ada-002）
+	APIKey     string        `json:",optional"` // API密钥
+	APIBase    string        `json:",optional"` // API基础URL
+	// 重排提供方：http(重排服务)、local(本地 BM25、标识符重合度和路径匹配)
+	Provider string `json:",default=http"`
+	// 主提供方失败时使用的提供方，为空时保留向量检索顺序
+	Fallback string `json:",optional"`
+	// 重排分数与向量检索分数的融合方式：none(仅使用重排分数)、rrf(倒数排名融合，缩放到[0,1])、linear(归一化后加权求和)
+	Fusion string `json:",default=none,options=none|rrf|linear"`
+	// linear 融合时重排分数的权重，取值[0,1]
+	FusionWeight float32 `json:",default=0.7"`
+	Local        LocalRerankerConf
+}
+
+// LocalRerankerConf 本地重排配置，分数为候选集内归一化的 BM25 分数加上标识符重合度和路径匹配的加成
+type LocalRerankerConf struct {
+	K1               float64 `json:",default=1.2"`  // BM25 词频饱和参数
+	B                float64 `json:",default=0.75"` // BM25 文档长度归一化参数
+	IdentifierWeight float64 `json:",default=0.5"`  // 查询中的标识符在分块中出现的比例的权重
+	PathWeight       float64 `json:",default=0.3"`  // 查询词在文件路径中出现的比例的权重
 }
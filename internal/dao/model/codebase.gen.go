@@ -22,6 +22,8 @@ type Codebase struct {
 	FileCount     int32      `gorm:"column:file_count;not null;comment:Number of files in the project" json:"file_count"`                         // Number of files in the project
 	TotalSize     int64      `gorm:"column:total_size;not null;comment:Total size of the project (in bytes)" json:"total_size"`                   // Total size of the project (in bytes)
 	ExtraMetadata *string    `gorm:"column:extra_metadata;comment:Additional metadata about the project" json:"extra_metadata"`                   // Additional metadata about the project
+	VectorSlot    string     `gorm:"column:vector_slot;not null;comment:Named vector slot used for queries, switched after re-embedding with a new model" json:"vector_slot"` // Named vector slot used for queries, switched after re-embedding with a new model
+	EnableSummary bool       `gorm:"column:enable_summary;not null;comment:Whether to generate natural-language summaries of function and class chunks with an LLM" json:"enable_summary"` // Whether to generate natural-language summaries of function and class chunks with an LLM
 	CreatedAt     *time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;comment:Time when the record was created" json:"created_at"`      // Time when the record was created
 	UpdatedAt     *time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP;comment:Time when the record was last updated" json:"updated_at"` // Time when the record was last updated
 }
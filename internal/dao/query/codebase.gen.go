@@ -37,6 +37,8 @@ func newCodebase(db *gorm.DB, opts ...gen.DOOption) codebase {
 	_codebase.FileCount = field.NewInt32(tableName, "file_count")
 	_codebase.TotalSize = field.NewInt64(tableName, "total_size")
 	_codebase.ExtraMetadata = field.NewString(tableName, "extra_metadata")
+	_codebase.VectorSlot = field.NewString(tableName, "vector_slot")
+	_codebase.EnableSummary = field.NewBool(tableName, "enable_summary")
 	_codebase.CreatedAt = field.NewTime(tableName, "created_at")
 	_codebase.UpdatedAt = field.NewTime(tableName, "updated_at")
 
@@ -59,6 +61,8 @@ type codebase struct {
 	FileCount     field.Int32  // Number of files in the project
 	TotalSize     field.Int64  // Total size of the project (in bytes)
 	ExtraMetadata field.String // Additional metadata about the project
+	VectorSlot    field.String // Named vector slot used for queries, switched after re-embedding with a new model
+	EnableSummary field.Bool   // Whether to generate natural-language summaries of function and class chunks with an LLM
 	CreatedAt     field.Time   // Time when the record was created
 	UpdatedAt     field.Time   // Time when the record was last updated
 
@@ -87,6 +91,8 @@ func (c *codebase) updateTableName(table string) *codebase {
 	c.FileCount = field.NewInt32(table, "file_count")
 	c.TotalSize = field.NewInt64(table, "total_size")
 	c.ExtraMetadata = field.NewString(table, "extra_metadata")
+	c.VectorSlot = field.NewString(table, "vector_slot")
+	c.EnableSummary = field.NewBool(table, "enable_summary")
 	c.CreatedAt = field.NewTime(table, "created_at")
 	c.UpdatedAt = field.NewTime(table, "updated_at")
 
@@ -113,7 +119,7 @@ func (c *codebase) GetFieldByName(fieldName string) (field.OrderExpr, bool) {
 }
 
 func (c *codebase) fillFieldMap() {
-	c.fieldMap = make(map[string]field.Expr, 12)
+	c.fieldMap = make(map[string]field.Expr, 14)
 	c.fieldMap["id"] = c.ID
 	c.fieldMap["client_id"] = c.ClientID
 	c.fieldMap["user_id"] = c.UserID
@@ -124,6 +130,8 @@ func (c *codebase) fillFieldMap() {
 	c.fieldMap["file_count"] = c.FileCount
 	c.fieldMap["total_size"] = c.TotalSize
 	c.fieldMap["extra_metadata"] = c.ExtraMetadata
+	c.fieldMap["vector_slot"] = c.VectorSlot
+	c.fieldMap["enable_summary"] = c.EnableSummary
 	c.fieldMap["created_at"] = c.CreatedAt
 	c.fieldMap["updated_at"] = c.UpdatedAt
 }
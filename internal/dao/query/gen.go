@@ -17,26 +17,29 @@ import (
 
 func Use(db *gorm.DB, opts ...gen.DOOption) *Query {
 	return &Query{
-		db:           db,
-		Codebase:     newCodebase(db, opts...),
-		IndexHistory: newIndexHistory(db, opts...),
+		db:             db,
+		Codebase:       newCodebase(db, opts...),
+		EmbeddingUsage: newEmbeddingUsage(db, opts...),
+		IndexHistory:   newIndexHistory(db, opts...),
 	}
 }
 
 type Query struct {
 	db *gorm.DB
 
-	Codebase     codebase
-	IndexHistory indexHistory
+	Codebase       codebase
+	EmbeddingUsage embeddingUsage
+	IndexHistory   indexHistory
 }
 
 func (q *Query) Available() bool { return q.db != nil }
 
 func (q *Query) clone(db *gorm.DB) *Query {
 	return &Query{
-		db:           db,
-		Codebase:     q.Codebase.clone(db),
-		IndexHistory: q.IndexHistory.clone(db),
+		db:             db,
+		Codebase:       q.Codebase.clone(db),
+		EmbeddingUsage: q.EmbeddingUsage.clone(db),
+		IndexHistory:   q.IndexHistory.clone(db),
 	}
 }
 
@@ -50,21 +53,24 @@ func (q *Query) WriteDB() *Query {
 
 func (q *Query) ReplaceDB(db *gorm.DB) *Query {
 	return &Query{
-		db:           db,
-		Codebase:     q.Codebase.replaceDB(db),
-		IndexHistory: q.IndexHistory.replaceDB(db),
+		db:             db,
+		Codebase:       q.Codebase.replaceDB(db),
+		EmbeddingUsage: q.EmbeddingUsage.replaceDB(db),
+		IndexHistory:   q.IndexHistory.replaceDB(db),
 	}
 }
 
 type queryCtx struct {
-	Codebase     *codebaseDo
-	IndexHistory *indexHistoryDo
+	Codebase       *codebaseDo
+	EmbeddingUsage *embeddingUsageDo
+	IndexHistory   *indexHistoryDo
 }
 
 func (q *Query) WithContext(ctx context.Context) *queryCtx {
 	return &queryCtx{
-		Codebase:     q.Codebase.WithContext(ctx),
-		IndexHistory: q.IndexHistory.WithContext(ctx),
+		Codebase:       q.Codebase.WithContext(ctx),
+		EmbeddingUsage: q.EmbeddingUsage.WithContext(ctx),
+		IndexHistory:   q.IndexHistory.WithContext(ctx),
 	}
 }
 
@@ -0,0 +1,275 @@
+package embedding
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/oasdiff/yaml"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+const (
+	AsyncAPI2 APIVersion = "asyncapi2"
+	AsyncAPI3 APIVersion = "asyncapi3"
+)
+
+// httpMethods OpenAPI/Swagger 路径下的操作字段
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// schemaRefPrefixes 指向具名 schema 的引用前缀：OpenAPI 3.x、AsyncAPI 的 components.schemas 和 Swagger 2.0 的 definitions
+var schemaRefPrefixes = []string{"#/components/schemas/", "#/definitions/"}
+
+// parseAPIDocument 将 YAML/JSON 格式的 API 描述文档解析为通用结构，用于提取操作元数据
+func parseAPIDocument(content []byte) (map[string]any, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// httpOperations 返回 OpenAPI/Swagger 文档中一个路径下各 HTTP 操作的元数据
+func httpOperations(doc map[string]any, path string) []types.APIOperation {
+	pathItem, _ := lookupMap(doc, "paths", path)
+	var operations []types.APIOperation
+	for _, method := range httpMethods {
+		op, ok := pathItem[method].(map[string]any)
+		if !ok {
+			continue
+		}
+		operations = append(operations, types.APIOperation{
+			Method:      strings.ToUpper(method),
+			Path:        path,
+			OperationID: stringField(op, "operationId"),
+			Tags:        tagNames(op["tags"]),
+			// 路径级参数对该路径下的所有操作生效
+			Schemas: schemaNames(doc, op, pathItem["parameters"]),
+		})
+	}
+	return operations
+}
+
+// schemaNames 收集节点中直接或间接（经由 parameters、responses、messages 等组件）引用的具名 schema
+func schemaNames(doc map[string]any, nodes ...any) []string {
+	var (
+		names []string
+		seen  = make(map[string]bool)
+		walk  func(node any)
+	)
+	walk = func(node any) {
+		switch v := node.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				if seen[ref] {
+					return
+				}
+				seen[ref] = true
+				for _, prefix := range schemaRefPrefixes {
+					if name, ok := strings.CutPrefix(ref, prefix); ok {
+						names = append(names, name)
+						return
+					}
+				}
+				// 其他组件引用解析后继续查找其中的 schema 引用
+				if target, ok := resolveLocalRef(doc, ref); ok {
+					walk(target)
+				}
+				return
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	for _, node := range nodes {
+		walk(node)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// resolveLocalRef 解析文档内的 JSON Pointer 引用，如 #/components/messages/UserSignedUp
+func resolveLocalRef(doc map[string]any, ref string) (any, bool) {
+	pointer, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil, false
+	}
+	var node any = doc
+	for _, token := range strings.Split(pointer, "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if node, ok = m[token]; !ok {
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+func lookupMap(doc map[string]any, keys ...string) (map[string]any, bool) {
+	node := doc
+	for _, key := range keys {
+		next, ok := node[key].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		node = next
+	}
+	return node, true
+}
+
+func stringField(m map[string]any, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+// tagNames 提取操作的标签名，兼容字符串数组（OpenAPI）和 {name} 对象数组（AsyncAPI）
+func tagNames(v any) []string {
+	items, _ := v.([]any)
+	var tags []string
+	for _, item := range items {
+		switch tag := item.(type) {
+		case string:
+			tags = append(tags, tag)
+		case map[string]any:
+			if name := stringField(tag, "name"); name != "" {
+				tags = append(tags, name)
+			}
+		}
+	}
+	return tags
+}
+
+// splitAsyncAPIFile 按通道切分 AsyncAPI 2.x/3.x 文档，每个分块为只包含一个通道（及 3.x 中使用该通道的操作）的文档，
+// 并记录 publish/subscribe（2.x）或 send/receive（3.x）操作的元数据
+func (p *CodeSplitter) splitAsyncAPIFile(codeFile *types.SourceFile, version APIVersion) ([]*types.CodeChunk, error) {
+	doc, err := parseAPIDocument(codeFile.Content)
+	if err != nil {
+		return nil, fmt.Errorf("asyncapi 解析失败: %v", err)
+	}
+	channels, ok := lookupMap(doc, "channels")
+	if !ok {
+		return nil, fmt.Errorf("缺少 channels 字段")
+	}
+	info, _ := lookupMap(doc, "info")
+	title := stringField(info, "title")
+
+	names := make([]string, 0, len(channels))
+	for name := range channels {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var chunks []*types.CodeChunk
+	for _, name := range names {
+		channel, _ := channels[name].(map[string]any)
+		// 浅拷贝文档，只保留当前通道
+		newDoc := make(map[string]any, len(doc))
+		for k, v := range doc {
+			newDoc[k] = v
+		}
+		newInfo := make(map[string]any, len(info)+1)
+		for k, v := range info {
+			newInfo[k] = v
+		}
+		newInfo["title"] = fmt.Sprintf("%s - %s", title, name)
+		newDoc["info"] = newInfo
+		newDoc["channels"] = map[string]any{name: channel}
+
+		var operations []types.APIOperation
+		if version == AsyncAPI2 {
+			operations = asyncAPI2Operations(doc, name, channel)
+		} else {
+			var channelOps map[string]any
+			operations, channelOps = asyncAPI3Operations(doc, name, channel)
+			newDoc["operations"] = channelOps
+		}
+
+		docBytes, err := json.Marshal(newDoc)
+		if err != nil {
+			return nil, fmt.Errorf("序列化 AsyncAPI 文档失败: %v", err)
+		}
+		chunks = append(chunks, &types.CodeChunk{
+			Language:      LanguageTypeDoc,
+			CodebaseId:    codeFile.CodebaseId,
+			CodebasePath:  codeFile.CodebasePath,
+			CodebaseName:  codeFile.CodebaseName,
+			Content:       docBytes,
+			FilePath:      codeFile.Path,
+			Range:         []int{0, 0, 0, 0}, // AsyncAPI 分割不涉及行号
+			TokenCount:    p.countToken(docBytes),
+			APIOperations: operations,
+		})
+	}
+	return chunks, nil
+}
+
+// asyncAPI2Operations 返回 AsyncAPI 2.x 通道的 publish、subscribe 操作，路径为通道名
+func asyncAPI2Operations(doc map[string]any, name string, channel map[string]any) []types.APIOperation {
+	var operations []types.APIOperation
+	for _, action := range []string{"publish", "subscribe"} {
+		op, ok := channel[action].(map[string]any)
+		if !ok {
+			continue
+		}
+		operations = append(operations, types.APIOperation{
+			Method:      strings.ToUpper(action),
+			Path:        name,
+			OperationID: stringField(op, "operationId"),
+			Tags:        tagNames(op["tags"]),
+			Schemas:     schemaNames(doc, op["message"], channel["parameters"]),
+		})
+	}
+	return operations
+}
+
+// asyncAPI3Operations 返回 AsyncAPI 3.x 中使用该通道的 send、receive 操作及其原始定义，
+// 路径为通道地址（未设置时为通道名），操作 ID 为 operations 中的键
+func asyncAPI3Operations(doc map[string]any, name string, channel map[string]any) ([]types.APIOperation, map[string]any) {
+	path := cmp.Or(stringField(channel, "address"), name)
+	channelRef := "#/channels/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+	allOps, _ := lookupMap(doc, "operations")
+
+	ids := make([]string, 0, len(allOps))
+	for id := range allOps {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	var (
+		operations []types.APIOperation
+		channelOps = make(map[string]any)
+	)
+	for _, id := range ids {
+		op, ok := allOps[id].(map[string]any)
+		if !ok {
+			continue
+		}
+		if ref, _ := lookupMap(op, "channel"); stringField(ref, "$ref") != channelRef {
+			continue
+		}
+		channelOps[id] = op
+		// 操作未列出消息时使用通道的全部消息
+		messages := op["messages"]
+		if messages == nil {
+			messages = channel["messages"]
+		}
+		operations = append(operations, types.APIOperation{
+			Method:      strings.ToUpper(stringField(op, "action")),
+			Path:        path,
+			OperationID: id,
+			Tags:        tagNames(op["tags"]),
+			Schemas:     schemaNames(doc, messages, channel["parameters"]),
+		})
+	}
+	return operations, channelOps
+}
@@ -0,0 +1,141 @@
+package embedding
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+func splitAPIDocument(t *testing.T, path, content string) []*types.CodeChunk {
+	splitter, err := NewCodeSplitter(SplitOptions{MaxTokensPerChunk: 1000, EnableOpenAPIParsing: true, EnableStructuredParsing: true})
+	require.NoError(t, err)
+	chunks, err := splitter.Split(&types.SourceFile{Path: path, Content: []byte(content)})
+	require.NoError(t, err)
+	return chunks
+}
+
+func TestOpenAPIOperations(t *testing.T) {
+	content := `openapi: 3.0.3
+info:
+  title: Users
+  version: 1.0.0
+paths:
+  /users/{id}:
+    parameters:
+      - $ref: '#/components/parameters/UserId'
+    get:
+      operationId: getUser
+      tags: [users]
+      responses:
+        "200":
+          $ref: '#/components/responses/UserResponse'
+    delete:
+      operationId: deleteUser
+      tags: [users, admin]
+      responses:
+        "204":
+          description: deleted
+components:
+  parameters:
+    UserId:
+      name: id
+      in: path
+      required: true
+      schema:
+        $ref: '#/components/schemas/Id'
+  responses:
+    UserResponse:
+      description: user
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/User'
+  schemas:
+    Id:
+      type: string
+    User:
+      type: object
+`
+	chunks := splitAPIDocument(t, "api/users.yaml", content)
+	require.Len(t, chunks, 1)
+	assert.Equal(t, []types.APIOperation{
+		{Method: "GET", Path: "/users/{id}", OperationID: "getUser", Tags: []string{"users"}, Schemas: []string{"Id", "User"}},
+		{Method: "DELETE", Path: "/users/{id}", OperationID: "deleteUser", Tags: []string{"users", "admin"}, Schemas: []string{"Id"}},
+	}, chunks[0].APIOperations)
+}
+
+func TestSplitAsyncAPI2(t *testing.T) {
+	content := `asyncapi: 2.6.0
+info:
+  title: Accounts
+  version: 1.0.0
+channels:
+  user/signedup:
+    subscribe:
+      operationId: onUserSignedUp
+      tags:
+        - name: user
+      message:
+        $ref: '#/components/messages/UserSignedUp'
+  user/deleted:
+    publish:
+      operationId: deleteUser
+      message:
+        payload:
+          type: object
+components:
+  messages:
+    UserSignedUp:
+      payload:
+        $ref: '#/components/schemas/User'
+  schemas:
+    User:
+      type: object
+`
+	chunks := splitAPIDocument(t, "asyncapi.yaml", content)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, []types.APIOperation{{Method: "PUBLISH", Path: "user/deleted", OperationID: "deleteUser"}}, chunks[0].APIOperations)
+	assert.Equal(t, []types.APIOperation{
+		{Method: "SUBSCRIBE", Path: "user/signedup", OperationID: "onUserSignedUp", Tags: []string{"user"}, Schemas: []string{"User"}},
+	}, chunks[1].APIOperations)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(chunks[1].Content, &doc))
+	assert.Len(t, doc["channels"], 1)
+	assert.Equal(t, "Accounts - user/signedup", doc["info"].(map[string]any)["title"])
+}
+
+func TestSplitAsyncAPI3(t *testing.T) {
+	content := `{
+  "asyncapi": "3.0.0",
+  "info": {"title": "Orders", "version": "1.0.0"},
+  "channels": {
+    "orderCreated": {
+      "address": "orders.{region}.created",
+      "messages": {"OrderCreated": {"payload": {"$ref": "#/components/schemas/Order"}}}
+    },
+    "orderShipped": {"address": "orders.shipped"}
+  },
+  "operations": {
+    "publishOrderCreated": {"action": "send", "channel": {"$ref": "#/channels/orderCreated"}},
+    "shipOrder": {"action": "receive", "channel": {"$ref": "#/channels/orderShipped"}, "tags": [{"name": "shipping"}]}
+  },
+  "components": {"schemas": {"Order": {"type": "object"}}}
+}`
+	chunks := splitAPIDocument(t, "asyncapi.json", content)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, []types.APIOperation{
+		{Method: "SEND", Path: "orders.{region}.created", OperationID: "publishOrderCreated", Schemas: []string{"Order"}},
+	}, chunks[0].APIOperations)
+	assert.Equal(t, []types.APIOperation{
+		{Method: "RECEIVE", Path: "orders.shipped", OperationID: "shipOrder", Tags: []string{"shipping"}},
+	}, chunks[1].APIOperations)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(chunks[1].Content, &doc))
+	assert.Len(t, doc["operations"], 1)
+}
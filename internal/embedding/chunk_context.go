@@ -0,0 +1,126 @@
+package embedding
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+
+	sitter "github.com/tree-sitter/go-tree-sitter"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+// DefaultContextTemplate 默认的上下文头模板，渲染结果拼接在代码前用于生成向量
+const DefaultContextTemplate = `File: {{.FilePath}}
+{{- with .Package}}
+Package: {{.}}{{end}}
+{{- with .Scopes}}
+Scope: {{join . " > "}}{{end}}
+{{- with .Signature}}
+Signature: {{.}}{{end}}
+{{- with .Imports}}
+Imports: {{join . "; "}}{{end}}`
+
+const (
+	maxImportsSummaryLength = 300 // 导入摘要的最大字节数
+	maxSignatureLength      = 200 // 签名的最大字节数
+)
+
+// ChunkContext 分块所在的代码上下文，作为上下文头模板的输入
+type ChunkContext struct {
+	FilePath  string
+	Language  string
+	Package   string   // 包或命名空间
+	Scopes    []string // 由外到内的外层作用域名称，如类、模块
+	Signature string   // 分块所属节点的首行，滑动窗口切分的分块借此保留函数签名
+	Imports   []string // 文件顶层导入声明的摘要
+}
+
+// newContextTemplate 解析上下文头模板，并用示例数据校验模板可以正常渲染
+func newContextTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("context").Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse context template: %w", err)
+	}
+	sample := ChunkContext{FilePath: "a.go", Language: "go", Package: "a", Scopes: []string{"A"}, Signature: "func A()", Imports: []string{`import "fmt"`}}
+	if err = tmpl.Execute(&strings.Builder{}, sample); err != nil {
+		return nil, fmt.Errorf("failed to execute context template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// applyContextHeader 渲染上下文头并写入分块，只影响嵌入文本，不改变分块内容
+func (p *CodeSplitter) applyContextHeader(chunks []*types.CodeChunk, chunkCtx ChunkContext) {
+	if p.contextTemplate == nil || len(chunks) == 0 {
+		return
+	}
+	var sb strings.Builder
+	if err := p.contextTemplate.Execute(&sb, chunkCtx); err != nil {
+		return
+	}
+	header := strings.TrimSpace(sb.String())
+	for _, chunk := range chunks {
+		chunk.EmbeddingHeader = header
+	}
+}
+
+// fileContext 从语法树顶层节点提取包名和导入摘要
+func fileContext(codeFile *types.SourceFile, language string, rule *chunkRule, root *sitter.Node) ChunkContext {
+	chunkCtx := ChunkContext{FilePath: codeFile.Path, Language: language}
+	importsLength := 0
+	for i := uint(0); i < root.NamedChildCount(); i++ {
+		child := root.NamedChild(i)
+		if _, ok := rule.packages[child.Kind()]; ok && chunkCtx.Package == "" {
+			chunkCtx.Package = nodeName(child, codeFile.Content)
+		}
+		if _, ok := rule.imports[child.Kind()]; ok {
+			if importsLength >= maxImportsSummaryLength {
+				chunkCtx.Imports = append(chunkCtx.Imports, "...")
+				break
+			}
+			text := strings.TrimSuffix(strings.Join(strings.Fields(child.Utf8Text(codeFile.Content)), " "), ";")
+			chunkCtx.Imports = append(chunkCtx.Imports, text)
+			importsLength += len(text)
+		}
+	}
+	return chunkCtx
+}
+
+// nodeContext 在文件上下文的基础上补充节点的外层作用域和签名
+func nodeContext(fileCtx ChunkContext, node *sitter.Node, rule *chunkRule, content []byte) ChunkContext {
+	var scopes []string
+	for parent := node.Parent(); parent != nil; parent = parent.Parent() {
+		if _, ok := rule.scopes[parent.Kind()]; !ok {
+			continue
+		}
+		if name := nodeName(parent, content); name != "" {
+			scopes = append(scopes, name)
+		}
+	}
+	slices.Reverse(scopes)
+	fileCtx.Scopes = scopes
+
+	signature, _, _ := strings.Cut(node.Utf8Text(content), "\n")
+	signature = strings.TrimSpace(signature)
+	if len(signature) > maxSignatureLength {
+		signature = strings.ToValidUTF8(signature[:maxSignatureLength], "") + "..."
+	}
+	fileCtx.Signature = signature
+	return fileCtx
+}
+
+// nodeName 获取声明节点的名称，优先使用 name 字段，其次是 type 字段（如 Rust impl），最后是首个标识符子节点
+func nodeName(node *sitter.Node, content []byte) string {
+	for _, field := range []string{"name", "type"} {
+		if child := node.ChildByFieldName(field); child != nil {
+			return strings.Join(strings.Fields(child.Utf8Text(content)), " ")
+		}
+	}
+	for i := uint(0); i < node.NamedChildCount(); i++ {
+		child := node.NamedChild(i)
+		if strings.Contains(child.Kind(), "identifier") || strings.Contains(child.Kind(), "name") {
+			return strings.Join(strings.Fields(child.Utf8Text(content)), " ")
+		}
+	}
+	return ""
+}
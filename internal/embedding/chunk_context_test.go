@@ -0,0 +1,62 @@
+package embedding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+func TestSplitWithContextHeader(t *testing.T) {
+	source := &types.SourceFile{
+		Path: "src/main/java/com/example/UserService.java",
+		Content: []byte(`package com.example.service;
+
+import java.util.List;
+import java.util.Map;
+
+public class UserService {
+    static class Cache {
+        public List<String> load(String key) {
+            return List.of(key);
+        }
+    }
+}
+`),
+	}
+
+	splitter, err := NewCodeSplitter(SplitOptions{
+		MaxTokensPerChunk:          1000,
+		SlidingWindowOverlapTokens: 100,
+		ChunkRules:                 []ChunkRuleOverride{{Language: "java", Kinds: []string{"method_declaration"}}},
+		ContextTemplate:            DefaultContextTemplate,
+	})
+	require.NoError(t, err)
+
+	chunks, err := splitter.Split(source)
+	require.NoError(t, err)
+
+	var method *types.CodeChunk
+	for _, chunk := range chunks {
+		if chunk.Range[0] == 7 {
+			method = chunk
+		}
+	}
+	require.NotNil(t, method)
+	// 存储内容保持原始代码，上下文只出现在嵌入文本的头部
+	assert.Equal(t, "public List<String> load(String key) {\n            return List.of(key);\n        }", string(method.Content))
+	assert.Equal(t, "File: src/main/java/com/example/UserService.java\n"+
+		"Package: com.example.service\n"+
+		"Scope: UserService > Cache\n"+
+		"Signature: public List<String> load(String key) {\n"+
+		"Imports: import java.util.List; import java.util.Map", method.EmbeddingHeader)
+
+	// 剩余代码分块只带文件级上下文
+	assert.Equal(t, "package com.example.service;\n\nimport java.util.List;\nimport java.util.Map;\n\npublic class UserService {\n    static class Cache {", string(chunks[0].Content))
+	assert.NotContains(t, chunks[0].EmbeddingHeader, "Scope:")
+
+	_, err = NewCodeSplitter(SplitOptions{MaxTokensPerChunk: 1000, ContextTemplate: "{{.Unknown}}"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+package embedding
+
+import (
+	"strings"
+
+	sitter "github.com/tree-sitter/go-tree-sitter"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+// mergeScope 分块所属的语法作用域，同一作用域内相邻的小分块可以合并
+type mergeScope struct {
+	id  uintptr      // 父节点标识
+	ctx ChunkContext // 合并后用于渲染上下文头
+}
+
+// remainderScope 返回包含剩余代码分块的最小语法节点，分块恰好覆盖的节点取其父节点
+func remainderScope(root *sitter.Node, lineStarts []int, chunk *types.CodeChunk) *sitter.Node {
+	start, end := rangeBytes(lineStarts, chunk.Range)
+	node := root.NamedDescendantForByteRange(start, end)
+	for node != nil && node.StartByte() >= start && node.EndByte() <= end && node.Id() != root.Id() {
+		node = node.Parent()
+	}
+	return node
+}
+
+// mergeSmallChunks 合并同一作用域内相邻的小分块，合并后的分块通过 SubRanges 保留各原始分块的范围
+func (p *CodeSplitter) mergeSmallChunks(codeFile *types.SourceFile, lineStarts []int, chunks []*types.CodeChunk,
+	scopes map[*types.CodeChunk]mergeScope) []*types.CodeChunk {
+	smallTokens, targetTokens := p.splitOptions.MergeSmallTokens, min(p.splitOptions.MergeTargetTokens, p.splitOptions.MaxTokensPerChunk)
+	if smallTokens <= 0 || targetTokens <= 0 {
+		return chunks
+	}
+	isSmall := func(chunk *types.CodeChunk) bool {
+		_, ok := scopes[chunk]
+		return ok && chunk.TokenCount < smallTokens
+	}
+
+	merged := make([]*types.CodeChunk, 0, len(chunks))
+	for i := 0; i < len(chunks); {
+		first := chunks[i]
+		if !isSmall(first) {
+			merged = append(merged, first)
+			i++
+			continue
+		}
+		scope := scopes[first]
+		start, end := rangeBytes(lineStarts, first.Range)
+		tokenCount := first.TokenCount
+		j := i + 1
+		for ; j < len(chunks) && isSmall(chunks[j]) && scopes[chunks[j]].id == scope.id; j++ {
+			_, nextEnd := rangeBytes(lineStarts, chunks[j].Range)
+			nextTokens := p.countToken(codeFile.Content[start:nextEnd])
+			if nextTokens > targetTokens {
+				break
+			}
+			end, tokenCount = nextEnd, nextTokens
+		}
+		if j == i+1 {
+			merged = append(merged, first)
+			i++
+			continue
+		}
+
+		last := chunks[j-1]
+		chunk := &types.CodeChunk{
+			Language:     first.Language,
+			CodebaseId:   codeFile.CodebaseId,
+			CodebasePath: codeFile.CodebasePath,
+			CodebaseName: codeFile.CodebaseName,
+			Content:      codeFile.Content[start:end],
+			FilePath:     codeFile.Path,
+			Range:        []int{first.Range[0], first.Range[1], last.Range[2], last.Range[3]},
+			TokenCount:   tokenCount,
+		}
+		chunk.Breadcrumb = commonBreadcrumb(chunks[i:j])
+		var docstrings []string
+		for _, c := range chunks[i:j] {
+			chunk.SubRanges = append(chunk.SubRanges, c.Range)
+			if c.Docstring != "" {
+				docstrings = append(docstrings, c.Docstring)
+			}
+		}
+		chunk.Docstring = strings.Join(docstrings, "\n")
+		// 合并后的分块包含多个符号，没有共同签名
+		scope.ctx.Signature = ""
+		p.applyContextHeader([]*types.CodeChunk{chunk}, scope.ctx)
+		merged = append(merged, chunk)
+		i = j
+	}
+	return merged
+}
+
+// commonBreadcrumb 返回多个分块键路径的公共前缀
+func commonBreadcrumb(chunks []*types.CodeChunk) string {
+	common := strings.Split(chunks[0].Breadcrumb, BreadcrumbSeparator)
+	for _, chunk := range chunks[1:] {
+		path := strings.Split(chunk.Breadcrumb, BreadcrumbSeparator)
+		n := 0
+		for n < len(common) && n < len(path) && common[n] == path[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	return strings.Join(common, BreadcrumbSeparator)
+}
+
+// rangeBytes 将分块的行列范围转换为字节范围
+func rangeBytes(lineStarts []int, r []int) (uint, uint) {
+	return uint(lineStarts[r[0]] + r[1]), uint(lineStarts[r[2]] + r[3])
+}
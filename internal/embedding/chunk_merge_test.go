@@ -0,0 +1,52 @@
+package embedding
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+func TestMergeSmallChunks(t *testing.T) {
+	var body strings.Builder
+	for i := range 30 {
+		body.WriteString("\tresult = append(result, compute(input, " + strings.Repeat("x", i%5+1) + "))\n")
+	}
+	content := "package user\n\n" +
+		"func (u *User) Name() string { return u.name }\n\n" +
+		"func (u *User) Age() int { return u.age }\n\n" +
+		"func (u *User) Email() string { return u.email }\n\n" +
+		"func Process(input []int) []int {\n\tvar result []int\n" + body.String() + "\treturn result\n}\n\n" +
+		"func (u *User) ID() int64 { return u.id }\n"
+	source := &types.SourceFile{Path: "user.go", Content: []byte(content)}
+
+	splitter, err := NewCodeSplitter(SplitOptions{
+		MaxTokensPerChunk:          1000,
+		SlidingWindowOverlapTokens: 100,
+		MergeSmallTokens:           30,
+		MergeTargetTokens:          200,
+	})
+	require.NoError(t, err)
+	chunks, err := splitter.Split(source)
+	require.NoError(t, err)
+	require.Len(t, chunks, 3)
+
+	// 包声明与相邻的三个 getter 合并，保留各自的范围
+	merged := chunks[0]
+	assert.True(t, strings.HasPrefix(string(merged.Content), "package user"))
+	assert.True(t, strings.HasSuffix(string(merged.Content), "return u.email }"))
+	assert.Equal(t, []int{0, 0, 6, 48}, merged.Range)
+	assert.Equal(t, [][]int{{0, 0, 0, 12}, {2, 0, 2, 46}, {4, 0, 4, 41}, {6, 0, 6, 48}}, merged.SubRanges)
+	assert.Equal(t, splitter.countToken(merged.Content), merged.TokenCount)
+	// 合并后的分块包含多个符号，不记录声明节点类型
+	assert.Empty(t, merged.NodeKind)
+
+	// 大函数阻断合并，其后的 getter 单独成块
+	assert.True(t, strings.HasPrefix(string(chunks[1].Content), "func Process"))
+	assert.Nil(t, chunks[1].SubRanges)
+	assert.Equal(t, "function_declaration", chunks[1].NodeKind)
+	assert.Equal(t, "func (u *User) ID() int64 { return u.id }", string(chunks[2].Content))
+}
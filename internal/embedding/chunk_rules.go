@@ -0,0 +1,187 @@
+package embedding
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/oasdiff/yaml"
+	sitter "github.com/tree-sitter/go-tree-sitter"
+	"github.com/zgsm-ai/codebase-indexer/internal/parser"
+)
+
+//go:embed chunkrules/*.yaml
+var chunkRuleFS embed.FS
+
+const chunkRuleDir = "chunkrules"
+const chunkRuleExt = ".yaml"
+
+// ChunkRule 语言的语义切块规则，内置规则定义在 chunkrules/<language>.yaml
+type ChunkRule struct {
+	Kinds     []string   `json:"kinds"`     // 单独成块的语法节点类型
+	MinTokens int        `json:"minTokens"` // 低于该 token 数的节点不单独成块，0 表示不限制
+	Merge     [][]string `json:"merge"`     // 连续出现时合并为一个分块的节点类型分组
+	Package   []string   `json:"package"`   // 包或命名空间声明，用于上下文头
+	Scopes    []string   `json:"scopes"`    // 作为外层作用域的节点类型，用于上下文头
+	Imports   []string   `json:"imports"`   // 导入声明，用于上下文头
+	Attach    []string   `json:"attach"`    // 紧邻声明之前、并入分块的注释和注解节点
+	// 函数或类体的首个字符串语句是否作为文档字符串（如 Python docstring）
+	BodyDocstring bool `json:"bodyDocstring"`
+}
+
+// ChunkRuleOverride 配置中对内置切块规则的覆盖，未设置的字段沿用内置规则
+type ChunkRuleOverride struct {
+	Language  string
+	Kinds     []string
+	MinTokens int // 大于0时覆盖
+	Merge     [][]string
+}
+
+// defaultChunkRules 内置切块规则
+var defaultChunkRules = make(map[parser.Language]*ChunkRule)
+
+// chunkRule 编译后的切块规则
+type chunkRule struct {
+	kinds      map[string]struct{}
+	minTokens  int
+	mergeGroup map[string]int // 节点类型 -> 合并分组序号
+	packages   map[string]struct{}
+	scopes     map[string]struct{}
+	imports    map[string]struct{}
+	attach     map[string]struct{}
+	// 是否提取函数或类体中的文档字符串
+	bodyDocstring bool
+}
+
+func init() {
+	if err := loadChunkRules(); err != nil {
+		panic(fmt.Errorf("embedding load chunk rules err:%v", err))
+	}
+}
+
+func loadChunkRules() error {
+	for _, lang := range parser.GetLanguageConfigs() {
+		sitterLang := lang.SitterLanguage()
+		if sitterLang == nil {
+			// 没有 tree-sitter 解析器的语言（如 Markdown）由专门的切分逻辑处理
+			continue
+		}
+		rulePath := path.Join(chunkRuleDir, string(lang.Language)+chunkRuleExt)
+		content, err := chunkRuleFS.ReadFile(rulePath)
+		if err != nil {
+			return fmt.Errorf("failed to read chunk rule file %s: %w", rulePath, err)
+		}
+		var rule ChunkRule
+		if err = yaml.Unmarshal(content, &rule); err != nil {
+			return fmt.Errorf("failed to parse chunk rule file %s: %w", rulePath, err)
+		}
+		if err = validateChunkRule(sitterLang, &rule); err != nil {
+			return fmt.Errorf("invalid chunk rule file %s: %w", rulePath, err)
+		}
+		defaultChunkRules[lang.Language] = &rule
+	}
+	return nil
+}
+
+// validateChunkRule 校验规则中的节点类型都存在于语法中
+func validateChunkRule(sitterLang *sitter.Language, rule *ChunkRule) error {
+	if len(rule.Kinds) == 0 && len(rule.Merge) == 0 {
+		return fmt.Errorf("no chunk node kinds")
+	}
+	if rule.MinTokens < 0 {
+		return fmt.Errorf("minTokens must not be negative")
+	}
+	kinds := slices.Concat(rule.Kinds, rule.Package, rule.Scopes, rule.Imports, rule.Attach)
+	for _, group := range rule.Merge {
+		if len(group) == 0 {
+			return fmt.Errorf("empty merge group")
+		}
+		kinds = append(kinds, group...)
+	}
+	for _, kind := range kinds {
+		if sitterLang.IdForNodeKind(kind, true) == 0 {
+			return fmt.Errorf("node kind %q not found in grammar", kind)
+		}
+	}
+	return nil
+}
+
+// resolveChunkRules 合并内置规则与配置覆盖，返回编译后的规则
+func resolveChunkRules(overrides []ChunkRuleOverride) (map[parser.Language]*chunkRule, error) {
+	rules := make(map[parser.Language]ChunkRule, len(defaultChunkRules))
+	for lang, rule := range defaultChunkRules {
+		rules[lang] = *rule
+	}
+
+	for _, override := range overrides {
+		lang := parser.Language(strings.ToLower(override.Language))
+		langConf := findLanguageConfig(lang)
+		if langConf == nil || langConf.SitterLanguage() == nil {
+			return nil, fmt.Errorf("chunk rule override for unsupported language %q", override.Language)
+		}
+		rule := rules[lang]
+		if len(override.Kinds) > 0 {
+			rule.Kinds = override.Kinds
+		}
+		if override.MinTokens > 0 {
+			rule.MinTokens = override.MinTokens
+		}
+		if len(override.Merge) > 0 {
+			rule.Merge = override.Merge
+		}
+		if err := validateChunkRule(langConf.SitterLanguage(), &rule); err != nil {
+			return nil, fmt.Errorf("invalid chunk rule override for %s: %w", lang, err)
+		}
+		rules[lang] = rule
+	}
+
+	compiled := make(map[parser.Language]*chunkRule, len(rules))
+	for lang, rule := range rules {
+		compiled[lang] = compileChunkRule(rule)
+	}
+	return compiled, nil
+}
+
+func compileChunkRule(rule ChunkRule) *chunkRule {
+	compiled := &chunkRule{
+		kinds:      kindSet(rule.Kinds),
+		minTokens:  rule.MinTokens,
+		mergeGroup: make(map[string]int),
+		packages:   kindSet(rule.Package),
+		scopes:     kindSet(rule.Scopes),
+		imports:    kindSet(rule.Imports),
+		attach:     kindSet(rule.Attach),
+		// Python 等语言的文档字符串位于函数或类体内
+		bodyDocstring: rule.BodyDocstring,
+	}
+	for i, group := range rule.Merge {
+		for _, kind := range group {
+			compiled.mergeGroup[kind] = i
+		}
+	}
+	return compiled
+}
+
+func kindSet(kinds []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(kinds))
+	for _, kind := range kinds {
+		set[kind] = struct{}{}
+	}
+	return set
+}
+
+func findLanguageConfig(lang parser.Language) *parser.LanguageConfig {
+	for _, conf := range parser.GetLanguageConfigs() {
+		if conf.Language == lang {
+			return conf
+		}
+	}
+	return nil
+}
+
+// isCommentKind 判断节点是否为注释，合并兄弟节点时跳过注释
+func isCommentKind(kind string) bool {
+	return strings.Contains(kind, "comment")
+}
@@ -0,0 +1,90 @@
+package embedding
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/parser"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+func TestChunkRuleKindsExistInGrammar(t *testing.T) {
+	for _, lang := range parser.GetLanguageConfigs() {
+		sitterLang := lang.SitterLanguage()
+		if sitterLang == nil {
+			continue
+		}
+		rule, ok := defaultChunkRules[lang.Language]
+		require.True(t, ok, "missing chunk rule for %s", lang.Language)
+
+		kinds := slices.Concat(rule.Kinds, rule.Package, rule.Scopes, rule.Imports, rule.Attach)
+		for _, group := range rule.Merge {
+			kinds = append(kinds, group...)
+		}
+		assert.NotEmpty(t, rule.Kinds, lang.Language)
+		for _, kind := range kinds {
+			assert.NotZero(t, sitterLang.IdForNodeKind(kind, true), "%s: node kind %q not found in grammar", lang.Language, kind)
+		}
+	}
+}
+
+func TestChunkRuleOverrides(t *testing.T) {
+	source := &types.SourceFile{
+		Path: "main.go",
+		Content: []byte(`package main
+
+const a = 1
+const b = 2
+
+var c = 3
+
+func tiny() {}
+
+func main() {
+	println(a, b, c)
+	tiny()
+}
+`),
+	}
+
+	splitter, err := NewCodeSplitter(SplitOptions{MaxTokensPerChunk: 1000, SlidingWindowOverlapTokens: 100})
+	require.NoError(t, err)
+	chunks, err := splitter.Split(source)
+	require.NoError(t, err)
+	require.Len(t, chunks, 4)
+	assert.Equal(t, "package main", string(chunks[0].Content))
+	// 连续的常量和变量声明合并为一个分块
+	assert.Equal(t, "const a = 1\nconst b = 2\n\nvar c = 3", string(chunks[1].Content))
+	assert.Equal(t, []int{2, 0, 5, 9}, chunks[1].Range)
+	assert.Equal(t, "func tiny() {}", string(chunks[2].Content))
+
+	splitter, err = NewCodeSplitter(SplitOptions{
+		MaxTokensPerChunk:          1000,
+		SlidingWindowOverlapTokens: 100,
+		ChunkRules: []ChunkRuleOverride{
+			{Language: "go", MinTokens: 12, Merge: [][]string{{"const_declaration"}}},
+		},
+	})
+	require.NoError(t, err)
+	chunks, err = splitter.Split(source)
+	require.NoError(t, err)
+	// 过小的节点和不在合并分组中的变量声明归入剩余代码分块
+	require.Len(t, chunks, 2)
+	assert.Equal(t, "package main\n\nconst a = 1\nconst b = 2\n\nvar c = 3\n\nfunc tiny() {}", string(chunks[0].Content))
+	assert.Contains(t, string(chunks[1].Content), "func main()")
+
+	_, err = NewCodeSplitter(SplitOptions{
+		MaxTokensPerChunk: 1000,
+		ChunkRules:        []ChunkRuleOverride{{Language: "c", Kinds: []string{"struct_declaration"}}},
+	})
+	assert.ErrorContains(t, err, "struct_declaration")
+
+	_, err = NewCodeSplitter(SplitOptions{
+		MaxTokensPerChunk: 1000,
+		ChunkRules:        []ChunkRuleOverride{{Language: "markdown", Kinds: []string{"section"}}},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,241 @@
+package embedding
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/ignore"
+)
+
+const (
+	GeneratedActionSkip       = "skip"       // 生成/第三方代码不做嵌入
+	GeneratedActionDownWeight = "downweight" // 生成/第三方代码正常嵌入，查询时降权
+
+	gitAttributesFile   = ".gitattributes"
+	attrLinguistGen     = "linguist-generated"
+	attrLinguistVendor  = "linguist-vendored"
+	headerScanBytes     = 2048 // 检测生成代码标记时扫描的文件头字节数
+	minifiedMinFileSize = 512  // 小于该字节数的文件不做压缩代码检测
+)
+
+// 生成代码文件头标记
+var generatedHeaderMarkers = []*regexp.Regexp{
+	regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.?\s*$`),
+	regexp.MustCompile(`@generated\b`),
+	regexp.MustCompile(`(?i)<auto-generated`),
+	regexp.MustCompile(`(?i)generated by the protocol buffer compiler`),
+	regexp.MustCompile(`(?i)this file (was|is) (automatically|auto-?) ?generated`),
+	regexp.MustCompile(`(?i)auto-?generated (file|code)`),
+	regexp.MustCompile(`(?i)\bdo not (edit|modify)\b.*\b(generated|automatically)\b`),
+}
+
+// 生成代码路径规则
+var generatedPathPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\.pb(\.gw|\.validate)?\.go$`),
+	regexp.MustCompile(`(^|/)zz_generated[^/]*\.go$`),
+	regexp.MustCompile(`_generated\.go$`),
+	regexp.MustCompile(`\.gen\.go$`),
+	regexp.MustCompile(`_pb2(_grpc)?\.pyi?$`),
+	regexp.MustCompile(`\.pb\.(cc|h)$`),
+	regexp.MustCompile(`[.-]min\.(js|css)$`),
+	regexp.MustCompile(`\.bundle\.js$`),
+	regexp.MustCompile(`\.(js|css)\.map$`),
+	regexp.MustCompile(`\.(g|freezed)\.dart$`),
+	regexp.MustCompile(`\.[Dd]esigner\.cs$`),
+	regexp.MustCompile(`(^|/)(package-lock\.json|yarn\.lock|pnpm-lock\.yaml|go\.sum|Cargo\.lock|poetry\.lock|composer\.lock|Gemfile\.lock)$`),
+}
+
+// 第三方代码路径规则
+var vendoredPathPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(^|/)vendor/`),
+	regexp.MustCompile(`(^|/)node_modules/`),
+	regexp.MustCompile(`(^|/)bower_components/`),
+	regexp.MustCompile(`(^|/)jspm_packages/`),
+	regexp.MustCompile(`(^|/)third[-_]?party/`),
+	regexp.MustCompile(`(^|/)Pods/`),
+	regexp.MustCompile(`(^|/)Carthage/`),
+	regexp.MustCompile(`(^|/)Godeps/_workspace/`),
+	regexp.MustCompile(`(^|/)\.yarn/`),
+	regexp.MustCompile(`(^|/)site-packages/`),
+}
+
+// ClassifierOptions 生成/第三方代码检测配置
+type ClassifierOptions struct {
+	Enabled          bool
+	MaxAvgLineLength int // 平均行长度超过该值视为压缩代码
+}
+
+// FileClass 文件分类结果
+type FileClass struct {
+	Generated bool
+	Vendored  bool
+	Reason    string // 命中的规则，便于排查
+}
+
+// attrRule .gitattributes 中的单条 linguist 规则
+type attrRule struct {
+	matcher *ignore.Matcher
+	value   bool
+}
+
+// CodeClassifier 检测生成代码、压缩代码和第三方代码
+type CodeClassifier struct {
+	options        ClassifierOptions
+	generatedAttrs []attrRule
+	vendoredAttrs  []attrRule
+}
+
+// NewCodeClassifier 创建分类器，attributesFiles 为上传包中的 .gitattributes 文件
+func NewCodeClassifier(options ClassifierOptions, attributesFiles map[string][]byte) *CodeClassifier {
+	c := &CodeClassifier{options: options}
+	if c.options.MaxAvgLineLength <= 0 {
+		c.options.MaxAvgLineLength = 200
+	}
+
+	// 浅层目录先加载，深层目录的规则后加载以覆盖上层规则
+	filePaths := make([]string, 0, len(attributesFiles))
+	for filePath := range attributesFiles {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Slice(filePaths, func(i, j int) bool {
+		return strings.Count(filePaths[i], "/") < strings.Count(filePaths[j], "/") ||
+			(strings.Count(filePaths[i], "/") == strings.Count(filePaths[j], "/") && filePaths[i] < filePaths[j])
+	})
+	for _, filePath := range filePaths {
+		dir := path.Dir(strings.ReplaceAll(filePath, `\`, "/"))
+		if dir == "." {
+			dir = ""
+		}
+		c.parseAttributes(dir, attributesFiles[filePath])
+	}
+	return c
+}
+
+// IsAttributesFile 判断路径是否为 .gitattributes 文件
+func IsAttributesFile(filePath string) bool {
+	return path.Base(strings.ReplaceAll(filePath, `\`, "/")) == gitAttributesFile
+}
+
+// parseAttributes 解析 .gitattributes 中的 linguist-generated / linguist-vendored 属性
+func (c *CodeClassifier) parseAttributes(dir string, content []byte) {
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		for _, attr := range fields[1:] {
+			name, value := parseAttribute(attr)
+			if name != attrLinguistGen && name != attrLinguistVendor {
+				continue
+			}
+			m := &ignore.Matcher{}
+			m.AddPatterns(dir, []string{fields[0]})
+			if name == attrLinguistGen {
+				c.generatedAttrs = append(c.generatedAttrs, attrRule{matcher: m, value: value})
+			} else {
+				c.vendoredAttrs = append(c.vendoredAttrs, attrRule{matcher: m, value: value})
+			}
+		}
+	}
+}
+
+// parseAttribute 解析 attr、-attr、!attr、attr=value 形式的属性
+func parseAttribute(attr string) (string, bool) {
+	switch {
+	case strings.HasPrefix(attr, "-"), strings.HasPrefix(attr, "!"):
+		return attr[1:], false
+	case strings.Contains(attr, "="):
+		kv := strings.SplitN(attr, "=", 2)
+		return kv[0], kv[1] != "false" && kv[1] != "0"
+	default:
+		return attr, true
+	}
+}
+
+// lookupAttr 返回最后一条命中规则的值，未命中时 ok 为 false
+func lookupAttr(rules []attrRule, filePath string) (value bool, ok bool) {
+	for _, r := range rules {
+		if r.matcher.IsIgnored(filePath) {
+			value, ok = r.value, true
+		}
+	}
+	return value, ok
+}
+
+// Classify 判断文件是否为生成代码或第三方代码，.gitattributes 中的显式配置优先于启发式规则
+func (c *CodeClassifier) Classify(filePath string, content []byte) FileClass {
+	var class FileClass
+	if c == nil || !c.options.Enabled {
+		return class
+	}
+	p := strings.ReplaceAll(filePath, `\`, "/")
+
+	if value, ok := lookupAttr(c.vendoredAttrs, p); ok {
+		class.Vendored = value
+		if value {
+			class.Reason = attrLinguistVendor
+		}
+	} else if pattern := matchAny(vendoredPathPatterns, p); pattern != "" {
+		class.Vendored = true
+		class.Reason = "path:" + pattern
+	}
+
+	if value, ok := lookupAttr(c.generatedAttrs, p); ok {
+		class.Generated = value
+		if value {
+			class.Reason = attrLinguistGen
+		}
+		return class
+	}
+	if pattern := matchAny(generatedPathPatterns, p); pattern != "" {
+		class.Generated = true
+		class.Reason = "path:" + pattern
+		return class
+	}
+	if marker := c.matchHeaderMarker(content); marker != "" {
+		class.Generated = true
+		class.Reason = "header:" + marker
+		return class
+	}
+	// notebook 的输出中常有很长的图片和表格数据，按单元格源码判断
+	if c.isMinified(SourceView(p, content)) {
+		class.Generated = true
+		class.Reason = "minified"
+	}
+	return class
+}
+
+// matchHeaderMarker 检查文件头是否包含生成代码标记
+func (c *CodeClassifier) matchHeaderMarker(content []byte) string {
+	header := content
+	if len(header) > headerScanBytes {
+		header = header[:headerScanBytes]
+	}
+	for _, marker := range generatedHeaderMarkers {
+		if marker.Match(header) {
+			return marker.String()
+		}
+	}
+	return ""
+}
+
+// isMinified 根据平均行长度判断是否为压缩代码
+func (c *CodeClassifier) isMinified(content []byte) bool {
+	if len(content) < minifiedMinFileSize {
+		return false
+	}
+	lines := bytes.Count(content, []byte("\n")) + 1
+	return len(content)/lines > c.options.MaxAvgLineLength
+}
+
+func matchAny(patterns []*regexp.Regexp, p string) string {
+	for _, pattern := range patterns {
+		if pattern.MatchString(p) {
+			return pattern.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package embedding
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeClassifierClassify(t *testing.T) {
+	classifier := NewCodeClassifier(ClassifierOptions{Enabled: true}, map[string][]byte{
+		".gitattributes":     []byte("gen/** linguist-generated\nlibs/** linguist-vendored\n*.pb.go -linguist-generated\n"),
+		"web/.gitattributes": []byte("assets/*.js linguist-generated=true\n"),
+	})
+
+	minified := strings.Repeat("var a=function(){return 1};", 100)
+
+	testCases := []struct {
+		name          string
+		path          string
+		content       string
+		wantGenerated bool
+		wantVendored  bool
+	}{
+		{"plain source", "internal/app/main.go", "package main\n\nfunc main() {}\n", false, false},
+		{"go generated header", "internal/model/user.go", "// Code generated by gorm.io/gen. DO NOT EDIT.\npackage model\n", true, false},
+		{"protobuf python", "api/user_pb2.py", "import grpc\n", true, false},
+		{"minified by name", "static/app.min.js", "var a=1;\n", true, false},
+		{"minified by line length", "static/app.js", minified, true, false},
+		{"vendor dir", "vendor/github.com/x/y.go", "package y\n", false, true},
+		{"node_modules", "web/node_modules/lodash/index.js", "module.exports = {}\n", false, true},
+		{"gitattributes generated", "gen/client.go", "package gen\n", true, false},
+		{"gitattributes vendored", "libs/jquery/jquery.js", "(function(){})()\n", false, true},
+		{"gitattributes overrides path rule", "api/user.pb.go", "package api\n", false, false},
+		{"nested gitattributes", "web/assets/chart.js", "let x = 1\n", true, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			class := classifier.Classify(tc.path, []byte(tc.content))
+			assert.Equal(t, tc.wantGenerated, class.Generated, class.Reason)
+			assert.Equal(t, tc.wantVendored, class.Vendored, class.Reason)
+		})
+	}
+}
+
+func TestCodeClassifierDisabled(t *testing.T) {
+	classifier := NewCodeClassifier(ClassifierOptions{Enabled: false}, nil)
+	class := classifier.Classify("vendor/a.go", []byte("// Code generated by x. DO NOT EDIT.\n"))
+	assert.False(t, class.Generated)
+	assert.False(t, class.Vendored)
+
+	var nilClassifier *CodeClassifier
+	assert.False(t, nilClassifier.Classify("a.min.js", nil).Generated)
+}
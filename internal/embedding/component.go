@@ -0,0 +1,96 @@
+package embedding
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/parser"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+// scriptBlockPattern 匹配单文件组件（Vue、Svelte）中的 <script> 块，分组 1 为标签属性，分组 2 为脚本内容
+var scriptBlockPattern = regexp.MustCompile(`(?is)<script\b([^>]*)>(.*?)</script\s*>`)
+
+// scriptLangPattern 匹配 <script> 标签的 lang 属性
+var scriptLangPattern = regexp.MustCompile(`(?i)\blang\s*=\s*["']?([a-z]+)`)
+
+// scriptLanguage 根据 lang 属性确定脚本语法，未指定时为 JavaScript
+func scriptLanguage(attrs string) parser.Language {
+	m := scriptLangPattern.FindStringSubmatch(attrs)
+	if m == nil {
+		return parser.JavaScript
+	}
+	switch strings.ToLower(m[1]) {
+	case "ts", "typescript":
+		return parser.TypeScript
+	case "tsx":
+		return parser.TSX
+	default:
+		return parser.JavaScript
+	}
+}
+
+// splitComponentFile 切分单文件组件：<script> 块按 TS/JS 语法切分，模板和样式按行分组切分
+func (p *CodeSplitter) splitComponentFile(codeFile *types.SourceFile, language parser.Language) ([]*types.CodeChunk, error) {
+	content := codeFile.Content
+	lineStarts := lineStartOffsets(content)
+
+	var (
+		chunks  []*types.CodeChunk
+		covered [][2]uint
+	)
+	for _, m := range scriptBlockPattern.FindAllSubmatchIndex(content, -1) {
+		start, end := m[4], m[5]
+		if len(bytes.TrimSpace(content[start:end])) == 0 {
+			continue
+		}
+		script := &types.SourceFile{
+			CodebaseId:   codeFile.CodebaseId,
+			CodebasePath: codeFile.CodebasePath,
+			CodebaseName: codeFile.CodebaseName,
+			Path:         codeFile.Path,
+			Content:      content[start:end],
+		}
+		scriptChunks, err := p.splitCode(script, findLanguageConfig(scriptLanguage(string(content[m[2]:m[3]]))))
+		if err != nil {
+			return nil, fmt.Errorf("failed to split script block of %s: %w", codeFile.Path, err)
+		}
+		// 脚本块内的位置换算为组件文件中的位置
+		row, col := bytePosition(lineStarts, start)
+		for _, chunk := range scriptChunks {
+			shiftRange(chunk.Range, row, col)
+			for _, r := range chunk.SubRanges {
+				shiftRange(r, row, col)
+			}
+		}
+		chunks = append(chunks, scriptChunks...)
+		covered = append(covered, [2]uint{uint(start), uint(end)})
+	}
+
+	remainderChunks := p.splitRemainder(codeFile, lineStarts, covered)
+	p.applyContextHeader(remainderChunks, ChunkContext{FilePath: codeFile.Path, Language: string(language)})
+	chunks = append(chunks, remainderChunks...)
+	slices.SortStableFunc(chunks, func(a, b *types.CodeChunk) int {
+		return cmp.Or(cmp.Compare(a.Range[0], b.Range[0]), cmp.Compare(a.Range[1], b.Range[1]))
+	})
+	return chunks, nil
+}
+
+// shiftRange 将相对脚本块的范围平移为文件中的范围，脚本块首行的列需要加上块起始列
+func shiftRange(r []int, row, col int) {
+	if len(r) < 4 {
+		return
+	}
+	if r[0] == 0 {
+		r[1] += col
+	}
+	if r[2] == 0 {
+		r[3] += col
+	}
+	r[0] += row
+	r[2] += row
+}
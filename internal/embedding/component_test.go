@@ -0,0 +1,61 @@
+package embedding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/parser"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+func TestSplitComponentFile(t *testing.T) {
+	source := &types.SourceFile{
+		Path: "src/Counter.vue",
+		Content: []byte(`<template>
+  <button @click="increment">{{ count }}</button>
+</template>
+
+<script setup lang="ts">
+import { ref } from 'vue'
+
+const count = ref(0)
+
+function increment(): void {
+  count.value++
+}
+</script>
+
+<style scoped>
+button { color: red; }
+</style>
+`),
+	}
+
+	splitter, err := NewCodeSplitter(SplitOptions{MaxTokensPerChunk: 1000, SlidingWindowOverlapTokens: 100})
+	require.NoError(t, err)
+	chunks, err := splitter.Split(source)
+	require.NoError(t, err)
+
+	var function *types.CodeChunk
+	for _, chunk := range chunks {
+		assert.Equal(t, source.Path, chunk.FilePath)
+		if string(chunk.Content) == "function increment(): void {\n  count.value++\n}" {
+			function = chunk
+		}
+	}
+	require.NotNil(t, function)
+	// 脚本块内的行号换算为组件文件中的行号
+	assert.Equal(t, []int{9, 0, 11, 1}, function.Range)
+	assert.Contains(t, string(chunks[0].Content), "<template>")
+	assert.Contains(t, string(chunks[len(chunks)-1].Content), "button { color: red; }")
+	assert.Equal(t, float64(1), ChunkCoverage(source.Content, chunks))
+}
+
+func TestScriptLanguage(t *testing.T) {
+	assert.Equal(t, parser.JavaScript, scriptLanguage(""))
+	assert.Equal(t, parser.TypeScript, scriptLanguage(` setup lang="ts"`))
+	assert.Equal(t, parser.TSX, scriptLanguage(` lang='tsx'`))
+	assert.Equal(t, parser.JavaScript, scriptLanguage(` context="module"`))
+}
@@ -0,0 +1,109 @@
+package embedding
+
+import (
+	"slices"
+	"strings"
+
+	sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// leadingAttachments 返回紧邻节点之前的注释和注解兄弟节点（按源码顺序），中间有空行时停止
+func leadingAttachments(node *sitter.Node, rule *chunkRule) []*sitter.Node {
+	if len(rule.attach) == 0 {
+		return nil
+	}
+	var attached []*sitter.Node
+	next := node
+	for prev := node.PrevNamedSibling(); prev != nil; prev = prev.PrevNamedSibling() {
+		if _, ok := rule.attach[prev.Kind()]; !ok {
+			break
+		}
+		if prev.EndPosition().Row+1 < next.StartPosition().Row {
+			break
+		}
+		// 与前一个节点同行的是行尾注释，属于前一个节点（部分语法的行注释包含换行符，结束于下一行首列）
+		if before := prev.PrevSibling(); before != nil && isCommentKind(prev.Kind()) &&
+			before.EndPosition().Row == prev.StartPosition().Row && before.EndPosition().Column > 0 {
+			break
+		}
+		attached = append(attached, prev)
+		next = prev
+	}
+	slices.Reverse(attached)
+	return attached
+}
+
+// extractDocstring 提取节点的文档：紧邻的文档注释和函数或类体中的文档字符串
+func extractDocstring(node *sitter.Node, attached []*sitter.Node, rule *chunkRule, content []byte) string {
+	var docs []string
+	for _, n := range attached {
+		if !isCommentKind(n.Kind()) {
+			continue
+		}
+		if doc := stripCommentMarkers(n.Utf8Text(content)); doc != "" {
+			docs = append(docs, doc)
+		}
+	}
+	if rule.bodyDocstring {
+		if doc := bodyDocstring(node, content); doc != "" {
+			docs = append(docs, doc)
+		}
+	}
+	return strings.Join(docs, "\n")
+}
+
+// bodyDocstring 提取函数或类体中首个字符串语句，带装饰器的定义取其 definition 字段
+func bodyDocstring(node *sitter.Node, content []byte) string {
+	if definition := node.ChildByFieldName("definition"); definition != nil {
+		node = definition
+	}
+	body := node.ChildByFieldName("body")
+	if body == nil || body.NamedChildCount() == 0 {
+		return ""
+	}
+	first := body.NamedChild(0)
+	if first.Kind() == "expression_statement" && first.NamedChildCount() > 0 {
+		first = first.NamedChild(0)
+	}
+	if first.Kind() != "string" {
+		return ""
+	}
+	text := first.Utf8Text(content)
+	for _, quote := range []string{`"""`, `'''`, `"`, `'`} {
+		if len(text) >= 2*len(quote) && strings.HasPrefix(text, quote) && strings.HasSuffix(text, quote) {
+			text = text[len(quote) : len(text)-len(quote)]
+			break
+		}
+	}
+	return dedentLines(text)
+}
+
+// stripCommentMarkers 去掉注释符号，保留注释正文
+func stripCommentMarkers(text string) string {
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(text, "/**")
+	text = strings.TrimPrefix(text, "/*")
+	text = strings.TrimSuffix(text, "*/")
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		for _, marker := range []string{"///", "//!", "//", "#", "--", "*"} {
+			if strings.HasPrefix(line, marker) {
+				line = strings.TrimPrefix(line, marker)
+				break
+			}
+		}
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// dedentLines 去掉每行首尾空白和首尾空行
+func dedentLines(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,74 @@
+package embedding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+func TestSplitAttachDocComments(t *testing.T) {
+	splitter, err := NewCodeSplitter(SplitOptions{MaxTokensPerChunk: 1000, SlidingWindowOverlapTokens: 100})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		path          string
+		content       string
+		wantContent   string
+		wantDocstring string
+	}{
+		{
+			name:          "go doc comment",
+			path:          "user.go",
+			content:       "package user\n\n// Copyright header\n\n// Load reads the user\n// from the database.\nfunc Load() {}\n",
+			wantContent:   "// Load reads the user\n// from the database.\nfunc Load() {}",
+			wantDocstring: "Load reads the user\nfrom the database.",
+		},
+		{
+			name:          "go trailing comment of previous line",
+			path:          "user.go",
+			content:       "package user\n\nvar x = 1 // counter\nfunc Load() {}\n",
+			wantContent:   "func Load() {}",
+			wantDocstring: "",
+		},
+		{
+			name:          "python decorator and docstring",
+			path:          "user.py",
+			content:       "# cached lookup\n@cache\ndef load(uid):\n    \"\"\"Load the user\n    by id.\"\"\"\n    return db.get(uid)\n",
+			wantContent:   "# cached lookup\n@cache\ndef load(uid):\n    \"\"\"Load the user\n    by id.\"\"\"\n    return db.get(uid)",
+			wantDocstring: "cached lookup\nLoad the user\nby id.",
+		},
+		{
+			name:          "rust doc comment and attribute",
+			path:          "user.rs",
+			content:       "/// A user record.\n#[derive(Debug)]\nstruct User {\n    id: u64,\n}\n",
+			wantContent:   "/// A user record.\n#[derive(Debug)]\nstruct User {\n    id: u64,\n}",
+			wantDocstring: "A user record.",
+		},
+		{
+			name:          "javadoc",
+			path:          "User.java",
+			content:       "/**\n * A user record.\n */\n@Entity\npublic class User {}\n",
+			wantContent:   "/**\n * A user record.\n */\n@Entity\npublic class User {}",
+			wantDocstring: "A user record.",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chunks, err := splitter.Split(&types.SourceFile{Path: tc.path, Content: []byte(tc.content)})
+			require.NoError(t, err)
+			var found *types.CodeChunk
+			for _, chunk := range chunks {
+				if string(chunk.Content) == tc.wantContent {
+					found = chunk
+				}
+			}
+			require.NotNil(t, found, "chunks: %v", chunks)
+			assert.Equal(t, tc.wantDocstring, found.Docstring)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package embedding
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+var (
+	// markdownFencePattern 代码围栏的开始/结束行，捕获缩进后的围栏符号和信息串
+	markdownFencePattern = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})(.*)$")
+	// markdownInlineLinkPattern 行内链接和图片 [text](target "title")
+	markdownInlineLinkPattern = regexp.MustCompile(`!?\[[^\]]*\]\(\s*(<[^>]*>|[^\s)]+)(?:\s+(?:"[^"]*"|'[^']*'))?\s*\)`)
+	// markdownReferencePattern 链接引用定义 [label]: target
+	markdownReferencePattern = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:\s*(<[^>]*>|\S+)`)
+	// markdownCodeSpanPattern 行内代码，其中的链接语法不生效
+	markdownCodeSpanPattern = regexp.MustCompile("`+[^`]*`+")
+	// urlSchemePattern 带协议的外部链接
+	urlSchemePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.\-]*:`)
+)
+
+// markdownTitle 去掉 ATX 标题的 # 标记，返回标题文本
+func markdownTitle(heading string) string {
+	title := strings.TrimLeft(strings.TrimSpace(heading), "#")
+	// 可选的结束标记需与标题文本以空格分隔
+	if trimmed := strings.TrimRight(title, "#"); trimmed == "" || strings.HasSuffix(trimmed, " ") {
+		title = trimmed
+	}
+	return strings.TrimSpace(title)
+}
+
+// markdownBreadcrumb 将标题路径转换为面包屑，如 "README > Deployment > Redis"
+func markdownBreadcrumb(headerPath []string) string {
+	titles := make([]string, 0, len(headerPath))
+	for _, heading := range headerPath {
+		if title := markdownTitle(heading); title != "" {
+			titles = append(titles, title)
+		}
+	}
+	return strings.Join(titles, BreadcrumbSeparator)
+}
+
+// annotateMarkdownChunk 记录文档分块中代码围栏的语言和指向代码库内文件的链接
+func annotateMarkdownChunk(chunk *types.CodeChunk, filePath string) {
+	chunk.FenceLanguages, chunk.Links = scanMarkdown(string(chunk.Content), filePath)
+}
+
+// scanMarkdown 逐行扫描 markdown 内容，返回代码围栏语言（按出现顺序去重、小写）和
+// 解析为代码库相对路径的链接；代码围栏和行内代码中的链接语法被忽略
+func scanMarkdown(content, filePath string) (fenceLanguages []string, links []string) {
+	var (
+		fence     string // 当前所在围栏的开始符号，为空表示不在围栏内
+		seenLang  = make(map[string]bool)
+		seenLinks = make(map[string]bool)
+	)
+	addLink := func(target string) {
+		if link, ok := resolveMarkdownLink(filePath, target); ok && !seenLinks[link] {
+			seenLinks[link] = true
+			links = append(links, link)
+		}
+	}
+	for _, line := range strings.Split(content, "\n") {
+		if m := markdownFencePattern.FindStringSubmatch(line); m != nil {
+			switch {
+			case fence == "":
+				fence = m[1]
+				// 反引号围栏的信息串中不能包含反引号
+				if strings.HasPrefix(fence, "`") && strings.Contains(m[2], "`") {
+					fence = ""
+					break
+				}
+				if fields := strings.Fields(m[2]); len(fields) > 0 {
+					lang := strings.ToLower(strings.Trim(fields[0], "{}."))
+					if lang != "" && !seenLang[lang] {
+						seenLang[lang] = true
+						fenceLanguages = append(fenceLanguages, lang)
+					}
+				}
+				continue
+			case m[1][0] == fence[0] && len(m[1]) >= len(fence) && strings.TrimSpace(m[2]) == "":
+				fence = ""
+				continue
+			}
+		}
+		if fence != "" {
+			continue
+		}
+		if m := markdownReferencePattern.FindStringSubmatch(line); m != nil {
+			addLink(m[1])
+			continue
+		}
+		line = markdownCodeSpanPattern.ReplaceAllString(line, "")
+		for _, m := range markdownInlineLinkPattern.FindAllStringSubmatch(line, -1) {
+			addLink(m[1])
+		}
+	}
+	return fenceLanguages, links
+}
+
+// resolveMarkdownLink 将链接目标解析为代码库相对路径：相对路径相对于文档所在目录，以 / 开头的相对于代码库根目录；
+// 外部链接、页内锚点和指向代码库之外的链接返回 false
+func resolveMarkdownLink(filePath, target string) (string, bool) {
+	target = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(target), "<"), ">")
+	if i := strings.IndexAny(target, "#?"); i >= 0 {
+		target = target[:i]
+	}
+	if target == "" || strings.HasPrefix(target, "//") || urlSchemePattern.MatchString(target) {
+		return "", false
+	}
+	if unescaped, err := url.PathUnescape(target); err == nil {
+		target = unescaped
+	}
+
+	var resolved string
+	if strings.HasPrefix(target, "/") {
+		resolved = path.Clean(strings.TrimLeft(target, "/"))
+	} else {
+		resolved = path.Join(path.Dir(strings.ReplaceAll(filePath, "\\", "/")), target)
+	}
+	if resolved == "." || resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return "", false
+	}
+	return resolved, true
+}
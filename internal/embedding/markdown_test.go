@@ -0,0 +1,54 @@
+package embedding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+func TestSplitMarkdownMetadata(t *testing.T) {
+	content := "# README\n\nSee [setup](./docs/setup.md#install) and [site](https://example.com).\n\n" +
+		"## Deployment\n\n### Redis\n\nConfig lives in [conf](/etc/conf.yaml).\n\n" +
+		"```yaml\nredis: [link](not/a/link.md)\n```\n\n~~~ Go {.numberLines}\nfunc main() {}\n~~~\n" +
+		"Use `[x](ignored.md)` or ![arch](../images/arch.png).\n\n[ref]: ../../outside.md\n"
+	splitter, err := NewCodeSplitter(SplitOptions{MaxTokensPerChunk: 1000, EnableMarkdownParsing: true})
+	require.NoError(t, err)
+	chunks, err := splitter.Split(&types.SourceFile{Path: "guide/README.md", Content: []byte(content)})
+	require.NoError(t, err)
+	require.Len(t, chunks, 3)
+
+	assert.Equal(t, "README", chunks[0].Breadcrumb)
+	assert.Equal(t, []string{"guide/docs/setup.md"}, chunks[0].Links)
+	assert.Equal(t, "README > Deployment", chunks[1].Breadcrumb)
+
+	redis := chunks[2]
+	assert.Equal(t, "README > Deployment > Redis", redis.Breadcrumb)
+	assert.Equal(t, []string{"yaml", "go"}, redis.FenceLanguages)
+	// 代码围栏、行内代码中的链接和指向代码库之外的链接被忽略
+	assert.Equal(t, []string{"etc/conf.yaml", "images/arch.png"}, redis.Links)
+}
+
+func TestResolveMarkdownLink(t *testing.T) {
+	for target, want := range map[string]string{
+		"api.md":             "docs/api.md",
+		"<my%20file.md>":     "docs/my file.md",
+		"../src/main.go?x=1": "src/main.go",
+		"/README.md":         "README.md",
+		"#anchor":            "",
+		"mailto:a@b.c":       "",
+		"//cdn.example.com":  "",
+		"../../up.md":        "",
+	} {
+		got, ok := resolveMarkdownLink("docs/index.md", target)
+		assert.Equal(t, want != "", ok, target)
+		assert.Equal(t, want, got, target)
+	}
+}
+
+func TestMarkdownTitle(t *testing.T) {
+	assert.Equal(t, "Deployment", markdownTitle("## Deployment ##"))
+	assert.Equal(t, "C#", markdownTitle("# C#"))
+}
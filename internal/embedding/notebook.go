@@ -0,0 +1,181 @@
+package embedding
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/parser"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+const (
+	notebookCellCode     = "code"
+	notebookCellMarkdown = "markdown"
+)
+
+// notebook Jupyter notebook 中切分需要的字段，单元格输出不参与切分
+type notebook struct {
+	Cells    []notebookCell `json:"cells"`
+	Metadata struct {
+		Kernelspec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+}
+
+type notebookCell struct {
+	CellType string         `json:"cell_type"`
+	Source   notebookSource `json:"source"`
+}
+
+// notebookSource 单元格源码，nbformat 允许字符串或按行拆分的字符串数组
+type notebookSource string
+
+func (s *notebookSource) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*s = notebookSource(strings.Join(lines, ""))
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	*s = notebookSource(text)
+	return nil
+}
+
+// notebookLanguageAliases 内核语言名与解析器语言不一致的映射
+var notebookLanguageAliases = map[string]parser.Language{
+	"c++": parser.CPP,
+	"c#":  parser.CSharp,
+}
+
+// viewCell 单元格在拼接视图中的位置
+type viewCell struct {
+	index    int // 单元格序号，从 0 开始
+	cellType string
+	source   []byte // 以换行结尾的单元格源码
+	startRow int    // 在拼接视图中的起始行
+}
+
+func parseNotebook(content []byte) (*notebook, error) {
+	var nb notebook
+	if err := json.Unmarshal(content, &nb); err != nil {
+		return nil, err
+	}
+	if nb.Cells == nil {
+		return nil, fmt.Errorf("missing cells")
+	}
+	return &nb, nil
+}
+
+// view 按顺序拼接各单元格源码，每个单元格以换行结尾，空单元格占一个空行
+func (nb *notebook) view() ([]byte, []viewCell) {
+	var (
+		view  bytes.Buffer
+		cells = make([]viewCell, 0, len(nb.Cells))
+		row   int
+	)
+	for i, cell := range nb.Cells {
+		source := []byte(cell.Source)
+		if !bytes.HasSuffix(source, []byte("\n")) {
+			source = append(source, '\n')
+		}
+		cells = append(cells, viewCell{index: i, cellType: cell.CellType, source: source, startRow: row})
+		view.Write(source)
+		row += bytes.Count(source, []byte("\n"))
+	}
+	return view.Bytes(), cells
+}
+
+// language 返回内核语言，未声明时按 Python 处理
+func (nb *notebook) language() parser.Language {
+	name := strings.ToLower(cmp.Or(nb.Metadata.LanguageInfo.Name, nb.Metadata.Kernelspec.Language, string(parser.Python)))
+	if lang, ok := notebookLanguageAliases[name]; ok {
+		return lang
+	}
+	return parser.Language(name)
+}
+
+// SourceView 返回分块行号所对应的文件视图：Jupyter notebook 为各单元格源码按顺序拼接
+// （每个单元格以换行结尾，不含输出），其余文件和无法解析的 notebook 为原内容
+func SourceView(filePath string, content []byte) []byte {
+	if !strings.EqualFold(path.Ext(filePath), ".ipynb") {
+		return content
+	}
+	nb, err := parseNotebook(content)
+	if err != nil {
+		return content
+	}
+	view, _ := nb.view()
+	return view
+}
+
+// splitNotebook 切分 Jupyter notebook：代码单元格按内核语言的切块规则切分，markdown 单元格使用 markdown 切分，
+// 输出被丢弃；分块的行号对应 SourceView 中的拼接视图
+func (p *CodeSplitter) splitNotebook(codeFile *types.SourceFile) ([]*types.CodeChunk, error) {
+	nb, err := parseNotebook(codeFile.Content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", parser.ErrInvalidNotebook, codeFile.Path, err)
+	}
+	language := nb.language()
+	langConf := findLanguageConfig(language)
+	if langConf != nil && langConf.SitterLanguage() == nil {
+		langConf = nil
+	}
+
+	_, cells := nb.view()
+	var chunks []*types.CodeChunk
+	for _, cell := range cells {
+		if len(bytes.TrimSpace(cell.source)) == 0 {
+			continue
+		}
+		cellFile := &types.SourceFile{
+			CodebaseId:   codeFile.CodebaseId,
+			CodebasePath: codeFile.CodebasePath,
+			CodebaseName: codeFile.CodebaseName,
+			Path:         codeFile.Path,
+			Content:      cell.source,
+		}
+
+		var cellChunks []*types.CodeChunk
+		switch {
+		case cell.cellType == notebookCellCode && langConf != nil:
+			if cellChunks, err = p.splitCode(cellFile, langConf); err != nil {
+				return nil, fmt.Errorf("failed to split cell %d of %s: %w", cell.index, codeFile.Path, err)
+			}
+		case cell.cellType == notebookCellCode:
+			// 没有切块规则的内核语言按行分组切分
+			cellChunks = p.splitRemainder(cellFile, lineStartOffsets(cell.source), nil)
+			p.applyContextHeader(cellChunks, ChunkContext{FilePath: codeFile.Path, Language: string(language)})
+		case cell.cellType == notebookCellMarkdown && p.splitOptions.EnableMarkdownParsing:
+			if cellChunks, err = p.splitMarkdownFileBySitter(cellFile); err != nil {
+				return nil, fmt.Errorf("failed to split cell %d of %s: %w", cell.index, codeFile.Path, err)
+			}
+			p.applyContextHeader(cellChunks, ChunkContext{FilePath: codeFile.Path, Language: string(parser.Markdown)})
+		}
+
+		for _, chunk := range cellChunks {
+			index := cell.index
+			chunk.CellIndex = &index
+			shiftRange(chunk.Range, cell.startRow, 0)
+			for _, r := range chunk.SubRanges {
+				shiftRange(r, cell.startRow, 0)
+			}
+		}
+		chunks = append(chunks, cellChunks...)
+	}
+	slices.SortStableFunc(chunks, func(a, b *types.CodeChunk) int {
+		return cmp.Or(cmp.Compare(a.Range[0], b.Range[0]), cmp.Compare(a.Range[1], b.Range[1]))
+	})
+	return chunks, nil
+}
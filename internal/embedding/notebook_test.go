@@ -0,0 +1,51 @@
+package embedding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/parser"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+const testNotebook = `{
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# Load data\n", "Read the training set."]},
+  {"cell_type": "code", "execution_count": 1, "metadata": {}, "outputs": [
+    {"output_type": "display_data", "data": {"image/png": "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNk"}}
+   ],
+   "source": ["import pandas as pd\n", "\n", "def load(path):\n", "    return pd.read_csv(path)\n"]},
+  {"cell_type": "code", "execution_count": null, "metadata": {}, "outputs": [], "source": []},
+  {"cell_type": "code", "execution_count": 2, "metadata": {}, "outputs": [], "source": "df = load('train.csv')\ndf.head()"}
+ ],
+ "metadata": {"kernelspec": {"language": "python", "name": "python3"}, "language_info": {"name": "python"}},
+ "nbformat": 4,
+ "nbformat_minor": 5
+}`
+
+func TestSplitNotebook(t *testing.T) {
+	splitter, err := NewCodeSplitter(SplitOptions{MaxTokensPerChunk: 1000, SlidingWindowOverlapTokens: 100, EnableMarkdownParsing: true})
+	require.NoError(t, err)
+	chunks, err := splitter.Split(&types.SourceFile{Path: "analysis.ipynb", Content: []byte(testNotebook)})
+	require.NoError(t, err)
+
+	view := string(SourceView("analysis.ipynb", []byte(testNotebook)))
+	assert.Equal(t, "# Load data\nRead the training set.\nimport pandas as pd\n\ndef load(path):\n    return pd.read_csv(path)\n\ndf = load('train.csv')\ndf.head()\n", view)
+
+	var cells []int
+	for _, chunk := range chunks {
+		require.NotNil(t, chunk.CellIndex)
+		cells = append(cells, *chunk.CellIndex)
+		assert.NotContains(t, string(chunk.Content), "iVBORw0KGgo")
+	}
+	assert.Equal(t, []int{0, 1, 1, 3}, cells)
+	// 分块行号对应单元格拼接视图
+	assert.Equal(t, "def load(path):\n    return pd.read_csv(path)", string(chunks[2].Content))
+	assert.Equal(t, []int{4, 0, 5, 28}, chunks[2].Range)
+	assert.Equal(t, 7, chunks[3].Range[0])
+
+	_, err = splitter.Split(&types.SourceFile{Path: "broken.ipynb", Content: []byte(`{"cells": [`)})
+	assert.True(t, parser.IsNotSupportedFileError(err))
+}
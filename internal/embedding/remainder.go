@@ -0,0 +1,140 @@
+package embedding
+
+import (
+	"bytes"
+	"sort"
+	"unicode"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+// splitRemainder 为未被语义节点覆盖的代码区间生成分块，covered 为已切块节点的字节范围
+func (p *CodeSplitter) splitRemainder(codeFile *types.SourceFile, lineStarts []int, covered [][2]uint) []*types.CodeChunk {
+	content := codeFile.Content
+
+	var chunks []*types.CodeChunk
+	prev := 0
+	for _, r := range append(covered, [2]uint{uint(len(content)), uint(len(content))}) {
+		if int(r[0]) > prev {
+			chunks = append(chunks, p.splitGap(codeFile, lineStarts, prev, int(r[0]))...)
+		}
+		prev = max(prev, int(r[1]))
+	}
+	return chunks
+}
+
+// splitGap 将一段剩余代码按行合并为不超过分块上限的分块，跳过空行和只有标点的片段
+func (p *CodeSplitter) splitGap(codeFile *types.SourceFile, lineStarts []int, start, end int) []*types.CodeChunk {
+	content := codeFile.Content
+	maxTokens := p.splitOptions.MaxTokensPerChunk
+
+	var (
+		chunks     []*types.CodeChunk
+		groupStart = -1
+		groupEnd   int
+		groupToken int
+	)
+	flush := func() {
+		if groupStart >= 0 {
+			chunks = append(chunks, p.newRemainderChunk(codeFile, lineStarts, groupStart, groupEnd)...)
+		}
+		groupStart, groupToken = -1, 0
+	}
+
+	for lineStart := start; lineStart < end; {
+		lineEnd := end
+		if i := bytes.IndexByte(content[lineStart:end], '\n'); i >= 0 {
+			lineEnd = lineStart + i
+		}
+		line := content[lineStart:lineEnd]
+		next := lineEnd + 1
+
+		if len(bytes.TrimSpace(line)) == 0 {
+			lineStart = next
+			continue
+		}
+		// 逐行累加 token 数近似分组大小，最终分块的 token 数重新精确计算
+		lineToken := p.countToken(line) + 1
+		if groupStart >= 0 && groupToken+lineToken > maxTokens {
+			flush()
+		}
+		if groupStart < 0 {
+			groupStart = lineStart
+		}
+		groupEnd = lineEnd
+		groupToken += lineToken
+		lineStart = next
+	}
+	flush()
+	return chunks
+}
+
+// newRemainderChunk 创建剩余代码分块，超过分块上限的单行使用滑动窗口切分
+func (p *CodeSplitter) newRemainderChunk(codeFile *types.SourceFile, lineStarts []int, start, end int) []*types.CodeChunk {
+	content := codeFile.Content[start:end]
+	if bytes.IndexFunc(content, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+		return nil
+	}
+	startRow, startCol := bytePosition(lineStarts, start)
+	tokenCount := p.countToken(content)
+	if tokenCount > p.splitOptions.MaxTokensPerChunk {
+		return p.splitFuncWithSlidingWindow(string(content), codeFile, startRow, LanguageTypeCode)
+	}
+	endRow, endCol := bytePosition(lineStarts, end)
+	return []*types.CodeChunk{{
+		Language:     LanguageTypeCode,
+		CodebaseId:   codeFile.CodebaseId,
+		CodebasePath: codeFile.CodebasePath,
+		CodebaseName: codeFile.CodebaseName,
+		Content:      content,
+		FilePath:     codeFile.Path,
+		Range:        []int{startRow, startCol, endRow, endCol},
+		TokenCount:   tokenCount,
+	}}
+}
+
+// lineStartOffsets 返回每一行起始位置的字节偏移
+func lineStartOffsets(content []byte) []int {
+	offsets := []int{0}
+	for i, b := range content {
+		if b == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
+
+// bytePosition 将字节偏移转换为从 0 开始的行号和列号
+func bytePosition(lineStarts []int, offset int) (int, int) {
+	row := sort.Search(len(lineStarts), func(i int) bool { return lineStarts[i] > offset }) - 1
+	return row, offset - lineStarts[row]
+}
+
+// ChunkCoverage 统计分块覆盖的非空行比例，文件没有非空行时返回 1
+func ChunkCoverage(content []byte, chunks []*types.CodeChunk) float64 {
+	lines := bytes.Split(content, []byte("\n"))
+	covered := make([]bool, len(lines))
+	for _, chunk := range chunks {
+		if len(chunk.Range) < 3 {
+			continue
+		}
+		for row := max(chunk.Range[0], 0); row <= chunk.Range[2] && row < len(lines); row++ {
+			covered[row] = true
+		}
+	}
+
+	total, hit := 0, 0
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		total++
+		if covered[i] {
+			hit++
+		}
+	}
+	if total == 0 {
+		return 1
+	}
+	return float64(hit) / float64(total)
+}
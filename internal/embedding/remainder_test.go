@@ -0,0 +1,45 @@
+package embedding
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+func TestSplitRemainder(t *testing.T) {
+	splitter, err := NewCodeSplitter(SplitOptions{MaxTokensPerChunk: 50, SlidingWindowOverlapTokens: 10})
+	require.NoError(t, err)
+
+	var settings strings.Builder
+	for i := range 20 {
+		settings.WriteString("SETTING_" + strings.Repeat("X", i%3) + " = load_setting(\"key\")\n")
+	}
+	content := "import os\nimport sys\n\n\ndef main():\n    print(os.getcwd())\n\n\n" + settings.String() + "\nif __name__ == \"__main__\":\n    main()\n"
+	source := &types.SourceFile{Path: "app.py", Content: []byte(content)}
+
+	chunks, err := splitter.Split(source)
+	require.NoError(t, err)
+	require.Greater(t, len(chunks), 3)
+
+	assert.Equal(t, "import os\nimport sys", string(chunks[0].Content))
+	assert.Equal(t, []int{0, 0, 1, 10}, chunks[0].Range)
+	assert.Equal(t, "def main():\n    print(os.getcwd())", string(chunks[1].Content))
+	// 模块级语句按分块上限分组
+	for _, chunk := range chunks[2:] {
+		assert.LessOrEqual(t, chunk.TokenCount, 50)
+	}
+	last := chunks[len(chunks)-1]
+	assert.Equal(t, "if __name__ == \"__main__\":\n    main()", string(last.Content))
+	assert.Equal(t, 1.0, ChunkCoverage(source.Content, chunks))
+}
+
+func TestChunkCoverage(t *testing.T) {
+	content := []byte("a\n\nb\nc\n")
+	assert.Equal(t, 1.0, ChunkCoverage([]byte("\n\n"), nil))
+	assert.Equal(t, 0.0, ChunkCoverage(content, nil))
+	assert.InDelta(t, 2.0/3, ChunkCoverage(content, []*types.CodeChunk{{Range: []int{0, 0, 2, 1}}}), 1e-9)
+}
@@ -1,19 +1,21 @@
 package embedding
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"slices"
 	"strconv"
 	"strings"
+	"text/template"
 
 	"github.com/getkin/kin-openapi/openapi2"
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/oasdiff/yaml"
-	"github.com/tiktoken-go/tokenizer"
 	tree_sitter_markdown "github.com/tree-sitter-grammars/tree-sitter-markdown/bindings/go"
 	sitter "github.com/tree-sitter/go-tree-sitter"
 	"github.com/zgsm-ai/codebase-indexer/internal/parser"
+	"github.com/zgsm-ai/codebase-indexer/internal/tokenizer"
 	"github.com/zgsm-ai/codebase-indexer/internal/types"
 )
 
@@ -23,27 +25,52 @@ const (
 )
 
 type CodeSplitter struct {
-	tokenizer    tokenizer.Codec
-	splitOptions SplitOptions
+	tokenizer       tokenizer.Tokenizer
+	splitOptions    SplitOptions
+	chunkRules      map[parser.Language]*chunkRule
+	contextTemplate *template.Template
 }
 
 type SplitOptions struct {
 	MaxTokensPerChunk          int
 	SlidingWindowOverlapTokens int
-	EnableMarkdownParsing      bool // 是否启用markdown文件解析
-	EnableOpenAPIParsing       bool // 是否启用OpenAPI文档解析
+	EnableMarkdownParsing      bool                // 是否启用markdown文件解析
+	EnableOpenAPIParsing       bool                // 是否启用OpenAPI文档解析
+	EnableStructuredParsing    bool                // 是否按结构切分 YAML、JSON、TOML、Protobuf、GraphQL 文件
+	ChunkRules                 []ChunkRuleOverride // 覆盖内置的语言切块规则
+	ContextTemplate            string              // 嵌入文本的上下文头模板，为空时只嵌入代码本身
+	MergeSmallTokens           int                 // 低于该 token 数的相邻分块在同一作用域内合并，0 表示不合并
+	MergeTargetTokens          int                 // 合并后分块的目标 token 数上限
+	Tokenizer                  tokenizer.Tokenizer // 与嵌入模型一致的分词器，为空时使用 cl100k
 }
 
 // NewCodeSplitter 创建代码分割器
 func NewCodeSplitter(splitOptions SplitOptions) (*CodeSplitter, error) {
-	codec, err := tokenizer.Get(tokenizer.Cl100kBase)
+	tok := splitOptions.Tokenizer
+	if tok == nil {
+		var err error
+		if tok, err = tokenizer.Get(tokenizer.TypeCl100k, ""); err != nil {
+			return nil, fmt.Errorf("failed to get tokenizer: %w", err)
+		}
+	}
+
+	chunkRules, err := resolveChunkRules(splitOptions.ChunkRules)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get tokenizer: %w", err)
+		return nil, err
+	}
+
+	var contextTemplate *template.Template
+	if splitOptions.ContextTemplate != "" {
+		if contextTemplate, err = newContextTemplate(splitOptions.ContextTemplate); err != nil {
+			return nil, err
+		}
 	}
 
 	return &CodeSplitter{
-		tokenizer:    codec,
-		splitOptions: splitOptions,
+		tokenizer:       tok,
+		splitOptions:    splitOptions,
+		chunkRules:      chunkRules,
+		contextTemplate: contextTemplate,
 	}, nil
 }
 
@@ -61,15 +88,43 @@ func (p *CodeSplitter) Split(codeFile *types.SourceFile) ([]*types.CodeChunk, er
 
 	// 特殊处理 markdown 文件 - 只有在配置开启时才解析markdown
 	if language.Language == parser.Markdown && p.splitOptions.EnableMarkdownParsing {
-		return p.splitMarkdownFileBySitter(codeFile)
+		chunks, err := p.splitMarkdownFileBySitter(codeFile)
+		p.applyContextHeader(chunks, ChunkContext{FilePath: codeFile.Path, Language: string(language.Language)})
+		return chunks, err
 	}
-	if (language.Language == parser.OpenAPI || language.Language == parser.Swagger)  {
-		if !p.splitOptions.EnableOpenAPIParsing{
-			return nil,fmt.Errorf("openapi file parse is close")
+	if language.Language == parser.OpenAPI || language.Language == parser.Swagger {
+		if !p.splitOptions.EnableOpenAPIParsing {
+			return nil, fmt.Errorf("openapi file parse is close")
 		}
-		return p.splitOpenAPIFile(codeFile)
+		chunks, err := p.splitOpenAPIFile(codeFile)
+		p.applyContextHeader(chunks, ChunkContext{FilePath: codeFile.Path, Language: string(language.Language)})
+		return chunks, err
 	}
 
+	if isStructuredLanguage(language.Language) {
+		if (language.Language == parser.YAML || language.Language == parser.JSON) && p.splitOptions.EnableOpenAPIParsing {
+			if _, err = p.validateOpenAPISpec(codeFile.Content, codeFile.Path); err == nil {
+				chunks, err := p.splitOpenAPIFile(codeFile)
+				p.applyContextHeader(chunks, ChunkContext{FilePath: codeFile.Path, Language: string(language.Language)})
+				return chunks, err
+			}
+		}
+		if !p.splitOptions.EnableStructuredParsing {
+			return nil, fmt.Errorf("structured file parse is close: %w", parser.ErrLangConfNotFound)
+		}
+		return p.splitStructuredFile(codeFile, language.Language)
+	}
+	if language.Language == parser.Jupyter {
+		return p.splitNotebook(codeFile)
+	}
+	if language.Language == parser.Vue || language.Language == parser.Svelte {
+		return p.splitComponentFile(codeFile, language.Language)
+	}
+	return p.splitCode(codeFile, language)
+}
+
+// splitCode 按语法树切分代码文件
+func (p *CodeSplitter) splitCode(codeFile *types.SourceFile, language *parser.LanguageConfig) ([]*types.CodeChunk, error) {
 	sitterParser := sitter.NewParser()
 	defer sitterParser.Close()
 	// 设置解析器语言（复用已创建的Parser）
@@ -85,7 +140,7 @@ func (p *CodeSplitter) Split(codeFile *types.SourceFile) ([]*types.CodeChunk, er
 	defer tree.Close()
 
 	// 获取要提取的节点类型
-	nodeKinds, ok := languageChunkNodeKind[language.Language]
+	rule, ok := p.chunkRules[language.Language]
 	if !ok {
 		return nil, fmt.Errorf("missing chunk config for language %s", language.Language)
 	}
@@ -93,29 +148,69 @@ func (p *CodeSplitter) Split(codeFile *types.SourceFile) ([]*types.CodeChunk, er
 	// 预分配切片，减少内存重新分配
 	estimatedChunks := 10 // 预估每个文件约10个代码块
 	allChunks := make([]*types.CodeChunk, 0, estimatedChunks)
+	// 已切块节点的字节范围，按遍历顺序递增且互不重叠
+	covered := make([][2]uint, 0, estimatedChunks)
+	// 可参与小分块合并的分块及其作用域
+	scopes := make(map[*types.CodeChunk]mergeScope, estimatedChunks)
+
+	var fileCtx ChunkContext
+	if p.contextTemplate != nil {
+		fileCtx = fileContext(codeFile, string(language.Language), rule, tree.RootNode())
+	}
 
 	// 遍历语法树
 	cursor := tree.RootNode().Walk()
 	defer cursor.Close()
 
 	// 使用更简洁的遍历逻辑
+walk:
 	for {
 		currentNode := cursor.Node()
 		kind := currentNode.Kind()
+		_, isChunkKind := rule.kinds[kind]
+		group, isMergeKind := rule.mergeGroup[kind]
 		// 处理目标节点类型
-		if slices.Contains(nodeKinds, kind) {
+		if isChunkKind || isMergeKind {
+			lastNode := currentNode
+			if isMergeKind {
+				// 合并连续的同组兄弟节点，合并后不超过分块上限
+				steps := 0
+				for next, i := currentNode.NextSibling(), 1; next != nil; next, i = next.NextSibling(), i+1 {
+					if !next.IsNamed() || isCommentKind(next.Kind()) {
+						continue
+					}
+					if g, ok := rule.mergeGroup[next.Kind()]; !ok || g != group {
+						break
+					}
+					if p.countToken(codeFile.Content[currentNode.StartByte():next.EndByte()]) > p.splitOptions.MaxTokensPerChunk {
+						break
+					}
+					lastNode, steps = next, i
+				}
+				for range steps {
+					cursor.GotoNextSibling()
+				}
+			}
+
+			// 分块范围向前扩展到紧邻的文档注释和注解
+			firstNode := currentNode
+			attached := leadingAttachments(currentNode, rule)
+			if len(attached) > 0 {
+				firstNode = attached[0]
+			}
+
 			// 提取节点信息
-			startPos := currentNode.StartPosition()
-			endPos := currentNode.EndPosition()
-			content := codeFile.Content[currentNode.StartByte():currentNode.EndByte()]
+			startPos := firstNode.StartPosition()
+			endPos := lastNode.EndPosition()
+			content := codeFile.Content[firstNode.StartByte():lastNode.EndByte()]
 			tokenCount := p.countToken(content)
 
-			// 处理代码切块
+			// 处理代码切块，过小的节点不单独成块，由剩余代码分块覆盖
+			var nodeChunks []*types.CodeChunk
 			if tokenCount > p.splitOptions.MaxTokensPerChunk {
-				subChunks := p.splitFuncWithSlidingWindow(string(content), codeFile, int(startPos.Row), LanguageTypeCode)
-				allChunks = append(allChunks, subChunks...)
-			} else {
-				allChunks = append(allChunks, &types.CodeChunk{
+				nodeChunks = p.splitFuncWithSlidingWindow(string(content), codeFile, int(startPos.Row), LanguageTypeCode)
+			} else if tokenCount >= rule.minTokens {
+				nodeChunks = []*types.CodeChunk{{
 					Language:     LanguageTypeCode,
 					CodebaseId:   codeFile.CodebaseId,
 					CodebasePath: codeFile.CodebasePath,
@@ -124,7 +219,34 @@ func (p *CodeSplitter) Split(codeFile *types.SourceFile) ([]*types.CodeChunk, er
 					FilePath:     codeFile.Path,
 					Range:        []int{int(startPos.Row), int(startPos.Column), int(endPos.Row), int(endPos.Column)},
 					TokenCount:   tokenCount,
-				})
+				}}
+			}
+			if len(nodeChunks) > 0 {
+				covered = append(covered, [2]uint{firstNode.StartByte(), lastNode.EndByte()})
+				if isChunkKind && lastNode == currentNode {
+					for _, chunk := range nodeChunks {
+						chunk.NodeKind = kind
+					}
+				}
+				if docstring := extractDocstring(currentNode, attached, rule, codeFile.Content); docstring != "" {
+					for _, chunk := range nodeChunks {
+						chunk.Docstring = docstring
+					}
+				}
+				var chunkCtx ChunkContext
+				if p.contextTemplate != nil {
+					chunkCtx = nodeContext(fileCtx, currentNode, rule, codeFile.Content)
+					if lastNode != currentNode {
+						// 合并的多个节点没有共同签名
+						chunkCtx.Signature = ""
+					}
+					p.applyContextHeader(nodeChunks, chunkCtx)
+				}
+				// 滑动窗口切分的分块不参与小分块合并
+				if len(nodeChunks) == 1 && currentNode.Parent() != nil {
+					scopes[nodeChunks[0]] = mergeScope{id: currentNode.Parent().Id(), ctx: chunkCtx}
+				}
+				allChunks = append(allChunks, nodeChunks...)
 			}
 
 			// 跳过子节点，直接移动到兄弟节点
@@ -132,7 +254,7 @@ func (p *CodeSplitter) Split(codeFile *types.SourceFile) ([]*types.CodeChunk, er
 				// 没有兄弟节点，回溯到父节点的兄弟节点
 				for {
 					if !cursor.GotoParent() {
-						return allChunks, nil // 遍历完成
+						break walk // 遍历完成
 					}
 					if cursor.GotoNextSibling() {
 						break
@@ -155,10 +277,28 @@ func (p *CodeSplitter) Split(codeFile *types.SourceFile) ([]*types.CodeChunk, er
 
 			// 无兄弟节点，回溯父节点
 			if !cursor.GotoParent() {
-				return allChunks, nil // 遍历完成
+				break walk // 遍历完成
 			}
 		}
 	}
+
+	// 节点之间的顶层代码（导入、常量、模块级语句等）生成剩余代码分块，保证文件每一行都可被检索
+	lineStarts := lineStartOffsets(codeFile.Content)
+	remainderChunks := p.splitRemainder(codeFile, lineStarts, covered)
+	p.applyContextHeader(remainderChunks, fileCtx)
+	for _, chunk := range remainderChunks {
+		if chunk.TokenCount >= p.splitOptions.MergeSmallTokens {
+			continue
+		}
+		if scope := remainderScope(tree.RootNode(), lineStarts, chunk); scope != nil {
+			scopes[chunk] = mergeScope{id: scope.Id(), ctx: fileCtx}
+		}
+	}
+	allChunks = append(allChunks, remainderChunks...)
+	slices.SortStableFunc(allChunks, func(a, b *types.CodeChunk) int {
+		return cmp.Or(cmp.Compare(a.Range[0], b.Range[0]), cmp.Compare(a.Range[1], b.Range[1]))
+	})
+	return p.mergeSmallChunks(codeFile, lineStarts, allChunks, scopes), nil
 }
 
 // countToken 计算内容的token数量
@@ -183,8 +323,13 @@ func (p *CodeSplitter) splitFuncWithSlidingWindow(content string, codeFile *type
 		return nil
 	}
 
+	// 窗口需为模型自动添加的特殊 token 预留位置
+	if specials, err := p.tokenizer.Count(""); err == nil && maxTokens-specials > overlapTokens {
+		maxTokens -= specials
+	}
+
 	// 编码内容获取tokens和字节偏移量
-	_, tokens, err := p.tokenizer.Encode(content)
+	tokens, err := p.tokenizer.Split(content)
 	if err != nil {
 		return nil
 	}
@@ -552,6 +697,9 @@ func (p *CodeSplitter) splitMarkdownFileBySitter(codeFile *types.SourceFile) ([]
 			}
 			chunks = append(chunks, chunk)
 		}
+		for _, chunk := range chunks {
+			annotateMarkdownChunk(chunk, codeFile.Path)
+		}
 		return chunks, nil
 	}
 
@@ -559,6 +707,7 @@ func (p *CodeSplitter) splitMarkdownFileBySitter(codeFile *types.SourceFile) ([]
 	for i, header := range allHeaders {
 		// 获取当前标题的完整路径
 		headerPath := getHeaderPath(header, source, allHeaders)
+		breadcrumb := markdownBreadcrumb(headerPath)
 
 		// 查找下一个标题节点
 		var nextHeader *sitter.Node
@@ -605,6 +754,8 @@ func (p *CodeSplitter) splitMarkdownFileBySitter(codeFile *types.SourceFile) ([]
 				// 更新子块的内容和token数量
 				subChunk.Content = []byte(fullContent.String())
 				subChunk.TokenCount = p.countToken(subChunk.Content)
+				subChunk.Breadcrumb = breadcrumb
+				annotateMarkdownChunk(subChunk, codeFile.Path)
 
 				chunks = append(chunks, subChunk)
 			}
@@ -635,7 +786,9 @@ func (p *CodeSplitter) splitMarkdownFileBySitter(codeFile *types.SourceFile) ([]
 				FilePath:     codeFile.Path,
 				Range:        []int{startLine, startCol, endLine, endCol},
 				TokenCount:   finalTokenCount,
+				Breadcrumb:   breadcrumb,
 			}
+			annotateMarkdownChunk(chunk, codeFile.Path)
 			chunks = append(chunks, chunk)
 		}
 	}
@@ -791,6 +944,8 @@ func (p *CodeSplitter) splitOpenAPIFile(codeFile *types.SourceFile) ([]*types.Co
 		chunks, err = p.splitOpenAPI3File(codeFile)
 	case Swagger2:
 		chunks, err = p.splitSwagger2File(codeFile)
+	case AsyncAPI2, AsyncAPI3:
+		chunks, err = p.splitAsyncAPIFile(codeFile, version)
 	default:
 		return nil, parser.ErrInvalidOpenAPISpec
 	}
@@ -842,8 +997,21 @@ func (p *CodeSplitter) validateOpenAPISpec(data []byte, filePath string) (APIVer
 		}
 		return Unknown, fmt.Errorf("不支持的 Swagger 版本: %s", swaggerVersion)
 
+	case m["asyncapi"] != nil:
+		asyncapiVersion, ok := m["asyncapi"].(string)
+		if !ok {
+			return Unknown, fmt.Errorf("asyncapi版本字段格式错误")
+		}
+		switch {
+		case strings.HasPrefix(asyncapiVersion, "2"):
+			return AsyncAPI2, nil
+		case strings.HasPrefix(asyncapiVersion, "3"):
+			return AsyncAPI3, nil
+		}
+		return Unknown, fmt.Errorf("不支持的 AsyncAPI 版本: %s", asyncapiVersion)
+
 	default:
-		return Unknown, fmt.Errorf("既不是 openapi 3.x 也不是 swagger 2.0")
+		return Unknown, fmt.Errorf("既不是 openapi 3.x、swagger 2.0 也不是 asyncapi 2.x/3.x")
 	}
 }
 
@@ -861,6 +1029,10 @@ func (p *CodeSplitter) splitOpenAPI3File(codeFile *types.SourceFile) ([]*types.C
 	if err := doc.Validate(loader.Context); err != nil {
 		return nil, fmt.Errorf("openapi3 验证失败: %v", err)
 	}
+	rawDoc, err := parseAPIDocument(codeFile.Content)
+	if err != nil {
+		return nil, fmt.Errorf("openapi3 解析失败: %v", err)
+	}
 
 	var chunks []*types.CodeChunk
 
@@ -894,14 +1066,15 @@ func (p *CodeSplitter) splitOpenAPI3File(codeFile *types.SourceFile) ([]*types.C
 
 		// 创建代码块
 		chunk := &types.CodeChunk{
-			Language:     LanguageTypeDoc,
-			CodebaseId:   codeFile.CodebaseId,
-			CodebasePath: codeFile.CodebasePath,
-			CodebaseName: codeFile.CodebaseName,
-			Content:      docBytes,
-			FilePath:     codeFile.Path,
-			Range:        []int{0, 0, 0, 0}, // OpenAPI 分割不涉及行号
-			TokenCount:   tokenCount,
+			Language:      LanguageTypeDoc,
+			CodebaseId:    codeFile.CodebaseId,
+			CodebasePath:  codeFile.CodebasePath,
+			CodebaseName:  codeFile.CodebaseName,
+			Content:       docBytes,
+			FilePath:      codeFile.Path,
+			Range:         []int{0, 0, 0, 0}, // OpenAPI 分割不涉及行号
+			TokenCount:    tokenCount,
+			APIOperations: httpOperations(rawDoc, path),
 		}
 
 		chunks = append(chunks, chunk)
@@ -922,6 +1095,10 @@ func (p *CodeSplitter) splitSwagger2File(codeFile *types.SourceFile) ([]*types.C
 	if err := p.validateSwagger2Doc(&doc); err != nil {
 		return nil, fmt.Errorf("swagger2 验证失败: %v", err)
 	}
+	rawDoc, err := parseAPIDocument(codeFile.Content)
+	if err != nil {
+		return nil, fmt.Errorf("swagger2 解析失败: %v", err)
+	}
 
 	var chunks []*types.CodeChunk
 
@@ -962,14 +1139,15 @@ func (p *CodeSplitter) splitSwagger2File(codeFile *types.SourceFile) ([]*types.C
 
 		// 创建代码块
 		chunk := &types.CodeChunk{
-			Language:     LanguageTypeDoc,
-			CodebaseId:   codeFile.CodebaseId,
-			CodebasePath: codeFile.CodebasePath,
-			CodebaseName: codeFile.CodebaseName,
-			Content:      docBytes,
-			FilePath:     codeFile.Path,
-			Range:        []int{0, 0, 0, 0}, // Swagger 分割不涉及行号
-			TokenCount:   tokenCount,
+			Language:      LanguageTypeDoc,
+			CodebaseId:    codeFile.CodebaseId,
+			CodebasePath:  codeFile.CodebasePath,
+			CodebaseName:  codeFile.CodebaseName,
+			Content:       docBytes,
+			FilePath:      codeFile.Path,
+			Range:         []int{0, 0, 0, 0}, // Swagger 分割不涉及行号
+			TokenCount:    tokenCount,
+			APIOperations: httpOperations(rawDoc, path),
 		}
 
 		chunks = append(chunks, chunk)
@@ -0,0 +1,596 @@
+package embedding
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/parser"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+// BreadcrumbSeparator 键路径中各级名称的分隔符
+const BreadcrumbSeparator = " > "
+
+// structuredSpan 结构化文件中单独成块的区间
+type structuredSpan struct {
+	start, end int      // 字节范围
+	path       []string // 由外到内的键路径
+}
+
+// isStructuredLanguage 判断是否为按文件结构切分的配置和接口定义文件
+func isStructuredLanguage(language parser.Language) bool {
+	switch language {
+	case parser.YAML, parser.JSON, parser.TOML, parser.Protobuf, parser.GraphQL:
+		return true
+	}
+	return false
+}
+
+// splitStructuredFile 按文件结构切分配置和接口定义文件，每个分块携带键路径；
+// 无法解析的文件和结构之外的内容按行分组切分
+func (p *CodeSplitter) splitStructuredFile(codeFile *types.SourceFile, language parser.Language) ([]*types.CodeChunk, error) {
+	content := codeFile.Content
+
+	var spans []structuredSpan
+	switch language {
+	case parser.YAML:
+		spans = p.yamlSpans(content)
+	case parser.JSON:
+		spans = p.jsonSpans(content)
+	case parser.TOML:
+		spans = tomlSpans(content)
+	case parser.Protobuf:
+		spans = protoSpans(content)
+	case parser.GraphQL:
+		spans = graphqlSpans(content)
+	}
+
+	lineStarts := lineStartOffsets(content)
+	fileCtx := ChunkContext{FilePath: codeFile.Path, Language: string(language)}
+	var (
+		chunks  []*types.CodeChunk
+		covered [][2]uint
+		scopes  = make(map[*types.CodeChunk]mergeScope, len(spans))
+		parents = make(map[string]uintptr)
+	)
+	for _, span := range spans {
+		end := span.start + len(bytes.TrimRightFunc(content[span.start:span.end], unicode.IsSpace))
+		spanChunks := p.newRemainderChunk(codeFile, lineStarts, span.start, end)
+		if len(spanChunks) == 0 {
+			continue
+		}
+		breadcrumb := strings.Join(span.path, BreadcrumbSeparator)
+		for _, chunk := range spanChunks {
+			chunk.Breadcrumb = breadcrumb
+		}
+		chunkCtx := fileCtx
+		chunkCtx.Scopes = span.path
+		p.applyContextHeader(spanChunks, chunkCtx)
+		// 同一父级下相邻的小分块可以合并
+		if len(spanChunks) == 1 {
+			parent := strings.Join(span.path[:len(span.path)-1], BreadcrumbSeparator)
+			if _, ok := parents[parent]; !ok {
+				parents[parent] = uintptr(len(parents) + 1)
+			}
+			parentCtx := fileCtx
+			parentCtx.Scopes = span.path[:len(span.path)-1]
+			scopes[spanChunks[0]] = mergeScope{id: parents[parent], ctx: parentCtx}
+		}
+		chunks = append(chunks, spanChunks...)
+		covered = append(covered, [2]uint{uint(span.start), uint(end)})
+	}
+
+	remainderChunks := p.splitRemainder(codeFile, lineStarts, covered)
+	p.applyContextHeader(remainderChunks, fileCtx)
+	chunks = append(chunks, remainderChunks...)
+	slices.SortStableFunc(chunks, func(a, b *types.CodeChunk) int {
+		return cmp.Or(cmp.Compare(a.Range[0], b.Range[0]), cmp.Compare(a.Range[1], b.Range[1]))
+	})
+	return p.mergeSmallChunks(codeFile, lineStarts, chunks, scopes), nil
+}
+
+// tooLarge 判断区间是否超过分块上限，超过时继续按下一级键切分
+func (p *CodeSplitter) tooLarge(content []byte, start, end int) bool {
+	return p.countToken(content[start:end]) > p.splitOptions.MaxTokensPerChunk
+}
+
+// yamlDocSeparator 匹配 YAML 多文档分隔行
+var yamlDocSeparator = regexp.MustCompile(`(?m)^---[ \t]*(#.*)?\r?$`)
+
+// yamlSpans 按文档切分 YAML 文件：Kubernetes 资源每个文档一块，docker compose 每个服务一块，其余按顶层键切分
+func (p *CodeSplitter) yamlSpans(content []byte) []structuredSpan {
+	var spans []structuredSpan
+	start := 0
+	for _, separator := range append(yamlDocSeparator.FindAllIndex(content, -1), []int{len(content), len(content)}) {
+		spans = append(spans, p.yamlDocumentSpans(content, start, separator[0])...)
+		start = separator[1]
+	}
+	return spans
+}
+
+// yamlDocumentSpans 切分单个 YAML 文档，start、end 为文档在文件中的字节范围
+func (p *CodeSplitter) yamlDocumentSpans(content []byte, start, end int) []structuredSpan {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content[start:end], &doc); err != nil || len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode || root.Style&yaml.FlowStyle != 0 {
+		return nil
+	}
+	lineStarts := lineStartOffsets(content[start:end])
+	offset := func(node *yaml.Node) int {
+		line := min(max(node.Line-1, 0), len(lineStarts)-1)
+		return start + min(lineStarts[line]+max(node.Column-1, 0), end-start)
+	}
+
+	rootStart := offset(root)
+	if resource := kubernetesResource(root); resource != "" {
+		path := []string{resource}
+		if !p.tooLarge(content, rootStart, end) {
+			return []structuredSpan{{start: rootStart, end: end, path: path}}
+		}
+		return p.yamlMappingSpans(content, root, path, rootStart, end, offset)
+	}
+
+	var spans []structuredSpan
+	pairs := yamlPairs(root, rootStart, end, offset)
+	for _, pair := range pairs {
+		path := []string{pair.key.Value}
+		// docker compose 文件每个服务单独成块
+		if pair.key.Value == "services" && pair.value.Kind == yaml.MappingNode && pair.value.Style&yaml.FlowStyle == 0 {
+			spans = append(spans, p.yamlMappingSpans(content, pair.value, path, pair.start, pair.end, offset)...)
+			continue
+		}
+		spans = append(spans, p.yamlNodeSpans(content, pair.value, path, pair.start, pair.end, offset)...)
+	}
+	return spans
+}
+
+// yamlPair 映射中的一个键值对及其字节范围，范围到下一个键为止
+type yamlPair struct {
+	key, value *yaml.Node
+	start, end int
+}
+
+func yamlPairs(node *yaml.Node, start, end int, offset func(*yaml.Node) int) []yamlPair {
+	pairs := make([]yamlPair, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pair := yamlPair{key: node.Content[i], value: node.Content[i+1], start: offset(node.Content[i]), end: end}
+		if i+2 < len(node.Content) {
+			pair.end = offset(node.Content[i+2])
+		}
+		pairs = append(pairs, pair)
+	}
+	// 父级键所在行并入第一个子分块
+	if len(pairs) > 0 {
+		pairs[0].start = start
+	}
+	return pairs
+}
+
+// yamlNodeSpans 节点不超过分块上限时作为一个区间，否则按子节点切分
+func (p *CodeSplitter) yamlNodeSpans(content []byte, node *yaml.Node, path []string, start, end int,
+	offset func(*yaml.Node) int) []structuredSpan {
+	if !p.tooLarge(content, start, end) || node.Style&yaml.FlowStyle != 0 || len(node.Content) == 0 {
+		return []structuredSpan{{start: start, end: end, path: path}}
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		return p.yamlMappingSpans(content, node, path, start, end, offset)
+	case yaml.SequenceNode:
+		var spans []structuredSpan
+		for i, item := range node.Content {
+			// 序列项从 "- " 所在行开始
+			itemStart := max(start, offset(item)-lineColumn(content, offset(item)))
+			if i == 0 {
+				itemStart = start
+			}
+			itemEnd := end
+			if i+1 < len(node.Content) {
+				next := offset(node.Content[i+1])
+				itemEnd = next - lineColumn(content, next)
+			}
+			spans = append(spans, p.yamlNodeSpans(content, item, slices.Concat(path, []string{sequenceItemName(item, i)}), itemStart, itemEnd, offset)...)
+		}
+		return spans
+	default:
+		return []structuredSpan{{start: start, end: end, path: path}}
+	}
+}
+
+func (p *CodeSplitter) yamlMappingSpans(content []byte, node *yaml.Node, path []string, start, end int,
+	offset func(*yaml.Node) int) []structuredSpan {
+	var spans []structuredSpan
+	for _, pair := range yamlPairs(node, start, end, offset) {
+		spans = append(spans, p.yamlNodeSpans(content, pair.value, slices.Concat(path, []string{pair.key.Value}), pair.start, pair.end, offset)...)
+	}
+	return spans
+}
+
+// kubernetesResource 返回 Kubernetes 资源的 kind/name，不是 Kubernetes 资源时返回空
+func kubernetesResource(root *yaml.Node) string {
+	kind, metadata := yamlValue(root, "kind"), yamlValue(root, "metadata")
+	if kind == nil || kind.Kind != yaml.ScalarNode || yamlValue(root, "apiVersion") == nil {
+		return ""
+	}
+	if metadata != nil {
+		if name := yamlValue(metadata, "name"); name != nil && name.Kind == yaml.ScalarNode {
+			return kind.Value + "/" + name.Value
+		}
+	}
+	return kind.Value
+}
+
+// yamlValue 获取映射中键对应的值
+func yamlValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// sequenceItemName 序列项的名称，带 name 字段的映射（如容器、任务）使用该字段，否则使用序号
+func sequenceItemName(item *yaml.Node, index int) string {
+	if name := yamlValue(item, "name"); name != nil && name.Kind == yaml.ScalarNode && name.Value != "" {
+		return name.Value
+	}
+	return fmt.Sprintf("[%d]", index)
+}
+
+// lineColumn 返回字节偏移在所在行中的列
+func lineColumn(content []byte, offset int) int {
+	return offset - (bytes.LastIndexByte(content[:offset], '\n') + 1)
+}
+
+// jsonSpans 按顶层键切分 JSON 文件，超过分块上限的值继续按下一级键切分
+func (p *CodeSplitter) jsonSpans(content []byte) []structuredSpan {
+	if !json.Valid(content) {
+		return nil
+	}
+	start := skipJSONSpace(content, 0)
+	if content[start] != '{' && content[start] != '[' {
+		return nil
+	}
+	return p.jsonMemberSpans(content, start, jsonValueEnd(content, start), nil)
+}
+
+// jsonMemberSpans 将对象或数组的成员切分为区间，start、end 为对象或数组的字节范围
+func (p *CodeSplitter) jsonMemberSpans(content []byte, start, end int, path []string) []structuredSpan {
+	var spans []structuredSpan
+	isObject := content[start] == '{'
+	i := skipJSONSpace(content, start+1)
+	for index := 0; i < end-1; index++ {
+		memberStart := i
+		key := fmt.Sprintf("[%d]", index)
+		if isObject {
+			keyEnd := jsonValueEnd(content, i)
+			if err := json.Unmarshal(content[i:keyEnd], &key); err != nil {
+				return spans
+			}
+			i = skipJSONSpace(content, skipJSONSpace(content, keyEnd)+1)
+		}
+		valueEnd := jsonValueEnd(content, i)
+		memberPath := slices.Concat(path, []string{key})
+		if (content[i] == '{' || content[i] == '[') && p.tooLarge(content, memberStart, valueEnd) {
+			children := p.jsonMemberSpans(content, i, valueEnd, memberPath)
+			if len(children) > 0 {
+				// 键名并入第一个子分块
+				children[0].start = memberStart
+				spans = append(spans, children...)
+			} else {
+				spans = append(spans, structuredSpan{start: memberStart, end: valueEnd, path: memberPath})
+			}
+		} else {
+			spans = append(spans, structuredSpan{start: memberStart, end: valueEnd, path: memberPath})
+		}
+		i = skipJSONSpace(content, valueEnd)
+		if i < end && content[i] == ',' {
+			i = skipJSONSpace(content, i+1)
+		}
+	}
+	return spans
+}
+
+func skipJSONSpace(content []byte, i int) int {
+	for i < len(content) && (content[i] == ' ' || content[i] == '\t' || content[i] == '\r' || content[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+// jsonValueEnd 返回从 start 开始的 JSON 值的结束位置，调用方保证内容是合法的 JSON
+func jsonValueEnd(content []byte, start int) int {
+	switch content[start] {
+	case '"':
+		for i := start + 1; i < len(content); i++ {
+			switch content[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1
+			}
+		}
+		return len(content)
+	case '{', '[':
+		depth := 0
+		for i := start; i < len(content); i++ {
+			switch content[i] {
+			case '"':
+				i = jsonValueEnd(content, i) - 1
+			case '{', '[':
+				depth++
+			case '}', ']':
+				if depth--; depth == 0 {
+					return i + 1
+				}
+			}
+		}
+		return len(content)
+	default:
+		i := start
+		for i < len(content) && !strings.ContainsRune(",}] \t\r\n", rune(content[i])) {
+			i++
+		}
+		return i
+	}
+}
+
+// tomlTablePattern 匹配 TOML 表头 [table] 和数组表头 [[array]]
+var tomlTablePattern = regexp.MustCompile(`^\s*(\[\[?)\s*([^\[\]]+?)\s*\]\]?\s*(#.*)?$`)
+
+// tomlKeyPattern 匹配 TOML 键值对的键
+var tomlKeyPattern = regexp.MustCompile(`^\s*([A-Za-z0-9_\-."' ]+?)\s*=`)
+
+// tomlSpans 按表切分 TOML 文件，第一个表之前的顶层键各自成块
+func tomlSpans(content []byte) []structuredSpan {
+	var (
+		spans      []structuredSpan
+		arrayIndex = make(map[string]int)
+		inTable    bool
+		multiline  string // 未闭合的多行字符串定界符
+		offset     int
+	)
+	for _, line := range bytes.SplitAfter(content, []byte("\n")) {
+		lineStart := offset
+		offset += len(line)
+		text := strings.TrimRight(string(line), "\r\n")
+		if multiline != "" {
+			if strings.Count(text, multiline)%2 == 1 {
+				multiline = ""
+			}
+			continue
+		}
+
+		var path []string
+		if m := tomlTablePattern.FindStringSubmatch(text); m != nil {
+			path = tomlKeyPath(m[2])
+			if m[1] == "[[" {
+				key := strings.Join(path, ".")
+				path[len(path)-1] += fmt.Sprintf("[%d]", arrayIndex[key])
+				arrayIndex[key]++
+			}
+			inTable = true
+		} else if m := tomlKeyPattern.FindStringSubmatch(text); m != nil && !inTable {
+			path = tomlKeyPath(m[1])
+		}
+		if path != nil {
+			if len(spans) > 0 {
+				spans[len(spans)-1].end = lineStart
+			}
+			spans = append(spans, structuredSpan{start: lineStart, end: len(content), path: path})
+		}
+
+		for _, delimiter := range []string{`"""`, `'''`} {
+			if strings.Count(text, delimiter)%2 == 1 {
+				multiline = delimiter
+				break
+			}
+		}
+	}
+	return spans
+}
+
+// tomlKeyPath 将点分隔的 TOML 键拆分为键路径，引号内的点不作为分隔符
+func tomlKeyPath(key string) []string {
+	var (
+		path  []string
+		part  strings.Builder
+		quote rune
+	)
+	for _, r := range key {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				part.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '.':
+			path = append(path, strings.TrimSpace(part.String()))
+			part.Reset()
+		default:
+			part.WriteRune(r)
+		}
+	}
+	return append(path, strings.TrimSpace(part.String()))
+}
+
+var (
+	// protoDeclPattern 匹配 Protobuf 的 message、service、enum 和 extend 声明
+	protoDeclPattern = regexp.MustCompile(`^\s*(message|service|enum|extend)\s+([\w.]+)`)
+	// protoPackagePattern 匹配 Protobuf 的包声明
+	protoPackagePattern = regexp.MustCompile(`(?m)^\s*package\s+([\w.]+)\s*;`)
+)
+
+// protoSpans 切分 Protobuf 文件，顶层 message、service、enum 各自成块，键路径以包名开头
+func protoSpans(content []byte) []structuredSpan {
+	var pkg []string
+	if m := protoPackagePattern.FindSubmatch(content); m != nil {
+		pkg = []string{string(m[1])}
+	}
+
+	var spans []structuredSpan
+	for i := 0; i < len(content); {
+		lineEnd := len(content)
+		if n := bytes.IndexByte(content[i:], '\n'); n >= 0 {
+			lineEnd = i + n
+		}
+		m := protoDeclPattern.FindSubmatch(content[i:lineEnd])
+		open := bytes.IndexByte(content[i:], '{')
+		if m == nil || open < 0 {
+			i = lineEnd + 1
+			continue
+		}
+		end := blockEnd(content, i+open, "//")
+		path := slices.Concat(pkg, []string{string(m[1]) + " " + string(m[2])})
+		spans = append(spans, structuredSpan{start: leadingCommentStart(content, i, "//"), end: end, path: path})
+		i = end
+	}
+	return spans
+}
+
+// graphqlDefPattern 匹配 GraphQL 顶层的类型定义和操作定义
+var graphqlDefPattern = regexp.MustCompile(
+	`^(extend\s+)?(type|input|interface|enum|union|scalar|schema|directive|query|mutation|subscription|fragment)\b\s*@?(\w*)`)
+
+// graphqlSpans 切分 GraphQL SDL 和操作文件，每个顶层定义（含描述和注释）成块
+func graphqlSpans(content []byte) []structuredSpan {
+	var (
+		spans         []structuredSpan
+		depth         int
+		inDescription bool // 是否在多行描述字符串中
+	)
+	for i := 0; i < len(content); {
+		lineEnd := len(content)
+		if n := bytes.IndexByte(content[i:], '\n'); n >= 0 {
+			lineEnd = i + n
+		}
+		line := content[i:lineEnd]
+		if bytes.Count(line, []byte(`"""`))%2 == 1 {
+			inDescription = !inDescription
+			i = lineEnd + 1
+			continue
+		}
+		if inDescription {
+			i = lineEnd + 1
+			continue
+		}
+		if depth == 0 {
+			var name string
+			if m := graphqlDefPattern.FindSubmatch(line); m != nil {
+				name = strings.TrimSpace(string(m[1]) + string(m[2]) + " " + string(m[3]))
+			} else if bytes.HasPrefix(line, []byte("{")) {
+				name = "query"
+			}
+			if name != "" {
+				start := leadingCommentStart(content, i, "#")
+				if len(spans) > 0 {
+					spans[len(spans)-1].end = start
+				}
+				spans = append(spans, structuredSpan{start: start, end: len(content), path: []string{name}})
+			}
+		}
+		depth = max(depth+graphqlBraceDelta(line), 0)
+		i = lineEnd + 1
+	}
+	return spans
+}
+
+// graphqlBraceDelta 统计一行中花括号的嵌套变化，忽略字符串和注释中的花括号
+func graphqlBraceDelta(line []byte) int {
+	delta := 0
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case inString:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '#':
+			return delta
+		case c == '{':
+			delta++
+		case c == '}':
+			delta--
+		}
+	}
+	return delta
+}
+
+// blockEnd 返回与 open 处左花括号匹配的右花括号之后的位置，忽略字符串和注释中的花括号
+func blockEnd(content []byte, open int, lineComment string) int {
+	depth := 0
+	for i := open; i < len(content); i++ {
+		switch c := content[i]; {
+		case c == '"' || c == '\'':
+			for i++; i < len(content) && content[i] != c && content[i] != '\n'; i++ {
+				if content[i] == '\\' {
+					i++
+				}
+			}
+		case bytes.HasPrefix(content[i:], []byte(lineComment)):
+			if n := bytes.IndexByte(content[i:], '\n'); n >= 0 {
+				i += n
+			} else {
+				return len(content)
+			}
+		case bytes.HasPrefix(content[i:], []byte("/*")):
+			if n := bytes.Index(content[i:], []byte("*/")); n >= 0 {
+				i += n + 1
+			} else {
+				return len(content)
+			}
+		case c == '{':
+			depth++
+		case c == '}':
+			if depth--; depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(content)
+}
+
+// leadingCommentStart 返回紧邻声明之前的注释行（GraphQL 还包括描述字符串）的起始位置，中间有空行时停止
+func leadingCommentStart(content []byte, start int, lineComment string) int {
+	for start > 0 {
+		prevEnd := start - 1
+		prevStart := bytes.LastIndexByte(content[:prevEnd], '\n') + 1
+		line := bytes.TrimSpace(content[prevStart:prevEnd])
+		switch {
+		case bytes.HasPrefix(line, []byte(lineComment)):
+		case lineComment == "#" && bytes.HasPrefix(line, []byte(`"`)) && bytes.HasSuffix(line, []byte(`"`)) && len(line) > 1 &&
+			(!bytes.HasPrefix(line, []byte(`"""`)) || len(line) >= 6):
+		case lineComment == "#" && bytes.HasSuffix(line, []byte(`"""`)):
+			// 多行描述字符串，向前找到开头的三引号
+			open := bytes.LastIndex(content[:prevStart], []byte(`"""`))
+			if open < 0 {
+				return start
+			}
+			prevStart = bytes.LastIndexByte(content[:open], '\n') + 1
+		default:
+			return start
+		}
+		start = prevStart
+	}
+	return start
+}
@@ -0,0 +1,180 @@
+package embedding
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/parser"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+func splitStructured(t *testing.T, path, content string, maxTokens int) []*types.CodeChunk {
+	splitter, err := NewCodeSplitter(SplitOptions{
+		MaxTokensPerChunk:          maxTokens,
+		SlidingWindowOverlapTokens: 10,
+		EnableStructuredParsing:    true,
+	})
+	require.NoError(t, err)
+	chunks, err := splitter.Split(&types.SourceFile{Path: path, Content: []byte(content)})
+	require.NoError(t, err)
+	return chunks
+}
+
+func breadcrumbs(chunks []*types.CodeChunk) []string {
+	var result []string
+	for _, chunk := range chunks {
+		result = append(result, chunk.Breadcrumb)
+	}
+	return result
+}
+
+func TestSplitKubernetesYAML(t *testing.T) {
+	content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+    - port: 80
+`
+	chunks := splitStructured(t, "deploy/web.yaml", content, 1000)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, []string{"Deployment/web", "Service/web"}, breadcrumbs(chunks))
+	assert.Equal(t, []int{0, 0, 5, 13}, chunks[0].Range)
+	assert.True(t, strings.HasPrefix(string(chunks[1].Content), "apiVersion: v1\nkind: Service"))
+}
+
+func TestSplitComposeYAML(t *testing.T) {
+	content := `version: "3.8"
+services:
+  web:
+    image: nginx:1.27
+    ports:
+      - "80:80"
+  db:
+    image: postgres:16
+    environment:
+      POSTGRES_DB: app
+volumes:
+  data: {}
+`
+	splitter, err := NewCodeSplitter(SplitOptions{MaxTokensPerChunk: 1000, EnableStructuredParsing: true})
+	require.NoError(t, err)
+	chunks, err := splitter.Split(&types.SourceFile{Path: "docker-compose.yml", Content: []byte(content)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"version", "services > web", "services > db", "volumes"}, breadcrumbs(chunks))
+	// 服务名所在的父级键并入第一个服务
+	assert.True(t, strings.HasPrefix(string(chunks[1].Content), "services:\n  web:"))
+	assert.Equal(t, "db:\n    image: postgres:16\n    environment:\n      POSTGRES_DB: app", string(chunks[2].Content))
+}
+
+func TestSplitLargeJSON(t *testing.T) {
+	content := `{
+  "name": "app",
+  "scripts": {
+    "build": "tsc -p tsconfig.json && vite build --mode production",
+    "test": "vitest run --coverage --reporter verbose"
+  },
+  "private": true
+}
+`
+	chunks := splitStructured(t, "package.json", content, 32)
+	assert.Equal(t, []string{"name", "scripts > build", "scripts > test", "private"}, breadcrumbs(chunks))
+	assert.True(t, strings.HasPrefix(string(chunks[1].Content), `"scripts": {`))
+	assert.Equal(t, 4, chunks[2].Range[0])
+}
+
+func TestSplitTOML(t *testing.T) {
+	content := `name = "indexer"
+description = """
+[not a table]
+"""
+
+[tool.poetry]
+version = "1.0.0"
+
+[[bin]]
+name = "a"
+
+[[bin]]
+name = "b"
+`
+	splitter, err := NewCodeSplitter(SplitOptions{MaxTokensPerChunk: 1000, EnableStructuredParsing: true})
+	require.NoError(t, err)
+	chunks, err := splitter.Split(&types.SourceFile{Path: "pyproject.toml", Content: []byte(content)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"name", "description", "tool > poetry", "bin[0]", "bin[1]"}, breadcrumbs(chunks))
+	assert.Equal(t, "description = \"\"\"\n[not a table]\n\"\"\"", string(chunks[1].Content))
+}
+
+func TestSplitProto(t *testing.T) {
+	content := `syntax = "proto3";
+
+package acme.user.v1;
+
+// User 用户信息
+message User {
+  string id = 1;
+  // 嵌套消息
+  message Address { string city = 1; }
+}
+
+service UserService {
+  rpc GetUser(GetUserRequest) returns (User) {
+    option (google.api.http) = { get: "/v1/users/{id}" };
+  }
+}
+`
+	chunks := splitStructured(t, "user.proto", content, 1000)
+	require.Len(t, chunks, 3)
+	assert.Equal(t, "", chunks[0].Breadcrumb)
+	assert.Equal(t, "acme.user.v1 > message User", chunks[1].Breadcrumb)
+	assert.True(t, strings.HasPrefix(string(chunks[1].Content), "// User 用户信息\nmessage User {"))
+	assert.Equal(t, "acme.user.v1 > service UserService", chunks[2].Breadcrumb)
+	assert.Equal(t, []int{11, 0, 15, 1}, chunks[2].Range)
+}
+
+func TestSplitGraphQL(t *testing.T) {
+	content := `"""
+A registered user
+type is not a definition here
+"""
+type User {
+  id: ID!
+  name: String
+}
+
+# Look up a user
+query GetUser($id: ID!) {
+  user(id: $id) { id name }
+}
+
+scalar DateTime
+`
+	chunks := splitStructured(t, "schema.graphql", content, 1000)
+	assert.Equal(t, []string{"type User", "query GetUser", "scalar DateTime"}, breadcrumbs(chunks))
+	assert.True(t, strings.HasPrefix(string(chunks[0].Content), `"""`))
+	assert.True(t, strings.HasPrefix(string(chunks[1].Content), "# Look up a user\nquery GetUser"))
+}
+
+func TestSplitInvalidStructuredFile(t *testing.T) {
+	chunks := splitStructured(t, "broken.json", `{"name": "app",`, 1000)
+	require.Len(t, chunks, 1)
+	assert.Equal(t, "", chunks[0].Breadcrumb)
+
+	splitter, err := NewCodeSplitter(SplitOptions{MaxTokensPerChunk: 1000})
+	require.NoError(t, err)
+	_, err = splitter.Split(&types.SourceFile{Path: "config.yaml", Content: []byte("a: 1")})
+	// 未开启结构化切分时视为不支持的文件
+	assert.ErrorIs(t, err, parser.ErrLangConfNotFound)
+}
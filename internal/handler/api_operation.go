@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/zeromicro/go-zero/rest/httpx"
+	"github.com/zgsm-ai/codebase-indexer/internal/logic"
+	"github.com/zgsm-ai/codebase-indexer/internal/response"
+	"github.com/zgsm-ai/codebase-indexer/internal/svc"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+	"github.com/zgsm-ai/codebase-indexer/pkg/utils"
+)
+
+func apiOperationSearchHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.APIOperationSearchRequest
+		if err := httpx.Parse(r, &req); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		// 从请求头获取 Authorization
+		authorization := r.Header.Get("Authorization")
+		if authorization == "" {
+			response.Error(w, response.NewAuthError("missing Authorization header"))
+			return
+		}
+
+		l := logic.NewAPIOperationSearchLogic(r.Context(), svcCtx)
+		resp, err := l.APIOperationSearch(&req, authorization, utils.ParseJWTUserInfo(r, svcCtx.Config.Auth.UserInfoHeader))
+		if err != nil {
+			response.Error(w, err)
+		} else {
+			response.Json(w, resp)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/zeromicro/go-zero/rest/httpx"
+	"github.com/zgsm-ai/codebase-indexer/internal/logic"
+	"github.com/zgsm-ai/codebase-indexer/internal/response"
+	"github.com/zgsm-ai/codebase-indexer/internal/svc"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+func codebaseSummaryHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.CodebaseSummaryRequest
+		if err := httpx.Parse(r, &req); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		l := logic.NewCodebaseSummaryLogic(r.Context(), svcCtx)
+		resp, err := l.SetSummary(&req)
+		if err != nil {
+			response.Error(w, err)
+		} else {
+			response.Json(w, resp)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/zgsm-ai/codebase-indexer/internal/response"
 	"github.com/zgsm-ai/codebase-indexer/internal/svc"
 	"github.com/zgsm-ai/codebase-indexer/internal/types"
+	"github.com/zgsm-ai/codebase-indexer/pkg/utils"
 )
 
 func documentSearchHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
@@ -34,7 +35,7 @@ func documentSearchHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
 			req.ClientId, req.CodebasePath, req.Query, req.TopK, req.ScoreThreshold)
 
 		l := logic.NewDocumentSearchLogic(r.Context(), svcCtx)
-		resp, err := l.DocumentSearch(&req, authorization)
+		resp, err := l.DocumentSearch(&req, authorization, utils.ParseJWTUserInfo(r, svcCtx.Config.Auth.UserInfoHeader))
 		if err != nil {
 			response.Error(w, err)
 		} else {
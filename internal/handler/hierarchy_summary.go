@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/zeromicro/go-zero/rest/httpx"
+	"github.com/zgsm-ai/codebase-indexer/internal/logic"
+	"github.com/zgsm-ai/codebase-indexer/internal/response"
+	"github.com/zgsm-ai/codebase-indexer/internal/svc"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+func hierarchySummaryHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.HierarchySummaryRequest
+		if err := httpx.Parse(r, &req); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		l := logic.NewHierarchySummaryLogic(r.Context(), svcCtx)
+		resp, err := l.BuildSummaries(&req, r.Header.Get("Authorization"))
+		if err != nil {
+			response.Error(w, err)
+		} else {
+			response.Json(w, resp)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/logic"
+	"github.com/zgsm-ai/codebase-indexer/internal/response"
+	"github.com/zgsm-ai/codebase-indexer/internal/svc"
+)
+
+func readinessHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := logic.NewReadinessLogic(r.Context(), svcCtx)
+		resp := l.Readiness()
+		if !resp.Ready {
+			response.Unavailable(w, resp)
+			return
+		}
+		response.Json(w, resp)
+	}
+}
@@ -27,11 +27,17 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 				Path:    "/api/v1/search/document",
 				Handler: documentSearchHandler(serverCtx),
 			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/search/api-operations",
+				Handler: apiOperationSearchHandler(serverCtx),
+			},
 		},
 		rest.WithPrefix("/codebase-embedder"),
 	)
 	log.Println("[DEBUG] 已注册路由: POST /codebase-embedder/api/v1/search/semantic")
 	log.Println("[DEBUG] 已注册路由: POST /codebase-embedder/api/v1/search/document")
+	log.Println("[DEBUG] 已注册路由: POST /codebase-embedder/api/v1/search/api-operations")
 
 	server.AddRoutes(
 		[]rest.Route{
@@ -188,5 +194,59 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 		rest.WithPrefix("/codebase-embedder"),
 	)
 	log.Println("[DEBUG] 已注册路由: GET /codebase-embedder/api/v1/dictionary/records")
+
+	server.AddRoutes(
+		[]rest.Route{
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/readiness",
+				Handler: readinessHandler(serverCtx),
+			},
+		},
+		rest.WithPrefix("/codebase-embedder"),
+	)
+	log.Println("[DEBUG] 已注册路由: GET /codebase-embedder/api/v1/readiness")
+
+	server.AddRoutes(
+		[]rest.Route{
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/usage",
+				Handler: usageHandler(serverCtx),
+			},
+		},
+		rest.WithPrefix("/codebase-embedder"),
+	)
+	log.Println("[DEBUG] 已注册路由: GET /codebase-embedder/api/v1/usage")
+
+	server.AddRoutes(
+		[]rest.Route{
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/embeddings/migrate",
+				Handler: vectorMigrationHandler(serverCtx),
+			},
+		},
+		rest.WithPrefix("/codebase-embedder"),
+	)
+	log.Println("[DEBUG] 已注册路由: POST /codebase-embedder/api/v1/embeddings/migrate")
+
+	server.AddRoutes(
+		[]rest.Route{
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/codebase/summary",
+				Handler: codebaseSummaryHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/codebase/summary/build",
+				Handler: hierarchySummaryHandler(serverCtx),
+			},
+		},
+		rest.WithPrefix("/codebase-embedder"),
+	)
+	log.Println("[DEBUG] 已注册路由: POST /codebase-embedder/api/v1/codebase/summary")
+	log.Println("[DEBUG] 已注册路由: POST /codebase-embedder/api/v1/codebase/summary/build")
 	log.Println("[DEBUG] 路由注册完成")
 }
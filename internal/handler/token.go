@@ -8,6 +8,8 @@ import (
 	"github.com/zgsm-ai/codebase-indexer/internal/response"
 	"github.com/zgsm-ai/codebase-indexer/internal/svc"
 	"github.com/zgsm-ai/codebase-indexer/internal/types"
+	"github.com/zgsm-ai/codebase-indexer/internal/usage"
+	"github.com/zgsm-ai/codebase-indexer/pkg/utils"
 
 	"github.com/zeromicro/go-zero/rest/httpx"
 )
@@ -35,10 +37,10 @@ func (h *tokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// 创建token逻辑
 	tokenLogic := logic.NewTokenLogic(r.Context(), h.svcCtx)
-	tokenResp, err := tokenLogic.GenerateToken(&req)
+	tokenResp, err := tokenLogic.GenerateToken(&req, utils.ParseJWTUserInfo(r, h.svcCtx.Config.Auth.UserInfoHeader))
 	if err != nil {
-		// 检查是否为限流错误
-		if errors.Is(err, types.ErrRateLimitReached) {
+		// 检查是否为限流或用量配额错误
+		if errors.Is(err, types.ErrRateLimitReached) || errors.Is(err, usage.ErrQuotaExceeded) {
 			response.RateLimit(w, err)
 			return
 		}
@@ -0,0 +1,286 @@
+package ignore
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 默认读取的忽略规则文件，同一目录下越靠后优先级越高
+var DefaultIgnoreFiles = []string{".gitignore", ".ignore", ".embedderignore"}
+
+// rule 单条忽略规则
+type rule struct {
+	base    string // 规则文件所在目录（相对代码库根目录），根目录为空
+	pattern string // 原始模式
+	re      *regexp.Regexp
+	negate  bool // 以 ! 开头，重新包含
+	dirOnly bool // 以 / 结尾，仅匹配目录
+}
+
+// Matcher 按 gitignore 语义判断文件是否被忽略
+type Matcher struct {
+	rules []*rule
+}
+
+// New 创建匹配器，patterns 作为代码库根目录下优先级最低的全局规则
+func New(patterns []string) *Matcher {
+	m := &Matcher{}
+	m.AddPatterns("", patterns)
+	return m
+}
+
+// Load 从上传的文件中解析忽略规则文件，names 为规则文件名（按优先级从低到高）
+func Load(files map[string][]byte, names []string, globalPatterns []string) *Matcher {
+	m := New(globalPatterns)
+	if len(names) == 0 {
+		names = DefaultIgnoreFiles
+	}
+
+	ignoreFiles := make([]string, 0)
+	for filePath := range files {
+		if IsIgnoreFile(filePath, names) {
+			ignoreFiles = append(ignoreFiles, filePath)
+		}
+	}
+
+	// 浅层目录先加载，深层目录的规则后加载以覆盖上层规则；同目录按 names 中的顺序
+	rank := make(map[string]int, len(names))
+	for i, name := range names {
+		rank[name] = i
+	}
+	sort.Slice(ignoreFiles, func(i, j int) bool {
+		di, dj := dirOf(ignoreFiles[i]), dirOf(ignoreFiles[j])
+		if depth(di) != depth(dj) {
+			return depth(di) < depth(dj)
+		}
+		if di != dj {
+			return di < dj
+		}
+		return rank[path.Base(toSlash(ignoreFiles[i]))] < rank[path.Base(toSlash(ignoreFiles[j]))]
+	})
+
+	for _, filePath := range ignoreFiles {
+		dir := dirOf(filePath)
+		// 与 git 一致：被忽略目录中的规则文件不生效
+		if dir != "" && m.isDirIgnored(dir) {
+			continue
+		}
+		m.AddPatterns(dir, strings.Split(string(files[filePath]), "\n"))
+	}
+	return m
+}
+
+// MergeFiles 合并上次同步保存的规则文件和本次上传的规则文件：本次上传的覆盖同路径的旧文件，
+// deleted 中的规则文件被移除。路径统一为 / 分隔
+func MergeFiles(stored, uploaded map[string][]byte, deleted []string) map[string][]byte {
+	merged := make(map[string][]byte, len(stored)+len(uploaded))
+	for filePath, content := range stored {
+		merged[toSlash(filePath)] = content
+	}
+	for _, filePath := range deleted {
+		delete(merged, toSlash(filePath))
+	}
+	for filePath, content := range uploaded {
+		merged[toSlash(filePath)] = content
+	}
+	return merged
+}
+
+// IsIgnoreFile 判断路径是否为忽略规则文件
+func IsIgnoreFile(filePath string, names []string) bool {
+	if len(names) == 0 {
+		names = DefaultIgnoreFiles
+	}
+	base := path.Base(toSlash(filePath))
+	for _, name := range names {
+		if base == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AddPatterns 添加 base 目录下的一组规则（每个元素对应规则文件中的一行）
+func (m *Matcher) AddPatterns(base string, lines []string) {
+	base = strings.Trim(toSlash(base), "/")
+	for _, line := range lines {
+		if r := parseRule(base, line); r != nil {
+			m.rules = append(m.rules, r)
+		}
+	}
+}
+
+// Empty 是否没有任何规则
+func (m *Matcher) Empty() bool {
+	return m == nil || len(m.rules) == 0
+}
+
+// IsIgnored 判断相对代码库根目录的文件路径是否被忽略
+func (m *Matcher) IsIgnored(filePath string) bool {
+	if m.Empty() {
+		return false
+	}
+	p := strings.Trim(toSlash(filePath), "/")
+	if p == "" {
+		return false
+	}
+	// 父目录被忽略时，其下文件无法通过 ! 重新包含
+	if dir := dirOf(p); dir != "" && m.isDirIgnored(dir) {
+		return true
+	}
+	return m.match(p, false)
+}
+
+// isDirIgnored 判断目录自身或任一祖先目录是否被忽略
+func (m *Matcher) isDirIgnored(dir string) bool {
+	segments := strings.Split(dir, "/")
+	for i := range segments {
+		if m.match(strings.Join(segments[:i+1], "/"), true) {
+			return true
+		}
+	}
+	return false
+}
+
+// match 按规则顺序匹配，最后一条命中的规则生效
+func (m *Matcher) match(p string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		rel := p
+		if r.base != "" {
+			if !strings.HasPrefix(p, r.base+"/") {
+				continue
+			}
+			rel = p[len(r.base)+1:]
+		}
+		if r.re.MatchString(rel) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// parseRule 解析规则文件中的一行，空行和注释返回 nil
+func parseRule(base, line string) *rule {
+	line = strings.TrimSuffix(line, "\r")
+	line = trimTrailingSpaces(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	r := &rule{base: base, pattern: line}
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") && !strings.HasSuffix(line, `\/`) {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return nil
+	}
+
+	// 开头或中间包含 / 时相对规则文件所在目录锚定，否则匹配任意层级的名称
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil
+	}
+	r.re = re
+	return r
+}
+
+// globToRegexp 将 gitignore 通配符转换为正则表达式
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				atStart := i == 0 || glob[i-1] == '/'
+				atEnd := i+2 == len(glob)
+				if atStart && atEnd {
+					// 末尾的 /** 匹配目录下的所有内容；单独的 ** 匹配任意路径
+					sb.WriteString(".*")
+					i++
+					continue
+				}
+				if atStart && glob[i+2] == '/' {
+					// **/ 匹配零个或多个目录
+					sb.WriteString("(?:.*/)?")
+					i += 2
+					continue
+				}
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// trimTrailingSpaces 去除行尾未转义的空格
+func trimTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-2] + " "
+	}
+	return line
+}
+
+func toSlash(p string) string {
+	return strings.ReplaceAll(p, `\`, "/")
+}
+
+func dirOf(p string) string {
+	dir := path.Dir(strings.Trim(toSlash(p), "/"))
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+func depth(dir string) int {
+	if dir == "" {
+		return 0
+	}
+	return strings.Count(dir, "/") + 1
+}
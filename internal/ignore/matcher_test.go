@@ -0,0 +1,93 @@
+package ignore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcherIsIgnored(t *testing.T) {
+	files := map[string][]byte{
+		".gitignore": []byte(`# 构建产物
+/build/
+*.log
+!keep.log
+node_modules/
+docs/**/*.generated.md
+\#hash.txt
+`),
+		".embedderignore": []byte("vendor/\n*.min.js\n"),
+		"web/.gitignore":  []byte("/dist\n!important.log\n"),
+		"web/.ignore":     []byte("fixtures/\n"),
+		// 被忽略目录中的规则文件不生效
+		"node_modules/.gitignore": []byte("!*.js\n"),
+	}
+	m := Load(files, nil, []string{"*.tmp"})
+
+	testCases := []struct {
+		path string
+		want bool
+	}{
+		{"main.go", false},
+		{"build/out.go", true},
+		{"src/build/out.go", false},
+		{"app.log", true},
+		{"a/b/app.log", true},
+		{"keep.log", false},
+		{"node_modules/lib/index.js", true},
+		{"pkg/node_modules/x.js", true},
+		{"docs/a/b/api.generated.md", true},
+		{"docs/api.generated.md", true},
+		{"docs/api.md", false},
+		{"#hash.txt", true},
+		{"vendor/github.com/x/y.go", true},
+		{"static/app.min.js", true},
+		{"web/dist/index.js", true},
+		{"dist/index.js", false},
+		{"web/important.log", false},
+		{"web/fixtures/data.json", true},
+		{"cache.tmp", true},
+		{`web\dist\main.js`, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.want, m.IsIgnored(tc.path))
+		})
+	}
+}
+
+func TestMatcherParentDirCannotBeReincluded(t *testing.T) {
+	m := New([]string{"logs/", "!logs/keep.txt"})
+	assert.True(t, m.IsIgnored("logs/keep.txt"))
+
+	m = New([]string{"logs/*", "!logs/keep.txt"})
+	assert.False(t, m.IsIgnored("logs/keep.txt"))
+	assert.True(t, m.IsIgnored("logs/other.txt"))
+}
+
+func TestIsIgnoreFile(t *testing.T) {
+	assert.True(t, IsIgnoreFile("a/b/.gitignore", nil))
+	assert.True(t, IsIgnoreFile(".embedderignore", nil))
+	assert.False(t, IsIgnoreFile("a/gitignore", nil))
+	assert.True(t, IsIgnoreFile("x/.customignore", []string{".customignore"}))
+}
+
+func TestMergeFiles(t *testing.T) {
+	stored := map[string][]byte{
+		".gitignore":     []byte("*.log"),
+		"web/.gitignore": []byte("dist/"),
+		"docs/.ignore":   []byte("drafts/"),
+	}
+	uploaded := map[string][]byte{`web\.gitignore`: []byte("build/")}
+	merged := MergeFiles(stored, uploaded, []string{"docs/.ignore"})
+	assert.Equal(t, map[string][]byte{
+		".gitignore":     []byte("*.log"),
+		"web/.gitignore": []byte("build/"),
+	}, merged)
+
+	// 增量同步只上传了代码文件，仍按上次保存的规则忽略
+	m := Load(MergeFiles(merged, nil, nil), nil, nil)
+	assert.True(t, m.IsIgnored("server.log"))
+	assert.True(t, m.IsIgnored("web/build/app.js"))
+	assert.False(t, m.IsIgnored("web/dist/app.js"))
+}
@@ -5,100 +5,53 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/zgsm-ai/codebase-indexer/internal/tracer"
 
 	"github.com/panjf2000/ants/v2"
+	"github.com/zgsm-ai/codebase-indexer/internal/dao/model"
 	"github.com/zgsm-ai/codebase-indexer/internal/errs"
 	"github.com/zgsm-ai/codebase-indexer/internal/svc"
-	// "github.com/zgsm-ai/codebase-indexer/internal/types"
 )
 
 // baseProcessor 包含所有处理器共有的字段和方法
 type baseProcessor struct {
-	svcCtx         *svc.ServiceContext
-	params         *IndexTaskParams
-	taskHistoryId  int32
-	totalFileCnt   int32
-	successFileCnt int32
-	failedFileCnt  int32
-	ignoreFileCnt  int32
+	svcCtx             *svc.ServiceContext
+	params             *IndexTaskParams
+	history            *taskHistory
+	totalFileCnt       int32
+	successFileCnt     int32
+	failedFileCnt      int32
+	ignoreFileCnt      int32 // 被忽略规则命中或按生成代码策略跳过的文件
+	unsupportedFileCnt int32 // 不支持的语言或文件类型
 }
 
-// initTaskHistory 初始化任务历史记录
-func (p *baseProcessor) initTaskHistory(ctx context.Context, taskType string) error {
-	// taskHistory := &model.IndexHistory{
-	// 	SyncID:       p.params.SyncID,
-	// 	CodebaseID:   p.params.CodebaseID,
-	// 	CodebasePath: p.params.CodebasePath,
-	// 	TaskType:     taskType,
-	// 	Status:       types.TaskStatusPending,
-	// 	StartTime:    utils.CurrentTime(),
-	// }
-	// if err := p.svcCtx.Querier.IndexHistory.WithContext(ctx).Save(taskHistory); err != nil {
-	// 	tracer.WithTrace(ctx).Errorf("insert task history failed: %v, data:%v", err, taskHistory)
-	// 	return errs.InsertDatabaseFailed
-	// }
-	// p.taskHistoryId = taskHistory.ID
-	return nil
-}
-
-// updateTaskSuccess 更新任务状态为成功
-func (p *baseProcessor) updateTaskSuccess(ctx context.Context) error {
-	// progress := float64(1)
-	// m := &model.IndexHistory{
-	// 	ID:                p.taskHistoryId,
-	// 	Status:            types.TaskStatusSuccess,
-	// 	Progress:          &progress,
-	// 	EndTime:           utils.CurrentTime(),
-	// 	TotalFileCount:    &p.totalFileCnt,
-	// 	TotalSuccessCount: &p.successFileCnt,
-	// 	TotalFailCount:    &p.failedFileCnt,
-	// 	TotalIgnoreCount:  &p.ignoreFileCnt,
-	// }
-
-	// res, err := p.svcCtx.Querier.IndexHistory.WithContext(ctx).
-	// 	Where(p.svcCtx.Querier.IndexHistory.ID.Eq(m.ID)).
-	// 	Updates(m)
-	// if err != nil {
-	// 	tracer.WithTrace(ctx).Errorf("update task history %d failed: %v, model:%v", p.params.CodebaseID, err, m)
-	// 	return fmt.Errorf("upate task success failed: %w", err)
-	// }
-	// if res.RowsAffected == 0 {
-	// 	tracer.WithTrace(ctx).Errorf("update task history %d failed: %v, model:%v", p.params.CodebaseID, err, m)
-	// 	return fmt.Errorf("upate task success failed, codebaseId %d not found in database", p.params.CodebaseID)
-	// }
-	// if res.Error != nil {
-	// 	tracer.WithTrace(ctx).Errorf("update task history %d failed: %v, model:%v", p.params.CodebaseID, err, m)
-	// 	return fmt.Errorf("upate task success failed: %w", res.Error)
-	// }
-	return nil
+// initTaskHistory 初始化任务历史记录，写入失败时任务继续执行，只是不记录历史
+func (p *baseProcessor) initTaskHistory(ctx context.Context, taskType string) {
+	history, err := startTaskHistory(ctx, p.svcCtx, &model.Codebase{
+		ID:   p.params.CodebaseID,
+		Path: p.params.CodebasePath,
+		Name: p.params.CodebaseName,
+	}, taskType)
+	if err != nil {
+		tracer.WithTrace(ctx).Errorf("init task history of codebase %d failed: %v", p.params.CodebaseID, err)
+		return
+	}
+	p.history = history
 }
 
-// handleIfTaskFailed 处理任务失败情况
-func (p *baseProcessor) handleIfTaskFailed(ctx context.Context, err error) bool {
-	return true
-	// if err != nil {
-	// 	tracer.WithTrace(ctx).Errorf("index task failed, err: %v", err)
-	// 	if errors.Is(err, errs.InsertDatabaseFailed) {
-	// 		return true
-	// 	}
-	// 	status := types.TaskStatusFailed
-	// 	if errors.Is(err, errs.RunTimeout) {
-	// 		status = types.TaskStatusTimeout
-	// 	}
-	// 	_, err = p.svcCtx.Querier.IndexHistory.WithContext(ctx).
-	// 		Where(p.svcCtx.Querier.IndexHistory.ID.Eq(p.taskHistoryId)).
-	// 		UpdateColumnSimple(p.svcCtx.Querier.IndexHistory.Status.Value(status),
-	// 			p.svcCtx.Querier.IndexHistory.ErrorMessage.Value(err.Error()))
-	// 	if err != nil {
-	// 		tracer.WithTrace(ctx).Errorf("update task history %d failed: %v", p.params.CodebaseID, err)
-	// 	}
-
-	// 	return true
-	// }
-	// return false
+// finishTaskHistory 记录文件数量和任务结果，任务超时或取消后仍需记录
+func (p *baseProcessor) finishTaskHistory(ctx context.Context, runErr error) {
+	ctx = context.WithoutCancel(ctx)
+	p.history.updateProgress(ctx, 1, taskCounts{
+		total:   int(atomic.LoadInt32(&p.totalFileCnt)),
+		success: int(atomic.LoadInt32(&p.successFileCnt)),
+		failed:  int(atomic.LoadInt32(&p.failedFileCnt)),
+		ignored: int(atomic.LoadInt32(&p.ignoreFileCnt)),
+	})
+	p.history.finish(ctx, runErr)
 }
 
 // processFilesConcurrently 并发处理文件
@@ -52,6 +52,9 @@ func NewCleaner(ctx context.Context, svcCtx *svc.ServiceContext) (Job, error) {
 			if err != nil {
 				logx.Errorf("cleaner drop codebase store %s error: %v", cb.Path, err)
 			}
+			if err = svcCtx.IgnoreRules.Delete(ctx, cb.ID); err != nil {
+				logx.Errorf("cleaner delete ignore rule files of codebase %s error: %v", cb.Path, err)
+			}
 
 			// todo update db status， 唯一索引的存在(client_id、codebasePath)，给client_id 加个唯一后缀，避免冲突。
 			cb.ClientID = cb.ClientID + "@" + uuid.New().String()
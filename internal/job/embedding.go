@@ -8,12 +8,20 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/zgsm-ai/codebase-indexer/internal/embedding"
 	"github.com/zgsm-ai/codebase-indexer/internal/errs"
 	"github.com/zgsm-ai/codebase-indexer/internal/parser"
 	"github.com/zgsm-ai/codebase-indexer/internal/store/vector"
 	"github.com/zgsm-ai/codebase-indexer/internal/svc"
 	"github.com/zgsm-ai/codebase-indexer/internal/tracer"
 	"github.com/zgsm-ai/codebase-indexer/internal/types"
+	"github.com/zgsm-ai/codebase-indexer/internal/usage"
+)
+
+const (
+	fileStatusUnsupported = "unsupported"
+	fileStatusIgnored     = "ignored"
+	fileStatusFailed      = "failed"
 )
 
 type embeddingProcessor struct {
@@ -40,12 +48,18 @@ type fileProcessResult struct {
 
 func (t *embeddingProcessor) Process(ctx context.Context) error {
 	tracer.WithTrace(ctx).Infof("start to execute embedding task, codebase: %s RequestId %s", t.params.CodebaseName, t.params.RequestId)
+	ctx = usage.WithScope(ctx, t.svcCtx.Usage, usage.Scope{
+		RequestID:    t.params.RequestId,
+		Source:       usage.SourceIndex,
+		CodebaseID:   t.params.CodebaseID,
+		CodebasePath: t.params.CodebasePath,
+		ClientID:     t.params.ClientId,
+		UserID:       t.params.UserId,
+	})
 	start := time.Now()
 
 	err := func(t *embeddingProcessor) error {
-		if err := t.initTaskHistory(ctx, types.TaskTypeEmbedding); err != nil {
-			return err
-		}
+		t.initTaskHistory(ctx, types.TaskTypeEmbedding)
 
 		t.totalFileCnt = int32(len(t.params.Files))
 
@@ -56,8 +70,11 @@ func (t *embeddingProcessor) Process(ctx context.Context) error {
 		var (
 			addChunks        = make([]*types.CodeChunk, 0, t.totalFileCnt)
 			deleteFilePaths  = make(map[string]struct{})
-			unsupportedFiles = make([]string, 0) // 收集不支持的文件路径
-			mu               sync.Mutex          // 保护 addChunks 和 unsupportedFiles
+			unsupportedFiles = make([]string, 0)                         // 收集不支持的文件路径
+			ignoredFiles     = make([]string, 0)                         // 收集被忽略规则命中或按生成代码策略跳过的文件路径
+			redactions       = make(map[string][]types.RedactionFinding) // 收集各文件的脱敏记录
+			coverages        = make(map[string]float64)                  // 收集各文件的分块覆盖率
+			mu               sync.Mutex                                  // 保护 addChunks、unsupportedFiles、ignoredFiles、redactions 和 coverages
 		)
 
 		// 处理单个文件的函数
@@ -66,6 +83,25 @@ func (t *embeddingProcessor) Process(ctx context.Context) error {
 			case <-ctx.Done():
 				return errs.RunTimeout
 			default:
+				if t.params.IgnoreMatcher.IsIgnored(path) {
+					mu.Lock()
+					ignoredFiles = append(ignoredFiles, path)
+					mu.Unlock()
+					atomic.AddInt32(&t.ignoreFileCnt, 1)
+					return nil
+				}
+
+				class := t.params.Classifier.Classify(path, content)
+				if (class.Generated || class.Vendored) &&
+					t.svcCtx.Config.IndexTask.EmbeddingTask.GeneratedCode.Action == embedding.GeneratedActionSkip {
+					tracer.WithTrace(ctx).Debugf("skip generated/vendored file %s, reason: %s", path, class.Reason)
+					mu.Lock()
+					ignoredFiles = append(ignoredFiles, path)
+					mu.Unlock()
+					atomic.AddInt32(&t.ignoreFileCnt, 1)
+					return nil
+				}
+
 				chunks, err := t.splitFile(&types.SourceFile{Path: path, Content: content})
 				if err != nil {
 					mu.Lock()
@@ -73,14 +109,30 @@ func (t *embeddingProcessor) Process(ctx context.Context) error {
 					mu.Unlock()
 
 					if parser.IsNotSupportedFileError(err) {
-
-						atomic.AddInt32(&t.ignoreFileCnt, 1)
+						atomic.AddInt32(&t.unsupportedFileCnt, 1)
 						return nil
 					}
 					atomic.AddInt32(&t.failedFileCnt, 1)
 					return err
 				}
+				for _, chunk := range chunks {
+					chunk.Generated = class.Generated
+					chunk.Vendored = class.Vendored
+				}
+				coverage := embedding.ChunkCoverage(embedding.SourceView(path, content), chunks)
+				// 分块内容会发送到外部嵌入模型并可能写入向量库，先替换其中的敏感信息
+				findings := t.redactChunks(chunks)
+				// 摘要请求同样发送脱敏后的内容，失败的分块不带摘要继续嵌入
+				if t.params.EnableSummary && t.svcCtx.Summarizer.Enabled() {
+					if err := t.svcCtx.Summarizer.Summarize(ctx, chunks); err != nil {
+						tracer.WithTrace(ctx).Errorf("failed to summarize chunks of file %s: %v", path, err)
+					}
+				}
 				mu.Lock()
+				if len(findings) > 0 {
+					redactions[path] = findings
+				}
+				coverages[path] = coverage
 
 				if len(chunks) <= 0 {
 					unsupportedFiles = append(unsupportedFiles, path)
@@ -97,56 +149,22 @@ func (t *embeddingProcessor) Process(ctx context.Context) error {
 
 		// 使用基础结构的并发处理方法
 		if err := t.processFilesConcurrently(ctx, processFile, t.svcCtx.Config.IndexTask.EmbeddingTask.MaxConcurrency); err != nil {
-
-			if len(unsupportedFiles) > 0 {
-				tracer.WithTrace(ctx).Infof("updating %d unsupported files status", len(unsupportedFiles))
-				err := t.svcCtx.StatusManager.UpdateFileStatus(ctx, t.params.RequestId,
-					func(status *types.FileStatusResponseData) {
-						status.Process = "completed"
-						status.TotalProgress = 100
-						for _, filePath := range unsupportedFiles {
-							for i, item := range status.FileList {
-								if item.Path == filePath {
-									status.FileList[i].Status = "unsupported"
-									tracer.WithTrace(ctx).Infof("marked file as unsupported: %s", filePath)
-									break
-								}
-							}
-						}
-					})
-				if err != nil {
-					tracer.WithTrace(ctx).Errorf("failed to update unsupported files status: %v", err)
-				}
-			}
+			t.markFilesStatus(ctx, unsupportedFiles, fileStatusUnsupported)
+			t.markFilesStatus(ctx, ignoredFiles, fileStatusIgnored)
+			t.markFilesRedactions(ctx, redactions)
+			t.markFilesCoverage(ctx, coverages)
 			return err
 		}
 
-		// 统一更新不支持的文件状态
-		if len(unsupportedFiles) > 0 {
-			tracer.WithTrace(ctx).Infof("updating %d unsupported files status", len(unsupportedFiles))
-			// 更新不支持的文件状态
-			err := t.svcCtx.StatusManager.UpdateFileStatus(ctx, t.params.RequestId,
-				func(status *types.FileStatusResponseData) {
-					status.Process = "completed"
-					status.TotalProgress = 100
-					for _, filePath := range unsupportedFiles {
-						for i, item := range status.FileList {
-							if item.Path == filePath {
-								status.FileList[i].Status = "unsupported"
-								tracer.WithTrace(ctx).Infof("marked file as unsupported: %s", filePath)
-								break
-							}
-						}
-					}
-				})
-			if err != nil {
-				tracer.WithTrace(ctx).Errorf("failed to update unsupported files status: %v", err)
-			}
-		}
+		// 统一更新不支持和被忽略的文件状态
+		t.markFilesStatus(ctx, unsupportedFiles, fileStatusUnsupported)
+		t.markFilesStatus(ctx, ignoredFiles, fileStatusIgnored)
+		t.markFilesRedactions(ctx, redactions)
+		t.markFilesCoverage(ctx, coverages)
 
 		// 打印不支持文件个数
-		tracer.WithTrace(ctx).Infof("embedding splitFile successfully, cost: %d ms, total: %d,success %d ,  unsupported: %d",
-			time.Since(start).Milliseconds(), t.totalFileCnt, t.successFileCnt, t.ignoreFileCnt)
+		tracer.WithTrace(ctx).Infof("embedding splitFile successfully, cost: %d ms, total: %d,success %d ,  unsupported: %d, ignored: %d",
+			time.Since(start).Milliseconds(), t.totalFileCnt, t.successFileCnt, t.unsupportedFileCnt, t.ignoreFileCnt)
 
 		var saveErrs []error
 		// 先删除，再写入
@@ -185,8 +203,20 @@ func (t *embeddingProcessor) Process(ctx context.Context) error {
 				SyncId:       t.params.SyncID,
 				RequestId:    t.params.RequestId,
 				TotalFiles:   t.params.TotalFiles,
+				VectorSlot:   t.params.VectorSlot,
 			})
-			if err != nil {
+			var embedFailedErr *vector.EmbeddingFailedError
+			if errors.As(err, &embedFailedErr) {
+				// 部分文件嵌入失败，其余文件已写入，只将失败文件计入失败数
+				tracer.WithTrace(ctx).Errorf("embedding task upsert code chunks partially failed: %v", err)
+				failedCnt := int32(len(embedFailedErr.FailedFiles))
+				t.failedFileCnt += failedCnt
+				t.successFileCnt = max(t.successFileCnt-failedCnt, 0)
+				t.markFilesFailed(ctx, embedFailedErr.FailedFiles)
+				if t.successFileCnt == 0 {
+					saveErrs = append(saveErrs, err)
+				}
+			} else if err != nil {
 				tracer.WithTrace(ctx).Errorf("embedding task upsert code chunks failed: %v", err)
 				t.failedFileCnt += t.successFileCnt
 				t.successFileCnt = 0
@@ -204,23 +234,146 @@ func (t *embeddingProcessor) Process(ctx context.Context) error {
 		if len(saveErrs) > 0 {
 			return errors.Join(saveErrs...)
 		}
-		// update task status
-		if err := t.updateTaskSuccess(ctx); err != nil {
-			tracer.WithTrace(ctx).Errorf("embedding task update status success error:%v", err)
-		}
-
 		return nil
 	}(t)
 
-	if t.handleIfTaskFailed(ctx, err) {
-		return fmt.Errorf("embedding task failed to update status, err:%v", err)
+	t.finishTaskHistory(ctx, err)
+	if err != nil {
+		return err
 	}
 
-	tracer.WithTrace(ctx).Infof("embedding task end successfully, cost: %d ms, total: %d, success: %d, failed: %d, unsupported: %d",
-		time.Since(start).Milliseconds(), t.totalFileCnt, t.successFileCnt, t.failedFileCnt, t.ignoreFileCnt)
+	tracer.WithTrace(ctx).Infof("embedding task end successfully, cost: %d ms, total: %d, success: %d, failed: %d, unsupported: %d, ignored: %d",
+		time.Since(start).Milliseconds(), t.totalFileCnt, t.successFileCnt, t.failedFileCnt, t.unsupportedFileCnt, t.ignoreFileCnt)
 	return nil
 }
 
+// markFilesStatus 将文件列表中指定文件的状态更新为 fileStatus
+func (t *embeddingProcessor) markFilesStatus(ctx context.Context, filePaths []string, fileStatus string) {
+	if len(filePaths) == 0 {
+		return
+	}
+	tracer.WithTrace(ctx).Infof("updating %d %s files status", len(filePaths), fileStatus)
+	err := t.svcCtx.StatusManager.UpdateFileStatus(ctx, t.params.RequestId,
+		func(status *types.FileStatusResponseData) {
+			status.Process = "completed"
+			status.TotalProgress = 100
+			for _, filePath := range filePaths {
+				for i, item := range status.FileList {
+					if item.Path == filePath {
+						status.FileList[i].Status = fileStatus
+						tracer.WithTrace(ctx).Infof("marked file as %s: %s", fileStatus, filePath)
+						break
+					}
+				}
+			}
+		})
+	if err != nil {
+		tracer.WithTrace(ctx).Errorf("failed to update %s files status: %v", fileStatus, err)
+	}
+}
+
+// markFilesFailed 将嵌入失败的文件状态更新为 failed 并记录原因
+func (t *embeddingProcessor) markFilesFailed(ctx context.Context, failedFiles map[string]error) {
+	if len(failedFiles) == 0 {
+		return
+	}
+	tracer.WithTrace(ctx).Infof("updating %d %s files status", len(failedFiles), fileStatusFailed)
+	err := t.svcCtx.StatusManager.UpdateFileStatus(ctx, t.params.RequestId,
+		func(status *types.FileStatusResponseData) {
+			for i, item := range status.FileList {
+				if reason, ok := failedFiles[item.Path]; ok {
+					status.FileList[i].Status = fileStatusFailed
+					status.FileList[i].Reason = reason.Error()
+				}
+			}
+		})
+	if err != nil {
+		tracer.WithTrace(ctx).Errorf("failed to update %s files status: %v", fileStatusFailed, err)
+	}
+}
+
+// redactChunks 对分块内容、文档注释和上下文头脱敏，返回按文件行号去重后的脱敏记录
+func (t *embeddingProcessor) redactChunks(chunks []*types.CodeChunk) []types.RedactionFinding {
+	if !t.svcCtx.Redactor.Enabled() {
+		return nil
+	}
+	var (
+		findings []types.RedactionFinding
+		seen     = make(map[types.RedactionFinding]struct{})
+	)
+	for _, chunk := range chunks {
+		chunkFindings := t.svcCtx.Redactor.RedactChunk(chunk)
+		if len(chunkFindings) == 0 {
+			continue
+		}
+		startLine := 0
+		if len(chunk.Range) > 0 {
+			startLine = chunk.Range[0]
+		}
+		for _, f := range chunkFindings {
+			// 滑动窗口切分的分块可能重叠，同一处敏感信息只记录一次
+			finding := types.RedactionFinding{Rule: f.Rule, Line: startLine + f.Line}
+			if _, ok := seen[finding]; ok {
+				continue
+			}
+			seen[finding] = struct{}{}
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// markFilesRedactions 将各文件的脱敏记录写入文件状态
+func (t *embeddingProcessor) markFilesRedactions(ctx context.Context, redactions map[string][]types.RedactionFinding) {
+	if len(redactions) == 0 {
+		return
+	}
+	for filePath, findings := range redactions {
+		rules := make([]string, 0, len(findings))
+		for _, f := range findings {
+			rules = append(rules, fmt.Sprintf("%s@%d", f.Rule, f.Line))
+		}
+		tracer.WithTrace(ctx).Infof("redacted %d secrets in file %s: %v", len(findings), filePath, rules)
+	}
+	err := t.svcCtx.StatusManager.UpdateFileStatus(ctx, t.params.RequestId,
+		func(status *types.FileStatusResponseData) {
+			for i, item := range status.FileList {
+				if findings, ok := redactions[item.Path]; ok {
+					status.FileList[i].Redactions = findings
+				}
+			}
+		})
+	if err != nil {
+		tracer.WithTrace(ctx).Errorf("failed to update redacted files status: %v", err)
+	}
+}
+
+// markFilesCoverage 将各文件的分块覆盖率写入文件状态
+func (t *embeddingProcessor) markFilesCoverage(ctx context.Context, coverages map[string]float64) {
+	if len(coverages) == 0 {
+		return
+	}
+	var total float64
+	for filePath, coverage := range coverages {
+		total += coverage
+		if coverage < 1 {
+			tracer.WithTrace(ctx).Debugf("file %s chunk coverage %.2f", filePath, coverage)
+		}
+	}
+	tracer.WithTrace(ctx).Infof("chunk coverage of %d files, average: %.4f", len(coverages), total/float64(len(coverages)))
+	err := t.svcCtx.StatusManager.UpdateFileStatus(ctx, t.params.RequestId,
+		func(status *types.FileStatusResponseData) {
+			for i, item := range status.FileList {
+				if coverage, ok := coverages[item.Path]; ok {
+					status.FileList[i].Coverage = &coverage
+				}
+			}
+		})
+	if err != nil {
+		tracer.WithTrace(ctx).Errorf("failed to update files chunk coverage: %v", err)
+	}
+}
+
 func (t *embeddingProcessor) splitFile(file *types.SourceFile) ([]*types.CodeChunk, error) {
 	// 切分文件
 	return t.svcCtx.CodeSplitter.Split(&types.SourceFile{
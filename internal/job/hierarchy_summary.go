@@ -0,0 +1,185 @@
+package job
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/dao/model"
+	"github.com/zgsm-ai/codebase-indexer/internal/embedding"
+	"github.com/zgsm-ai/codebase-indexer/internal/store/vector"
+	"github.com/zgsm-ai/codebase-indexer/internal/summary"
+	"github.com/zgsm-ai/codebase-indexer/internal/svc"
+	"github.com/zgsm-ai/codebase-indexer/internal/tracer"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+	"github.com/zgsm-ai/codebase-indexer/internal/usage"
+)
+
+// HierarchySummaryTask 在后台为代码库自底向上（分块 -> 文件 -> 目录 -> 代码库）生成层级摘要，
+// 摘要以带级别的文档类型记录写入向量库，可被文档检索命中，并展示在目录树节点上
+type HierarchySummaryTask struct {
+	SvcCtx   *svc.ServiceContext
+	Codebase *model.Codebase
+	// 未存储源码时用于从客户端获取分块内容
+	Authorization string
+}
+
+// Run 生成并替换代码库的层级摘要，进度记录在 index_history 中
+func (t *HierarchySummaryTask) Run(ctx context.Context) error {
+	ctx = usage.WithScope(ctx, t.SvcCtx.Usage, usage.Scope{
+		RequestID:    tracer.TaskTraceId(int(t.Codebase.ID)),
+		Source:       usage.SourceIndex,
+		CodebaseID:   t.Codebase.ID,
+		CodebasePath: t.Codebase.Path,
+		ClientID:     t.Codebase.ClientID,
+		UserID:       t.Codebase.UserID,
+	})
+
+	history, err := startTaskHistory(ctx, t.SvcCtx, t.Codebase, types.TaskTypeSummary)
+	if err != nil {
+		return err
+	}
+	tracer.WithTrace(ctx).Infof("start to build hierarchy summaries for codebase %d", t.Codebase.ID)
+
+	generated, failed, err := t.build(ctx)
+	// 任务超时或取消后仍需记录结果
+	historyCtx := context.WithoutCancel(ctx)
+	history.updateProgress(historyCtx, 1, taskCounts{total: generated + failed, success: generated, failed: failed})
+	history.finish(historyCtx, err)
+	if err != nil {
+		return fmt.Errorf("build hierarchy summaries of codebase %d failed: %w", t.Codebase.ID, err)
+	}
+	tracer.WithTrace(ctx).Infof("codebase %d hierarchy summaries built, %d generated, %d failed", t.Codebase.ID, generated, failed)
+	return nil
+}
+
+// build 读取代码库的分块生成层级摘要并写入向量库，部分节点失败时仍写入其余摘要
+func (t *HierarchySummaryTask) build(ctx context.Context) (generated, failed int, err error) {
+	records, err := t.SvcCtx.VectorStore.GetCodebaseRecords(ctx, t.Codebase.ClientID, t.Codebase.Path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get codebase records: %w", err)
+	}
+	if err = t.fillContents(ctx, records); err != nil {
+		tracer.WithTrace(ctx).Errorf("failed to fetch chunk contents of codebase %d: %v", t.Codebase.ID, err)
+	}
+
+	conf := t.SvcCtx.Config.IndexTask.EmbeddingTask.Summary.Hierarchy
+	summaries, buildErr := t.SvcCtx.Summarizer.BuildHierarchy(ctx, fileInputs(records), summary.HierarchyOptions{
+		FilePrompt:      conf.FilePrompt,
+		DirectoryPrompt: conf.DirectoryPrompt,
+		MaxInputTokens:  conf.MaxInputTokens,
+	})
+	if buildErr != nil {
+		tracer.WithTrace(ctx).Errorf("failed to summarize some paths of codebase %d: %v", t.Codebase.ID, buildErr)
+		if joined, ok := buildErr.(interface{ Unwrap() []error }); ok {
+			failed = len(joined.Unwrap())
+		} else {
+			failed = 1
+		}
+	}
+	if len(summaries) == 0 {
+		return 0, failed, cmp.Or(buildErr, errors.New("no content to summarize"))
+	}
+
+	chunks := make([]*types.CodeChunk, 0, len(summaries))
+	for _, s := range summaries {
+		chunks = append(chunks, &types.CodeChunk{
+			Language:        embedding.LanguageTypeDoc,
+			CodebaseId:      t.Codebase.ID,
+			CodebasePath:    t.Codebase.Path,
+			CodebaseName:    t.Codebase.Name,
+			Content:         []byte(s.Summary),
+			FilePath:        s.Path,
+			Range:           []int{0, 0, 0, 0},
+			EmbeddingHeader: summaryHeader(s, t.Codebase.Name),
+			SummaryLevel:    s.Level,
+		})
+	}
+	err = t.SvcCtx.VectorStore.ReplaceHierarchySummaries(ctx, chunks, vector.Options{
+		ClientId:     t.Codebase.ClientID,
+		CodebaseId:   t.Codebase.ID,
+		CodebasePath: t.Codebase.Path,
+		CodebaseName: t.Codebase.Name,
+		VectorSlot:   t.Codebase.VectorSlot,
+	})
+	if err != nil {
+		return 0, failed + len(summaries), fmt.Errorf("failed to save hierarchy summaries: %w", err)
+	}
+	return len(summaries), failed, nil
+}
+
+// fillContents 为既没有分块摘要也没有存储源码的分块从客户端获取内容，内容发送到摘要模型前脱敏
+func (t *HierarchySummaryTask) fillContents(ctx context.Context, records []*types.CodebaseRecord) error {
+	missing := make(map[*types.CodeChunk]*types.CodebaseRecord)
+	chunks := make([]*types.CodeChunk, 0)
+	for _, record := range records {
+		if record.SummaryLevel != types.EmptyString || record.Summary != types.EmptyString || record.Content != types.EmptyString {
+			continue
+		}
+		chunk := &types.CodeChunk{FilePath: record.FilePath, Range: record.Range}
+		missing[chunk] = record
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+	err := vector.FetchChunkContents(ctx, t.SvcCtx.Config.VectorStore, vector.Options{
+		ClientId:      t.Codebase.ClientID,
+		CodebasePath:  t.Codebase.Path,
+		Authorization: t.Authorization,
+	}, chunks)
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		t.SvcCtx.Redactor.RedactChunk(chunk)
+		missing[chunk].Content = string(chunk.Content)
+	}
+	return nil
+}
+
+// fileInputs 按文件汇总分块：优先使用分块摘要，没有摘要的分块使用分块内容，按行号排序
+func fileInputs(records []*types.CodebaseRecord) []summary.FileInput {
+	byPath := make(map[string][]*types.CodebaseRecord)
+	for _, record := range records {
+		if record.SummaryLevel != types.EmptyString {
+			continue
+		}
+		byPath[record.FilePath] = append(byPath[record.FilePath], record)
+	}
+	inputs := make([]summary.FileInput, 0, len(byPath))
+	for path, fileRecords := range byPath {
+		slices.SortFunc(fileRecords, func(a, b *types.CodebaseRecord) int {
+			return cmp.Compare(startLine(a.Range), startLine(b.Range))
+		})
+		input := summary.FileInput{Path: path}
+		for _, record := range fileRecords {
+			if text := cmp.Or(record.Summary, record.Content); text != types.EmptyString {
+				input.Texts = append(input.Texts, text)
+			}
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs
+}
+
+// summaryHeader 摘要记录的嵌入上下文头，使路径参与生成向量
+func summaryHeader(s summary.PathSummary, codebaseName string) string {
+	switch s.Level {
+	case types.SummaryLevelFile:
+		return "File: " + s.Path
+	case types.SummaryLevelDirectory:
+		return "Directory: " + s.Path
+	default:
+		return "Repository: " + codebaseName
+	}
+}
+
+func startLine(r []int) int {
+	if len(r) > 0 {
+		return r[0]
+	}
+	return 0
+}
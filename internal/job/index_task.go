@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/zgsm-ai/codebase-indexer/internal/embedding"
+	"github.com/zgsm-ai/codebase-indexer/internal/ignore"
 	"github.com/zgsm-ai/codebase-indexer/internal/svc"
 	"github.com/zgsm-ai/codebase-indexer/internal/tracer"
 	"github.com/zgsm-ai/codebase-indexer/internal/types"
@@ -21,10 +23,18 @@ type IndexTaskParams struct {
 	CodebasePath string // 代码库路径
 	CodebaseName string // 代码库名字
 	ClientId     string // 客户端ID
+	UserId       string // 代码库所属用户，用于用量统计
 	RequestId    string // 请求ID，用于状态管理
-	Files        map[string][]byte
-	Metadata     *types.SyncMetadata // 同步元数据
-	TotalFiles   int                 // 文件总数
+	VectorSlot   string // 代码库当前用于查询的向量槽位，迁移期间新分块同时写入该槽位
+	// 代码库是否开启大模型摘要，同时需要全局开启 IndexTask.EmbeddingTask.Summary
+	EnableSummary bool
+	Files         map[string][]byte
+	Metadata      *types.SyncMetadata // 同步元数据
+	TotalFiles    int                 // 文件总数
+	// 忽略规则匹配器（.gitignore/.ignore/.embedderignore），为空时不忽略
+	IgnoreMatcher *ignore.Matcher
+	// 生成/压缩/第三方代码分类器，为空时不检测
+	Classifier *embedding.CodeClassifier
 }
 
 func (i *IndexTask) Run(ctx context.Context) (embedTaskOk bool) {
@@ -38,12 +48,30 @@ func (i *IndexTask) Run(ctx context.Context) (embedTaskOk bool) {
 	}
 
 	embedTaskOk = embedErr == nil
+	if embedTaskOk && i.Params.VectorSlot == types.EmptyString {
+		i.initVectorSlot(ctx)
+	}
 
 	tracer.WithTrace(ctx).Infof("index task end, cost %d ms. embedding ok? %t",
 		time.Since(start).Milliseconds(), embedTaskOk)
 	return
 }
 
+// initVectorSlot 尚未记录槽位的代码库在首次索引成功后使用当前模型的槽位查询
+func (i *IndexTask) initVectorSlot(ctx context.Context) {
+	slot := i.SvcCtx.VectorStore.TargetVectorSlot()
+	if slot == types.EmptyString {
+		return
+	}
+	c := i.SvcCtx.Querier.Codebase
+	_, err := c.WithContext(ctx).
+		Where(c.ID.Eq(i.Params.CodebaseID), c.VectorSlot.Eq(types.EmptyString)).
+		UpdateSimple(c.VectorSlot.Value(slot))
+	if err != nil {
+		tracer.WithTrace(ctx).Errorf("failed to init vector slot of codebase %d: %v", i.Params.CodebaseID, err)
+	}
+}
+
 func (i *IndexTask) buildEmbedding(ctx context.Context) error {
 	start := time.Now()
 
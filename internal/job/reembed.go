@@ -0,0 +1,113 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/dao/model"
+	"github.com/zgsm-ai/codebase-indexer/internal/store/vector"
+	"github.com/zgsm-ai/codebase-indexer/internal/svc"
+	"github.com/zgsm-ai/codebase-indexer/internal/tracer"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+	"github.com/zgsm-ai/codebase-indexer/internal/usage"
+)
+
+// ReembedTask 更换嵌入模型后，在后台为代码库的所有分块生成新槽位的向量，
+// 期间查询继续使用旧槽位，覆盖率达到 100% 后切换代码库的查询槽位
+type ReembedTask struct {
+	SvcCtx   *svc.ServiceContext
+	Codebase *model.Codebase
+	// 未存储源码时用于从客户端获取分块内容
+	Authorization string
+}
+
+// Run 执行迁移，进度记录在 index_history 中
+func (t *ReembedTask) Run(ctx context.Context) error {
+	targetSlot := t.SvcCtx.VectorStore.TargetVectorSlot()
+	fromSlot := t.Codebase.VectorSlot
+	if fromSlot == targetSlot && t.SvcCtx.VectorStore.LegacyVectorClass() == types.EmptyString {
+		return nil
+	}
+
+	ctx = usage.WithScope(ctx, t.SvcCtx.Usage, usage.Scope{
+		RequestID:    tracer.TaskTraceId(int(t.Codebase.ID)),
+		Source:       usage.SourceIndex,
+		CodebaseID:   t.Codebase.ID,
+		CodebasePath: t.Codebase.Path,
+		ClientID:     t.Codebase.ClientID,
+		UserID:       t.Codebase.UserID,
+	})
+
+	options := vector.Options{
+		ClientId:      t.Codebase.ClientID,
+		CodebaseId:    t.Codebase.ID,
+		CodebasePath:  t.Codebase.Path,
+		CodebaseName:  t.Codebase.Name,
+		Authorization: t.Authorization,
+		Redactor:      t.SvcCtx.Redactor,
+	}
+	// 旧版本单向量类中的租户先复制到命名向量类的旧模型槽位，再按槽位迁移
+	legacySlot, err := t.SvcCtx.VectorStore.MigrateLegacyTenant(ctx, options, func(slot string) error {
+		if slot == fromSlot {
+			return nil
+		}
+		return t.switchSlot(ctx, fromSlot, slot)
+	})
+	if err != nil {
+		return fmt.Errorf("migrate codebase %d from legacy vector class failed: %w", t.Codebase.ID, err)
+	}
+	if legacySlot != types.EmptyString {
+		fromSlot = legacySlot
+	}
+	if fromSlot == targetSlot {
+		return nil
+	}
+
+	history, err := startTaskHistory(ctx, t.SvcCtx, t.Codebase, types.TaskTypeReembed)
+	if err != nil {
+		return err
+	}
+	tracer.WithTrace(ctx).Infof("start to re-embed codebase %d from vector slot %q to %q", t.Codebase.ID, fromSlot, targetSlot)
+
+	coverage, err := t.SvcCtx.VectorStore.FillVectorSlot(ctx, options, func(coverage vector.VectorSlotCoverage) {
+		history.updateProgress(ctx, coverage.Progress(), taskCounts{total: coverage.Total, success: coverage.Covered, failed: coverage.Failed})
+	})
+	if err == nil && !coverage.Complete() {
+		err = fmt.Errorf("vector slot %s covers %d of %d chunks, %d failed", targetSlot, coverage.Covered, coverage.Total, coverage.Failed)
+	}
+	if err == nil {
+		err = t.switchSlot(ctx, fromSlot, targetSlot)
+	}
+	t.finishHistory(ctx, history, coverage, err)
+	if err != nil {
+		return fmt.Errorf("re-embed codebase %d failed: %w", t.Codebase.ID, err)
+	}
+	tracer.WithTrace(ctx).Infof("codebase %d switched to vector slot %s, %d chunks", t.Codebase.ID, targetSlot, coverage.Total)
+	return nil
+}
+
+// switchSlot 仅当代码库仍使用迁移开始时的槽位时切换，保证并发迁移只有一个生效
+func (t *ReembedTask) switchSlot(ctx context.Context, fromSlot, targetSlot string) error {
+	c := t.SvcCtx.Querier.Codebase
+	res, err := c.WithContext(ctx).
+		Where(c.ID.Eq(t.Codebase.ID), c.VectorSlot.Eq(fromSlot)).
+		UpdateSimple(c.VectorSlot.Value(targetSlot))
+	if err != nil {
+		return fmt.Errorf("failed to switch vector slot: %w", err)
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("vector slot of codebase was changed by another task")
+	}
+	t.Codebase.VectorSlot = targetSlot
+	return nil
+}
+
+// finishHistory 记录最终覆盖率和任务结果，任务超时或取消后仍需记录
+func (t *ReembedTask) finishHistory(ctx context.Context, history *taskHistory, coverage *vector.VectorSlotCoverage, runErr error) {
+	ctx = context.WithoutCancel(ctx)
+	if coverage != nil {
+		history.updateProgress(ctx, coverage.Progress(), taskCounts{total: coverage.Total, success: coverage.Covered, failed: coverage.Failed})
+	}
+	history.finish(ctx, runErr)
+}
@@ -0,0 +1,90 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/dao/model"
+	"github.com/zgsm-ai/codebase-indexer/internal/errs"
+	"github.com/zgsm-ai/codebase-indexer/internal/svc"
+	"github.com/zgsm-ai/codebase-indexer/internal/tracer"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+// taskHistory 代码库任务（索引、重新嵌入、层级摘要）在 index_history 中的执行记录，为 nil 时不记录
+type taskHistory struct {
+	svcCtx *svc.ServiceContext
+	record *model.IndexHistory
+}
+
+// startTaskHistory 插入一条运行中的任务记录
+func startTaskHistory(ctx context.Context, svcCtx *svc.ServiceContext, codebase *model.Codebase, taskType string) (*taskHistory, error) {
+	now := time.Now()
+	progress := float64(0)
+	record := &model.IndexHistory{
+		CodebaseID:   codebase.ID,
+		CodebasePath: codebase.Path,
+		CodebaseName: codebase.Name,
+		TaskType:     taskType,
+		Status:       types.TaskStatusRunning,
+		Progress:     &progress,
+		StartTime:    &now,
+	}
+	if err := svcCtx.Querier.IndexHistory.WithContext(ctx).Create(record); err != nil {
+		return nil, fmt.Errorf("insert %s task history failed: %w", taskType, err)
+	}
+	return &taskHistory{svcCtx: svcCtx, record: record}, nil
+}
+
+// taskCounts 任务处理的对象数量，索引任务为文件数，重新嵌入任务为分块数，层级摘要任务为摘要节点数
+type taskCounts struct {
+	total, success, failed, ignored int
+}
+
+// updateProgress 更新任务进度和各类数量
+func (t *taskHistory) updateProgress(ctx context.Context, progress float64, counts taskCounts) {
+	if t == nil {
+		return
+	}
+	h := t.svcCtx.Querier.IndexHistory
+	total, success, failed, ignored := int32(counts.total), int32(counts.success), int32(counts.failed), int32(counts.ignored)
+	_, err := h.WithContext(ctx).Where(h.ID.Eq(t.record.ID)).Updates(&model.IndexHistory{
+		Progress:          &progress,
+		TotalFileCount:    &total,
+		TotalSuccessCount: &success,
+		TotalFailCount:    &failed,
+		TotalIgnoreCount:  &ignored,
+		UpdatedAt:         time.Now(),
+	})
+	if err != nil {
+		tracer.WithTrace(ctx).Errorf("update %s task history %d failed: %v", t.record.TaskType, t.record.ID, err)
+	}
+}
+
+// finish 按任务错误记录结束状态，超时记为 timeout
+func (t *taskHistory) finish(ctx context.Context, runErr error) {
+	if t == nil {
+		return
+	}
+	h := t.svcCtx.Querier.IndexHistory
+	status := types.TaskStatusSuccess
+	errMsg := types.EmptyString
+	if runErr != nil {
+		status = types.TaskStatusFailed
+		if errors.Is(runErr, context.DeadlineExceeded) || errors.Is(runErr, errs.RunTimeout) {
+			status = types.TaskStatusTimeout
+		}
+		errMsg = runErr.Error()
+	}
+	_, err := h.WithContext(ctx).Where(h.ID.Eq(t.record.ID)).UpdateSimple(
+		h.Status.Value(status),
+		h.ErrorMessage.Value(errMsg),
+		h.EndTime.Value(time.Now()),
+		h.UpdatedAt.Value(time.Now()),
+	)
+	if err != nil {
+		tracer.WithTrace(ctx).Errorf("update %s task history %d failed: %v", t.record.TaskType, t.record.ID, err)
+	}
+}
@@ -0,0 +1,115 @@
+package logic
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zgsm-ai/codebase-indexer/internal/errs"
+	"github.com/zgsm-ai/codebase-indexer/internal/store/vector"
+	"github.com/zgsm-ai/codebase-indexer/internal/svc"
+	"github.com/zgsm-ai/codebase-indexer/internal/tracer"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+	"github.com/zgsm-ai/codebase-indexer/internal/usage"
+	"github.com/zgsm-ai/codebase-indexer/pkg/utils"
+)
+
+type APIOperationLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewAPIOperationSearchLogic(ctx context.Context, svcCtx *svc.ServiceContext) *APIOperationLogic {
+	return &APIOperationLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// APIOperationSearch 在 API 描述文档的分块中检索：按方法、路径、操作 ID、标签、schema 过滤，再按查询语义排序
+func (l *APIOperationLogic) APIOperationSearch(req *types.APIOperationSearchRequest, authorization string, userId string) (*types.APIOperationSearchResponseData, error) {
+	topK := req.TopK
+	if topK < documentMinPositive {
+		topK = documentDefaultTopK
+	}
+	filter := &vector.APIOperationFilter{
+		Method:      strings.TrimSpace(req.Method),
+		Path:        strings.TrimSpace(req.Path),
+		OperationID: strings.TrimSpace(req.OperationId),
+		Tags:        req.Tags,
+		Schema:      strings.TrimSpace(req.Schema),
+	}
+	query := req.Query
+	if utils.IsBlank(query) {
+		// 只有过滤条件时用过滤条件作为查询文本，使结果仍按相关性排序
+		query = strings.Join(slices.DeleteFunc(append([]string{filter.Method, filter.Path, filter.OperationID, filter.Schema}, filter.Tags...),
+			func(s string) bool { return strings.TrimSpace(s) == "" }), " ")
+	}
+	if utils.IsBlank(query) {
+		return nil, errs.NewInvalidParamErr(documentParamQuery, req.Query)
+	}
+
+	ctx := context.WithValue(l.ctx, tracer.Key, req.ClientId)
+	codebase := findQueryCodebase(l.ctx, l.svcCtx, req.ClientId, req.CodebasePath)
+	ctx = usage.WithScope(ctx, l.svcCtx.Usage, queryUsageScope(codebase, req.ClientId, req.CodebasePath, userId))
+
+	documents, err := l.svcCtx.VectorStore.Query(ctx, query, topK,
+		vector.Options{
+			ClientId:      req.ClientId,
+			CodebasePath:  req.CodebasePath,
+			Authorization: authorization,
+			Language:      "doc",
+			VectorSlot:    queryVectorSlot(codebase),
+			APIFilter:     filter,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*types.SemanticFileItem, 0, len(documents))
+	for _, doc := range documents {
+		if doc.Score < req.ScoreThreshold {
+			continue
+		}
+		// 分块包含一个路径下的所有操作，只返回满足过滤条件的操作
+		doc.APIOperations = slices.DeleteFunc(doc.APIOperations, func(op types.APIOperation) bool {
+			return !matchAPIOperation(op, filter)
+		})
+		if len(doc.APIOperations) > 0 {
+			list = append(list, doc)
+		}
+	}
+	return &types.APIOperationSearchResponseData{List: list}, nil
+}
+
+// matchAPIOperation 判断操作是否满足过滤条件，与向量库中的过滤语义一致
+func matchAPIOperation(op types.APIOperation, f *vector.APIOperationFilter) bool {
+	if f.Method != "" && !strings.EqualFold(op.Method, f.Method) {
+		return false
+	}
+	if f.Path != "" && !matchWildcard(f.Path, op.Path) {
+		return false
+	}
+	if f.OperationID != "" && op.OperationID != f.OperationID {
+		return false
+	}
+	if len(f.Tags) > 0 && !slices.ContainsFunc(f.Tags, func(tag string) bool { return slices.Contains(op.Tags, tag) }) {
+		return false
+	}
+	if f.Schema != "" && !slices.Contains(op.Schemas, f.Schema) {
+		return false
+	}
+	return true
+}
+
+// matchWildcard 通配匹配，* 匹配任意字符序列，? 匹配单个字符
+func matchWildcard(pattern, s string) bool {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(expr)
+	matched, err := regexp.MatchString("^"+expr+"$", s)
+	return err == nil && matched
+}
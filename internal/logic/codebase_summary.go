@@ -0,0 +1,53 @@
+package logic
+
+import (
+	"context"
+	"errors"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zgsm-ai/codebase-indexer/internal/errs"
+	"github.com/zgsm-ai/codebase-indexer/internal/svc"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+	"gorm.io/gorm"
+)
+
+type CodebaseSummaryLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewCodebaseSummaryLogic(ctx context.Context, svcCtx *svc.ServiceContext) *CodebaseSummaryLogic {
+	return &CodebaseSummaryLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// SetSummary 修改代码库的摘要开关，已索引的分块在文件下次同步时补充或去掉摘要
+func (l *CodebaseSummaryLogic) SetSummary(req *types.CodebaseSummaryRequest) (*types.CodebaseSummaryResponseData, error) {
+	c := l.svcCtx.Querier.Codebase
+	codebase, err := c.FindByClientIdAndPath(l.ctx, req.ClientId, req.CodebasePath)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errs.NewRecordNotFoundErr(types.NameCodeBase, req.CodebasePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if codebase.EnableSummary != req.Enabled {
+		if _, err = c.WithContext(l.ctx).Where(c.ID.Eq(codebase.ID)).UpdateSimple(c.EnableSummary.Value(req.Enabled)); err != nil {
+			return nil, err
+		}
+		l.Infof("codebase %d summary enabled: %t", codebase.ID, req.Enabled)
+	}
+	serverEnabled := l.svcCtx.Summarizer.Enabled()
+	if req.Enabled && !serverEnabled {
+		l.Infof("chunk summary is disabled on the server, codebase %d will not be summarized", codebase.ID)
+	}
+	return &types.CodebaseSummaryResponseData{
+		CodebaseId:    codebase.ID,
+		Enabled:       req.Enabled,
+		ServerEnabled: serverEnabled,
+	}, nil
+}
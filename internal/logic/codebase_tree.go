@@ -88,7 +88,7 @@ func (l *CodebaseTreeLogic) printTreeStructure(tree *types.TreeNode) {
 
 func (l *CodebaseTreeLogic) buildDirectoryTree(clientId string, req *types.CodebaseTreeRequest) (*types.TreeNode, error) {
 	// 从向量存储中获取文件路径
-	records, err := l.getRecordsFromVectorStore(clientId, req.CodebasePath)
+	records, summaries, err := l.getRecordsFromVectorStore(clientId, req.CodebasePath)
 	if err != nil {
 		return nil, err
 	}
@@ -106,9 +106,28 @@ func (l *CodebaseTreeLogic) buildDirectoryTree(clientId string, req *types.Codeb
 	if err != nil {
 		return nil, err
 	}
+	attachSummaries(result, summaries)
 	return result, nil
 }
 
+// attachSummaries 将层级摘要挂到对应的树节点上，根节点使用代码库摘要
+func attachSummaries(root *types.TreeNode, summaries map[string]string) {
+	if root == nil || len(summaries) == 0 {
+		return
+	}
+	var walk func(node *types.TreeNode)
+	walk = func(node *types.TreeNode) {
+		node.Summary = summaries[normalizePath(node.Path)]
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+	if repoSummary, ok := summaries[normalizePath(".")]; ok {
+		root.Summary = repoSummary
+	}
+}
+
 // checkCodebaseInDatabase 检查数据库中是否存在该 codebaseId
 func (l *CodebaseTreeLogic) checkCodebaseInDatabase(codebaseId int32) {
 	log.Printf("[DEBUG] 检查数据库中是否存在 codebaseId: %d", codebaseId)
@@ -121,21 +140,32 @@ func (l *CodebaseTreeLogic) checkCodebaseInDatabase(codebaseId int32) {
 	}
 }
 
-// getRecordsFromVectorStore 从向量存储中获取文件记录
-func (l *CodebaseTreeLogic) getRecordsFromVectorStore(clientId string, codebasePath string) ([]*types.CodebaseRecord, error) {
+// getRecordsFromVectorStore 从向量存储中获取文件记录，层级摘要记录单独按路径返回
+func (l *CodebaseTreeLogic) getRecordsFromVectorStore(clientId string, codebasePath string) ([]*types.CodebaseRecord, map[string]string, error) {
 	if l.svcCtx.VectorStore == nil {
-		return nil, fmt.Errorf("VectorStore 未初始化")
+		return nil, nil, fmt.Errorf("VectorStore 未初始化")
 	}
 
 	records, err := l.svcCtx.VectorStore.GetCodebaseRecords(l.ctx, clientId, codebasePath)
 	if err != nil {
-		return nil, fmt.Errorf("查询文件路径失败: %w", err)
+		return nil, nil, fmt.Errorf("查询文件路径失败: %w", err)
+	}
+
+	// 层级摘要记录与文件分块共用路径，不参与构建文件列表
+	summaries := make(map[string]string)
+	fileRecords := make([]*types.CodebaseRecord, 0, len(records))
+	for _, record := range records {
+		if record.SummaryLevel != types.EmptyString {
+			summaries[normalizePath(record.FilePath)] = record.Content
+			continue
+		}
+		fileRecords = append(fileRecords, record)
 	}
 
 	// 合并相同文件路径的记录
-	mergedRecords, _ := l.mergeRecordsByFilePath(records)
+	mergedRecords, _ := l.mergeRecordsByFilePath(fileRecords)
 
-	return mergedRecords, nil
+	return mergedRecords, summaries, nil
 }
 
 // mergeRecordsByFilePath 合并相同文件路径的记录
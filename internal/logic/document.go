@@ -2,6 +2,7 @@ package logic
 
 import (
 	"context"
+	"time"
 
 	"github.com/zgsm-ai/codebase-indexer/internal/errs"
 	"github.com/zgsm-ai/codebase-indexer/internal/store/vector"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/zgsm-ai/codebase-indexer/internal/svc"
 	"github.com/zgsm-ai/codebase-indexer/internal/types"
+	"github.com/zgsm-ai/codebase-indexer/internal/usage"
 
 	"github.com/zeromicro/go-zero/core/logx"
 )
@@ -34,7 +36,7 @@ func NewDocumentSearchLogic(ctx context.Context, svcCtx *svc.ServiceContext) *Do
 	}
 }
 
-func (l *DocumentLogic) DocumentSearch(req *types.DocumentSearchRequest, authorization string) (resp *types.DocumentSearchResponseData, err error) {
+func (l *DocumentLogic) DocumentSearch(req *types.DocumentSearchRequest, authorization string, userId string) (resp *types.DocumentSearchResponseData, err error) {
 	topK := req.TopK
 	if topK < documentMinPositive {
 		topK = documentDefaultTopK
@@ -43,15 +45,24 @@ func (l *DocumentLogic) DocumentSearch(req *types.DocumentSearchRequest, authori
 		return nil, errs.NewInvalidParamErr(documentParamQuery, req.Query)
 	}
 
+	var explain *types.SearchExplain
+	if req.Explain {
+		explain = &types.SearchExplain{}
+	}
+
 	// 预处理查询字符串
-	req.Query, err = l.preprocessQuery(req.Query)
+	start := time.Now()
+	processed, debug, err := preprocessQuery(l.svcCtx, req.Query, req.Mode)
 	if err != nil {
 		return nil, err
 	}
+	explain.AddStage(types.ExplainStagePreprocess, start, 0)
 
 	ctx := context.WithValue(l.ctx, tracer.Key, req.ClientId)
+	codebase := findQueryCodebase(l.ctx, l.svcCtx, req.ClientId, req.CodebasePath)
+	ctx = usage.WithScope(ctx, l.svcCtx.Usage, queryUsageScope(codebase, req.ClientId, req.CodebasePath, userId))
 
-	documents, err := l.svcCtx.VectorStore.Query(ctx, req.Query, topK,
+	documents, err := l.svcCtx.VectorStore.Query(ctx, processed.Text, topK,
 		vector.Options{
 			CodebaseId:    0,
 			ClientId:      req.ClientId,
@@ -59,28 +70,30 @@ func (l *DocumentLogic) DocumentSearch(req *types.DocumentSearchRequest, authori
 			CodebaseName:  "",
 			Authorization: authorization,
 			Language:      "doc",
+			VectorSlot:    queryVectorSlot(codebase),
+			Result: vector.ResultOptions{
+				MergeAdjacent: req.MergeAdjacent,
+				Diversity:     req.Diversity,
+				MaxPerFile:    req.MaxPerFile,
+			},
+			SearchMode:        processed.Mode,
+			HybridAlpha:       l.svcCtx.Config.Query.HybridAlpha,
+			KeywordScoreScale: l.svcCtx.Config.Query.KeywordScoreScale,
+			Explain:           explain,
 		})
 	if err != nil {
 		return nil, err
 	}
 
 	// 分数过滤
-	scoreThreshold := req.ScoreThreshold
-	filteredDocuments := make([]*types.SemanticFileItem, 0, len(documents))
-	for _, doc := range documents {
-		if doc.Score >= scoreThreshold {
-			filteredDocuments = append(filteredDocuments, doc)
-		}
-	}
+	filteredDocuments := filterByScore(documents, req.ScoreThreshold, explain)
 
-	return &types.DocumentSearchResponseData{
+	resp = &types.DocumentSearchResponseData{
 		List: filteredDocuments,
-	}, nil
-}
-
-// preprocessQuery 执行自定义查询预处理逻辑
-func (l *DocumentLogic) preprocessQuery(query string) (string, error) {
-	// TODO: 实现自定义预处理逻辑
-	// 例如: 去除特殊字符、敏感词过滤等
-	return query, nil
+	}
+	if req.Debug {
+		resp.Debug = debug
+	}
+	resp.Explain = explain
+	return resp, nil
 }
@@ -14,6 +14,8 @@ import (
 	"strings"
 
 	"github.com/zgsm-ai/codebase-indexer/internal/dao/model"
+	"github.com/zgsm-ai/codebase-indexer/internal/embedding"
+	"github.com/zgsm-ai/codebase-indexer/internal/ignore"
 	"github.com/zgsm-ai/codebase-indexer/internal/job"
 	"github.com/zgsm-ai/codebase-indexer/internal/store/vector"
 	"github.com/zgsm-ai/codebase-indexer/internal/tracer"
@@ -42,6 +44,8 @@ type TaskLogic struct {
 	svcCtx        *svc.ServiceContext
 	syncMetadata  *types.SyncMetadata
 	uploadedFiles map[string][]byte // 存储上传的文件内容
+	ignoreFiles   map[string][]byte // 上传包中的 .gitignore 等忽略规则文件
+	attrFiles     map[string][]byte // 上传包中的 .gitattributes 文件
 }
 
 func NewTaskLogic(ctx context.Context, svcCtx *svc.ServiceContext) *TaskLogic {
@@ -59,6 +63,8 @@ func NewTaskLogic(ctx context.Context, svcCtx *svc.ServiceContext) *TaskLogic {
 			Timestamp:     0,
 		},
 		uploadedFiles: make(map[string][]byte),
+		ignoreFiles:   make(map[string][]byte),
+		attrFiles:     make(map[string][]byte),
 	}
 }
 
@@ -386,6 +392,19 @@ func (l *TaskLogic) extractFilesFromZip(zipReader *zip.ReadCloser, files map[str
 			continue
 		}
 
+		// 忽略规则文件不论是否在FileList中都需要读取
+		if l.svcCtx.Config.IndexTask.FileValidation.RespectIgnoreFiles &&
+			ignore.IsIgnoreFile(zipFile.Name, l.svcCtx.Config.IndexTask.FileValidation.IgnoreFiles) {
+			if err := l.processRegularFile(zipFile, l.ignoreFiles); err != nil {
+				return 0, err
+			}
+		}
+		if l.svcCtx.Config.IndexTask.EmbeddingTask.GeneratedCode.Enabled && embedding.IsAttributesFile(zipFile.Name) {
+			if err := l.processRegularFile(zipFile, l.attrFiles); err != nil {
+				return 0, err
+			}
+		}
+
 		// 检查文件是否存在于ExtraMetadata中，如果不存在则忽略
 		if l.syncMetadata != nil {
 			// 将zipFile.Name中的Windows路径格式（反斜杠\）转换为Linux路径格式（正斜杠/）
@@ -602,12 +621,65 @@ func (l *TaskLogic) processRegularFile(zipFile *zip.File, files map[string][]byt
 	return nil
 }
 
+// buildIgnoreMatcher 根据代码库的忽略规则文件和配置的SkipPatterns构建匹配器。
+// 增量同步只上传变更的文件，规则文件按代码库保存，与本次上传的规则文件合并后使用
+func (l *TaskLogic) buildIgnoreMatcher(ctx context.Context, codebaseId int32) *ignore.Matcher {
+	conf := l.svcCtx.Config.IndexTask.FileValidation
+	if !conf.RespectIgnoreFiles {
+		return ignore.New(conf.SkipPatterns)
+	}
+	uploaded := ignore.MergeFiles(nil, l.ignoreFiles, nil)
+	deleted := l.deletedIgnoreFiles()
+	files := uploaded
+	if stored, err := l.svcCtx.IgnoreRules.Load(ctx, codebaseId); err != nil {
+		tracer.WithTrace(ctx).Errorf("failed to load ignore rule files of codebase %d, use uploaded files only: %v", codebaseId, err)
+	} else {
+		files = ignore.MergeFiles(stored, uploaded, deleted)
+	}
+	if err := l.svcCtx.IgnoreRules.Save(ctx, codebaseId, uploaded, deleted); err != nil {
+		tracer.WithTrace(ctx).Errorf("failed to save ignore rule files of codebase %d: %v", codebaseId, err)
+	}
+	l.Logger.Infof("上传 %d 个忽略规则文件，共使用 %d 个忽略规则文件", len(uploaded), len(files))
+	return ignore.Load(files, conf.IgnoreFiles, conf.SkipPatterns)
+}
+
+// deletedIgnoreFiles 返回本次同步中被删除或重命名的忽略规则文件
+func (l *TaskLogic) deletedIgnoreFiles() []string {
+	if l.syncMetadata == nil {
+		return nil
+	}
+	names := l.svcCtx.Config.IndexTask.FileValidation.IgnoreFiles
+	var deleted []string
+	for filePath, operation := range l.syncMetadata.FileList {
+		if strings.EqualFold(operation, "delete") && ignore.IsIgnoreFile(filePath, names) {
+			deleted = append(deleted, strings.ReplaceAll(filePath, "\\", "/"))
+		}
+	}
+	for _, item := range l.syncMetadata.FileListItems {
+		status := strings.ToLower(item.Status)
+		if (status == "delete" || status == "rename") && ignore.IsIgnoreFile(item.Path, names) {
+			deleted = append(deleted, strings.ReplaceAll(item.Path, "\\", "/"))
+		}
+	}
+	return deleted
+}
+
+// buildCodeClassifier 根据上传包中的 .gitattributes 构建生成/第三方代码分类器
+func (l *TaskLogic) buildCodeClassifier() *embedding.CodeClassifier {
+	conf := l.svcCtx.Config.IndexTask.EmbeddingTask.GeneratedCode
+	return embedding.NewCodeClassifier(embedding.ClassifierOptions{
+		Enabled:          conf.Enabled,
+		MaxAvgLineLength: conf.MaxAvgLineLength,
+	}, l.attrFiles)
+}
+
 // updateCodebaseInfo 更新代码库信息
 func (l *TaskLogic) updateCodebaseInfo(codebase *model.Codebase, fileCount int, fileTotals int64) error {
 	// 更新codebase的file_count和total_size字段
 	codebase.FileCount = int32(fileCount)
 	codebase.TotalSize = fileTotals
-	err := l.svcCtx.Querier.Codebase.WithContext(l.ctx).Save(codebase)
+	// 向量槽位只由迁移任务切换，避免覆盖并发的切换结果
+	err := l.svcCtx.Querier.Codebase.WithContext(l.ctx).Omit(l.svcCtx.Querier.Codebase.VectorSlot).Save(codebase)
 	if err != nil {
 		return fmt.Errorf("failed to update codebase file count: %w", err)
 	}
@@ -624,14 +696,19 @@ func (l *TaskLogic) submitIndexTask(ctx context.Context, codebase *model.Codebas
 	task := &job.IndexTask{
 		SvcCtx: l.svcCtx,
 		Params: &job.IndexTaskParams{
-			ClientId:     clientId,
-			CodebaseID:   codebase.ID,
-			CodebasePath: codebase.Path,
-			CodebaseName: codebase.Name,
-			RequestId:    requestId,
-			Files:        files,
-			Metadata:     metadata,
-			TotalFiles:   len(files),
+			ClientId:      clientId,
+			UserId:        codebase.UserID,
+			CodebaseID:    codebase.ID,
+			CodebasePath:  codebase.Path,
+			CodebaseName:  codebase.Name,
+			RequestId:     requestId,
+			VectorSlot:    codebase.VectorSlot,
+			EnableSummary: codebase.EnableSummary,
+			Files:         files,
+			Metadata:      metadata,
+			TotalFiles:    len(files),
+			IgnoreMatcher: l.buildIgnoreMatcher(ctx, codebase.ID),
+			Classifier:    l.buildCodeClassifier(),
 		},
 	}
 
@@ -704,6 +781,7 @@ func (l *TaskLogic) saveCodebase(clientId, clientPath, userUId, codebaseName str
 		ClientPath: clientPath,
 		Status:     string(model.CodebaseStatusActive),
 		Path:       clientPath,
+		VectorSlot: l.svcCtx.VectorStore.TargetVectorSlot(),
 	}
 	err := l.svcCtx.Querier.Codebase.WithContext(l.ctx).Save(codebaseModel)
 	if err != nil && !errors.Is(err, gorm.ErrDuplicatedKey) {
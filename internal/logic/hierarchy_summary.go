@@ -0,0 +1,70 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zgsm-ai/codebase-indexer/internal/errs"
+	"github.com/zgsm-ai/codebase-indexer/internal/job"
+	"github.com/zgsm-ai/codebase-indexer/internal/svc"
+	"github.com/zgsm-ai/codebase-indexer/internal/tracer"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+	"gorm.io/gorm"
+)
+
+type HierarchySummaryLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewHierarchySummaryLogic(ctx context.Context, svcCtx *svc.ServiceContext) *HierarchySummaryLogic {
+	return &HierarchySummaryLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// BuildSummaries 提交后台任务，为代码库生成文件、目录和代码库层级摘要，进度记录在 index_history 中
+func (l *HierarchySummaryLogic) BuildSummaries(req *types.HierarchySummaryRequest, authorization string) (*types.HierarchySummaryResponseData, error) {
+	conf := l.svcCtx.Config.IndexTask.EmbeddingTask.Summary.Hierarchy
+	if !conf.Enabled || l.svcCtx.Summarizer == nil {
+		return nil, errors.New("hierarchy summary is disabled on the server")
+	}
+
+	codebase, err := l.svcCtx.Querier.Codebase.FindByClientIdAndPath(l.ctx, req.ClientId, req.CodebasePath)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errs.NewRecordNotFoundErr(types.NameCodeBase, req.CodebasePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 文件摘要的输入来自分块摘要、存储的源码或从客户端获取的源码，三者都没有时无法生成
+	vectorConf := l.svcCtx.Config.VectorStore
+	if !codebase.EnableSummary && !vectorConf.StoreSourceCode && vectorConf.BaseURL == types.EmptyString {
+		return nil, errors.New("codebase has neither chunk summaries nor stored source code to summarize")
+	}
+
+	task := &job.HierarchySummaryTask{
+		SvcCtx:        l.svcCtx,
+		Codebase:      codebase,
+		Authorization: authorization,
+	}
+	err = l.svcCtx.TaskPool.Submit(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), conf.Timeout)
+		defer cancel()
+		ctx = context.WithValue(ctx, tracer.Key, tracer.TaskTraceId(int(codebase.ID)))
+		if err := task.Run(ctx); err != nil {
+			tracer.WithTrace(ctx).Errorf("hierarchy summary task failed: %v", err)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("hierarchy summary task submit failed, err:%w", err)
+	}
+	l.Infof("submitted hierarchy summary task for codebase %d", codebase.ID)
+	return &types.HierarchySummaryResponseData{CodebaseId: codebase.ID}, nil
+}
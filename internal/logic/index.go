@@ -38,6 +38,12 @@ func (l *IndexLogic) DeleteIndex(req *types.DeleteIndexRequest) (resp *types.Del
 		if err = l.svcCtx.VectorStore.DeleteByCodebase(ctx, clientId, req.CodebasePath); err != nil {
 			return nil, fmt.Errorf("failed to delete embedding codebase, err:%w", err)
 		}
+		// 重新索引时会完整上传规则文件，删除已保存的规则文件
+		if codebase, err := l.svcCtx.Querier.Codebase.FindByClientIdAndPath(ctx, clientId, req.CodebasePath); err == nil {
+			if err := l.svcCtx.IgnoreRules.Delete(ctx, codebase.ID); err != nil {
+				l.Errorf("failed to delete ignore rule files of codebase %d: %v", codebase.ID, err)
+			}
+		}
 		return &types.DeleteIndexResponseData{}, nil
 	}
 
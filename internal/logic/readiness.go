@@ -0,0 +1,50 @@
+package logic
+
+import (
+	"context"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zgsm-ai/codebase-indexer/internal/store/vector"
+	"github.com/zgsm-ai/codebase-indexer/internal/svc"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+type ReadinessLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewReadinessLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ReadinessLogic {
+	return &ReadinessLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// Readiness 检查服务是否就绪：嵌入服务至少有一个端点未熔断
+func (l *ReadinessLogic) Readiness() *types.ReadinessResponse {
+	resp := &types.ReadinessResponse{Ready: true}
+
+	reporter, ok := l.svcCtx.Embedder.(vector.EndpointHealthReporter)
+	if !ok {
+		return resp
+	}
+	resp.Embedder = reporter.EndpointHealth()
+	if len(resp.Embedder) == 0 {
+		return resp
+	}
+
+	healthy := 0
+	for _, ep := range resp.Embedder {
+		if ep.Healthy {
+			healthy++
+		}
+	}
+	if healthy == 0 {
+		l.Errorf("readiness check failed: all %d embedding endpoints are unhealthy", len(resp.Embedder))
+		resp.Ready = false
+	}
+	return resp
+}
@@ -2,10 +2,15 @@ package logic
 
 import (
 	"context"
+	"strconv"
+	"time"
 
+	"github.com/zgsm-ai/codebase-indexer/internal/dao/model"
 	"github.com/zgsm-ai/codebase-indexer/internal/errs"
+	"github.com/zgsm-ai/codebase-indexer/internal/preprocess"
 	"github.com/zgsm-ai/codebase-indexer/internal/store/vector"
 	"github.com/zgsm-ai/codebase-indexer/internal/tracer"
+	"github.com/zgsm-ai/codebase-indexer/internal/usage"
 	"github.com/zgsm-ai/codebase-indexer/pkg/utils"
 
 	"github.com/zgsm-ai/codebase-indexer/internal/svc"
@@ -18,6 +23,7 @@ const (
 	minPositive = 1
 	defaultTopK = 5
 	paramQuery  = "query"
+	paramMode   = "mode"
 )
 
 type SemanticLogic struct {
@@ -34,7 +40,7 @@ func NewSemanticSearchLogic(ctx context.Context, svcCtx *svc.ServiceContext) *Se
 	}
 }
 
-func (l *SemanticLogic) SemanticSearch(req *types.SemanticSearchRequest, authorization string) (resp *types.SemanticSearchResponseData, err error) {
+func (l *SemanticLogic) SemanticSearch(req *types.SemanticSearchRequest, authorization string, userId string) (resp *types.SemanticSearchResponseData, err error) {
 	topK := req.TopK
 	if topK < minPositive {
 		topK = defaultTopK
@@ -43,15 +49,24 @@ func (l *SemanticLogic) SemanticSearch(req *types.SemanticSearchRequest, authori
 		return nil, errs.NewInvalidParamErr(paramQuery, req.Query)
 	}
 
+	var explain *types.SearchExplain
+	if req.Explain {
+		explain = &types.SearchExplain{}
+	}
+
 	// 预处理查询字符串
-	req.Query, err = l.preprocessQuery(req.Query)
+	start := time.Now()
+	processed, debug, err := preprocessQuery(l.svcCtx, req.Query, req.Mode)
 	if err != nil {
 		return nil, err
 	}
+	explain.AddStage(types.ExplainStagePreprocess, start, 0)
 
 	ctx := context.WithValue(l.ctx, tracer.Key, req.ClientId)
+	codebase := findQueryCodebase(l.ctx, l.svcCtx, req.ClientId, req.CodebasePath)
+	ctx = usage.WithScope(ctx, l.svcCtx.Usage, queryUsageScope(codebase, req.ClientId, req.CodebasePath, userId))
 
-	documents, err := l.svcCtx.VectorStore.Query(ctx, req.Query, topK,
+	documents, err := l.svcCtx.VectorStore.Query(ctx, processed.Text, topK,
 		vector.Options{
 			CodebaseId:    0,
 			ClientId:      req.ClientId,
@@ -59,28 +74,102 @@ func (l *SemanticLogic) SemanticSearch(req *types.SemanticSearchRequest, authori
 			CodebaseName:  "",
 			Authorization: authorization,
 			Language:      "code",
+			VectorSlot:    queryVectorSlot(codebase),
+			Result: vector.ResultOptions{
+				MergeAdjacent: req.MergeAdjacent,
+				Diversity:     req.Diversity,
+				MaxPerFile:    req.MaxPerFile,
+			},
+			SearchMode:        processed.Mode,
+			HybridAlpha:       l.svcCtx.Config.Query.HybridAlpha,
+			KeywordScoreScale: l.svcCtx.Config.Query.KeywordScoreScale,
+			Explain:           explain,
 		})
 	if err != nil {
 		return nil, err
 	}
 
 	// 分数过滤
-	scoreThreshold := req.ScoreThreshold
-	filteredDocuments := make([]*types.SemanticFileItem, 0, len(documents))
+	filteredDocuments := filterByScore(documents, req.ScoreThreshold, explain)
+
+	resp = &types.SemanticSearchResponseData{
+		List: filteredDocuments,
+	}
+	if req.Debug {
+		resp.Debug = debug
+	}
+	resp.Explain = explain
+	return resp, nil
+}
+
+// filterByScore 过滤低于分数阈值的结果，explain 不为 nil 时记录阈值和去掉的结果数
+func filterByScore(documents []*types.SemanticFileItem, scoreThreshold float32, explain *types.SearchExplain) []*types.SemanticFileItem {
+	start := time.Now()
+	filtered := make([]*types.SemanticFileItem, 0, len(documents))
 	for _, doc := range documents {
 		if doc.Score >= scoreThreshold {
-			filteredDocuments = append(filteredDocuments, doc)
+			filtered = append(filtered, doc)
 		}
 	}
+	if explain != nil {
+		explain.ScoreThreshold = scoreThreshold
+		explain.AddFilter("scoreThreshold", strconv.FormatFloat(float64(scoreThreshold), 'f', -1, 32), len(documents)-len(filtered))
+		explain.AddStage(types.ExplainStageFilter, start, len(filtered))
+	}
+	return filtered
+}
 
-	return &types.SemanticSearchResponseData{
-		List: filteredDocuments,
-	}, nil
+// findQueryCodebase 查找检索的代码库，未入库时返回 nil，调用方按租户默认值处理
+func findQueryCodebase(ctx context.Context, svcCtx *svc.ServiceContext, clientId, clientPath string) *model.Codebase {
+	codebase, err := svcCtx.Querier.Codebase.FindByClientIdAndPath(ctx, clientId, clientPath)
+	if err != nil {
+		return nil
+	}
+	return codebase
+}
+
+// queryUsageScope 检索请求的用量归属，代码库未入库时只记录客户端路径
+func queryUsageScope(codebase *model.Codebase, clientId, clientPath, userId string) usage.Scope {
+	scope := usage.Scope{
+		Source:       usage.SourceQuery,
+		CodebasePath: clientPath,
+		ClientID:     clientId,
+		UserID:       userId,
+	}
+	if codebase != nil {
+		scope.CodebaseID = codebase.ID
+		scope.CodebasePath = codebase.Path
+		if scope.UserID == "" {
+			scope.UserID = codebase.UserID
+		}
+	}
+	scope.RequestID = tracer.RequestTraceId(int(scope.CodebaseID))
+	return scope
 }
 
-// preprocessQuery 执行自定义查询预处理逻辑
-func (l *SemanticLogic) preprocessQuery(query string) (string, error) {
-	// TODO: 实现自定义预处理逻辑
-	// 例如: 去除特殊字符、敏感词过滤等
-	return query, nil
+// queryVectorSlot 返回代码库当前用于查询的向量槽位
+func queryVectorSlot(codebase *model.Codebase) string {
+	if codebase == nil {
+		return types.EmptyString
+	}
+	return codebase.VectorSlot
+}
+
+// preprocessQuery 执行查询预处理流水线，请求指定检索模式时覆盖自动识别的模式。
+// 未存储源码时 content 为空，BM25 只能匹配路径等元数据，关键词检索改用混合检索
+func preprocessQuery(svcCtx *svc.ServiceContext, text, mode string) (*preprocess.Query, *types.QueryDebug, error) {
+	switch mode {
+	case types.EmptyString, types.SearchModeVector, types.SearchModeKeyword, types.SearchModeHybrid:
+	default:
+		return nil, nil, errs.NewInvalidParamErr(paramMode, mode)
+	}
+	processed, debug := svcCtx.QueryPipeline.Process(text)
+	if mode != types.EmptyString {
+		processed.Mode = mode
+	}
+	if processed.Mode == types.SearchModeKeyword && !svcCtx.Config.VectorStore.StoreSourceCode {
+		processed.Mode = types.SearchModeHybrid
+	}
+	debug.Mode = processed.Mode
+	return processed, debug, nil
 }
@@ -0,0 +1,55 @@
+package logic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/preprocess"
+	"github.com/zgsm-ai/codebase-indexer/internal/store/vector"
+	"github.com/zgsm-ai/codebase-indexer/internal/svc"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+func TestFilterByScoreAfterRRF(t *testing.T) {
+	reranker, err := vector.NewReranker(config.RerankerConf{
+		Provider: vector.RerankerProviderLocal,
+		Fusion:   vector.RerankFusionRRF,
+		Local:    config.LocalRerankerConf{K1: 1.2, B: 0.75, IdentifierWeight: 0.5, PathWeight: 0.3},
+	})
+	require.NoError(t, err)
+	docs, err := reranker.Rerank(context.Background(), "NewRateLimiter", []*types.SemanticFileItem{
+		{FilePath: "internal/job/cleaner.go", Content: "func (c *Cleaner) Run(ctx context.Context) error { return c.clean(ctx) }", Score: 0.9},
+		{FilePath: "internal/store/redis/rate_limiter.go", Content: "func NewRateLimiter(limit int) *RateLimiter { return &RateLimiter{limit: limit} }", Score: 0.7},
+	})
+	require.NoError(t, err)
+
+	// 融合分数缩放到 (0,1]，默认阈值 0.3 不会过滤掉所有结果
+	explain := &types.SearchExplain{}
+	filtered := filterByScore(docs, 0.3, explain)
+	assert.Len(t, filtered, 2)
+	assert.Equal(t, "internal/store/redis/rate_limiter.go", filtered[0].FilePath)
+	assert.Equal(t, []*types.FilterExplain{{Name: "scoreThreshold", Value: "0.3"}}, explain.Filters)
+}
+
+func TestPreprocessQueryKeywordMode(t *testing.T) {
+	pipeline, err := preprocess.NewPipeline(config.QueryConf{})
+	require.NoError(t, err)
+	svcCtx := &svc.ServiceContext{QueryPipeline: pipeline}
+
+	// 未存储源码时代码类查询和指定的关键词检索都改用混合检索
+	q, debug, err := preprocessQuery(svcCtx, "NewRateLimiter", types.EmptyString)
+	require.NoError(t, err)
+	assert.Equal(t, types.SearchModeHybrid, q.Mode)
+	assert.Equal(t, types.SearchModeHybrid, debug.Mode)
+	q, _, err = preprocessQuery(svcCtx, "rate limiter", types.SearchModeKeyword)
+	require.NoError(t, err)
+	assert.Equal(t, types.SearchModeHybrid, q.Mode)
+
+	svcCtx.Config.VectorStore.StoreSourceCode = true
+	q, _, err = preprocessQuery(svcCtx, "NewRateLimiter", types.EmptyString)
+	require.NoError(t, err)
+	assert.Equal(t, types.SearchModeKeyword, q.Mode)
+}
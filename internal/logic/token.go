@@ -8,6 +8,7 @@ import (
 
 	"github.com/zgsm-ai/codebase-indexer/internal/svc"
 	"github.com/zgsm-ai/codebase-indexer/internal/types"
+	"github.com/zgsm-ai/codebase-indexer/internal/usage"
 )
 
 // TokenLogic token生成逻辑
@@ -24,12 +25,20 @@ func NewTokenLogic(ctx context.Context, svcCtx *svc.ServiceContext) *TokenLogic
 	}
 }
 
-// GenerateToken 生成JWT令牌
-func (l *TokenLogic) GenerateToken(req *types.TokenRequest) (*types.TokenResponseData, error) {
+// GenerateToken 生成JWT令牌，userId 为空时不检查用量配额
+func (l *TokenLogic) GenerateToken(req *types.TokenRequest, userId string) (*types.TokenResponseData, error) {
 	if err := l.validateRequest(req); err != nil {
 		return nil, fmt.Errorf("invalid request: %w", err)
 	}
 
+	// 用户本月嵌入用量已达配额时拒绝新的索引任务
+	if err := l.svcCtx.Usage.CheckQuota(l.ctx, userId); err != nil {
+		if errors.Is(err, usage.ErrQuotaExceeded) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("查询用户用量失败: %w", err)
+	}
+
 	// 1. 读取限流配置文件
 	tokenLimit := l.svcCtx.Config.TokenLimit
 	if !tokenLimit.Enabled {
@@ -0,0 +1,115 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zgsm-ai/codebase-indexer/internal/errs"
+	"github.com/zgsm-ai/codebase-indexer/internal/svc"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+const usageDateLayout = "2006-01-02"
+
+// usageGroupColumns 聚合维度对应的分组表达式
+var usageGroupColumns = map[string]string{
+	"user":     "user_id",
+	"codebase": "codebase_path",
+	"request":  "request_id",
+	"model":    "model",
+	"day":      "to_char(created_at, 'YYYY-MM-DD')",
+	"month":    "to_char(created_at, 'YYYY-MM')",
+}
+
+type UsageLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewUsageLogic(ctx context.Context, svcCtx *svc.ServiceContext) *UsageLogic {
+	return &UsageLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// Usage 按时间范围和维度聚合嵌入/重排 token 用量
+func (l *UsageLogic) Usage(req *types.UsageRequest) (*types.UsageResponseData, error) {
+	groupBy := req.GroupBy
+	if groupBy == "" {
+		groupBy = "user"
+	}
+	column, ok := usageGroupColumns[groupBy]
+	if !ok {
+		return nil, errs.NewInvalidParamErr("groupBy", req.GroupBy)
+	}
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	end := now
+	var err error
+	if req.StartTime != "" {
+		if start, err = parseUsageTime(req.StartTime, false); err != nil {
+			return nil, errs.NewInvalidParamErr("startTime", req.StartTime)
+		}
+	}
+	if req.EndTime != "" {
+		if end, err = parseUsageTime(req.EndTime, true); err != nil {
+			return nil, errs.NewInvalidParamErr("endTime", req.EndTime)
+		}
+	}
+	if !end.After(start) {
+		return nil, errs.NewInvalidParamErr("endTime", req.EndTime)
+	}
+
+	u := l.svcCtx.Querier.EmbeddingUsage
+	db := u.WithContext(l.ctx).UnderlyingDB().
+		Select(fmt.Sprintf("%s AS \"key\", usage_type, SUM(prompt_tokens) AS prompt_tokens, SUM(total_tokens) AS total_tokens, "+
+			"COUNT(*) AS calls, SUM(CASE WHEN estimated THEN 1 ELSE 0 END) AS estimated_calls", column)).
+		Where("created_at >= ? AND created_at < ?", start, end)
+	if req.UserId != "" {
+		db = db.Where("user_id = ?", req.UserId)
+	}
+	if req.CodebaseId > 0 {
+		db = db.Where("codebase_id = ?", req.CodebaseId)
+	}
+	if req.UsageType != "" {
+		db = db.Where("usage_type = ?", req.UsageType)
+	}
+
+	list := make([]*types.UsageItem, 0)
+	if err := db.Group(column).Group("usage_type").Order("total_tokens DESC").Scan(&list).Error; err != nil {
+		return nil, fmt.Errorf("failed to aggregate usage: %w", err)
+	}
+
+	var total int64
+	for _, item := range list {
+		total += item.TotalTokens
+	}
+	return &types.UsageResponseData{
+		StartTime:   start.Format(time.RFC3339),
+		EndTime:     end.Format(time.RFC3339),
+		GroupBy:     groupBy,
+		TotalTokens: total,
+		List:        list,
+	}, nil
+}
+
+// parseUsageTime 支持 RFC3339 和日期格式，结束日期包含当天
+func parseUsageTime(value string, isEnd bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(usageDateLayout, value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if isEnd {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
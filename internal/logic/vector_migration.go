@@ -0,0 +1,93 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zgsm-ai/codebase-indexer/internal/dao/model"
+	"github.com/zgsm-ai/codebase-indexer/internal/job"
+	"github.com/zgsm-ai/codebase-indexer/internal/svc"
+	"github.com/zgsm-ai/codebase-indexer/internal/tracer"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+	"gorm.io/gorm"
+)
+
+type VectorMigrationLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewVectorMigrationLogic(ctx context.Context, svcCtx *svc.ServiceContext) *VectorMigrationLogic {
+	return &VectorMigrationLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// Migrate 为查询槽位不是当前模型槽位，或仍在旧版本单向量类中的代码库提交重新嵌入任务
+func (l *VectorMigrationLogic) Migrate(req *types.VectorMigrationRequest, authorization string) (*types.VectorMigrationResponseData, error) {
+	targetSlot := l.svcCtx.VectorStore.TargetVectorSlot()
+	codebases, err := l.findCodebases(req, targetSlot)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &types.VectorMigrationResponseData{
+		TargetSlot: targetSlot,
+		Submitted:  make([]*types.VectorMigrationItem, 0, len(codebases)),
+	}
+	for _, codebase := range codebases {
+		task := &job.ReembedTask{
+			SvcCtx:        l.svcCtx,
+			Codebase:      codebase,
+			Authorization: authorization,
+		}
+		err := l.svcCtx.TaskPool.Submit(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), l.svcCtx.Config.VectorStore.Migration.Timeout)
+			defer cancel()
+			ctx = context.WithValue(ctx, tracer.Key, tracer.TaskTraceId(int(task.Codebase.ID)))
+			if err := task.Run(ctx); err != nil {
+				tracer.WithTrace(ctx).Errorf("re-embed task failed: %v", err)
+			}
+		})
+		if err != nil {
+			return resp, fmt.Errorf("re-embed task submit failed, err:%w", err)
+		}
+		resp.Submitted = append(resp.Submitted, &types.VectorMigrationItem{
+			CodebaseId:   codebase.ID,
+			ClientId:     codebase.ClientID,
+			CodebasePath: codebase.Path,
+			FromSlot:     codebase.VectorSlot,
+		})
+	}
+	l.Infof("submitted %d re-embed tasks for vector slot %s", len(resp.Submitted), targetSlot)
+	return resp, nil
+}
+
+func (l *VectorMigrationLogic) findCodebases(req *types.VectorMigrationRequest, targetSlot string) ([]*model.Codebase, error) {
+	c := l.svcCtx.Querier.Codebase
+	legacy := l.svcCtx.VectorStore.LegacyVectorClass() != types.EmptyString
+	if req.ClientId != types.EmptyString || req.CodebasePath != types.EmptyString {
+		codebase, err := c.FindByClientIdAndPath(l.ctx, req.ClientId, req.CodebasePath)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("codebase not found, clientId: %s, codebasePath: %s", req.ClientId, req.CodebasePath)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if codebase.VectorSlot == targetSlot && !legacy {
+			return nil, nil
+		}
+		return []*model.Codebase{codebase}, nil
+	}
+	q := c.WithContext(l.ctx).Where(c.Status.Eq(string(model.CodebaseStatusActive)))
+	// 旧类中的租户与槽位无关，需要全部检查，不在旧类中的租户迁移时直接跳过
+	if !legacy {
+		q = q.Where(c.VectorSlot.Neq(targetSlot))
+	}
+	return q.Find()
+}
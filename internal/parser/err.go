@@ -9,6 +9,7 @@ var ErrFileExtNotFound = errors.New("file extension not found")
 var ErrLangConfNotFound = errors.New("langConf not found")
 var ErrQueryNotFound = errors.New("query not found")
 var ErrInvalidOpenAPISpec = errors.New("file does not conform to the OpenAPI specification")
+var ErrInvalidNotebook = errors.New("file is not a valid Jupyter notebook")
 
 // Custom errors
 var (
@@ -30,5 +31,6 @@ func IsRealQueryErr(err error) bool {
 }
 
 func IsNotSupportedFileError(err error) bool {
-	return errors.Is(err, ErrFileExtNotFound) || errors.Is(err, ErrLangConfNotFound) || errors.Is(err, ErrInvalidOpenAPISpec)
+	return errors.Is(err, ErrFileExtNotFound) || errors.Is(err, ErrLangConfNotFound) || errors.Is(err, ErrInvalidOpenAPISpec) ||
+		errors.Is(err, ErrInvalidNotebook)
 }
@@ -38,6 +38,14 @@ const (
 	Kotlin     Language = "kotlin"
 	Scala      Language = "scala"
 	Markdown   Language = "markdown"
+	Vue        Language = "vue"
+	Svelte     Language = "svelte"
+	YAML       Language = "yaml"
+	JSON       Language = "json"
+	TOML       Language = "toml"
+	Protobuf   Language = "protobuf"
+	GraphQL    Language = "graphql"
+	Jupyter    Language = "jupyter"
 	OpenAPI    Language = "openapi"
 	Swagger    Language = "swagger"
 )
@@ -50,6 +58,8 @@ type LanguageConfig struct {
 }
 
 // languageConfigs 定义了所有支持的语言配置
+// TODO Swift、Lua、Bash、SQL、Dart、Elixir 尚未支持：需要引入对应的 tree-sitter Go 绑定，
+// 并补充 chunkrules、queries/base、queries/def 和 testdata；在此之前这些文件返回 ErrLangConfNotFound，不会被索引
 var languageConfigs = []*LanguageConfig{
 	{
 		Language: Go,
@@ -158,6 +168,66 @@ var languageConfigs = []*LanguageConfig{
 		},
 		SupportedExts: []string{".md", ".mdx"},
 	},
+	{
+		Language: Vue,
+		SitterLanguage: func() *sitter.Language {
+			// 单文件组件没有独立的 tree-sitter 解析器，<script> 块按 TS/JS 语法解析
+			return nil
+		},
+		SupportedExts: []string{".vue"},
+	},
+	{
+		Language: Svelte,
+		SitterLanguage: func() *sitter.Language {
+			// 单文件组件没有独立的 tree-sitter 解析器，<script> 块按 TS/JS 语法解析
+			return nil
+		},
+		SupportedExts: []string{".svelte"},
+	},
+	{
+		Language: YAML,
+		SitterLanguage: func() *sitter.Language {
+			// 配置和接口定义文件按文件结构切分，不使用 tree-sitter 解析器
+			return nil
+		},
+		SupportedExts: []string{".yaml", ".yml"},
+	},
+	{
+		Language: JSON,
+		SitterLanguage: func() *sitter.Language {
+			return nil
+		},
+		SupportedExts: []string{".json"},
+	},
+	{
+		Language: TOML,
+		SitterLanguage: func() *sitter.Language {
+			return nil
+		},
+		SupportedExts: []string{".toml"},
+	},
+	{
+		Language: Protobuf,
+		SitterLanguage: func() *sitter.Language {
+			return nil
+		},
+		SupportedExts: []string{".proto"},
+	},
+	{
+		Language: GraphQL,
+		SitterLanguage: func() *sitter.Language {
+			return nil
+		},
+		SupportedExts: []string{".graphql", ".graphqls", ".gql"},
+	},
+	{
+		Language: Jupyter,
+		SitterLanguage: func() *sitter.Language {
+			// notebook 解析 JSON 后按单元格切分，代码单元格使用内核语言的解析器
+			return nil
+		},
+		SupportedExts: []string{".ipynb"},
+	},
 }
 
 // GetLanguageConfigs 获取所有语言配置
@@ -0,0 +1,78 @@
+package preprocess
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+const (
+	StageNormalize        = "normalize"         // 规范化空白和全角字符
+	StageDetectCode       = "detect_code"       // 识别代码类查询并选择检索模式
+	StageSplitIdentifiers = "split_identifiers" // 拆分驼峰和下划线标识符
+	StageGlossary         = "glossary"          // 按术语表扩展同义词
+)
+
+// DefaultStages 未配置时执行的预处理阶段，识别代码在拆分标识符和扩展同义词之前，只依据用户的原始输入
+var DefaultStages = []string{StageNormalize, StageDetectCode, StageSplitIdentifiers, StageGlossary}
+
+// Query 预处理中的查询
+type Query struct {
+	Text string
+	Mode string // 检索模式，types.SearchModeVector、SearchModeKeyword 或 SearchModeHybrid
+}
+
+// Stage 查询预处理阶段
+type Stage interface {
+	Name() string
+	Process(q *Query)
+}
+
+// StageFactory 根据配置创建预处理阶段
+type StageFactory func(c config.QueryConf) Stage
+
+var stageFactories = map[string]StageFactory{
+	StageNormalize:        func(config.QueryConf) Stage { return normalizeStage{} },
+	StageDetectCode:       func(config.QueryConf) Stage { return detectCodeStage{} },
+	StageSplitIdentifiers: func(config.QueryConf) Stage { return splitIdentifiersStage{} },
+	StageGlossary:         newGlossaryStage,
+}
+
+// Pipeline 按配置顺序执行的查询预处理流水线
+type Pipeline struct {
+	stages []Stage
+}
+
+// NewPipeline 按配置创建预处理流水线，未配置阶段时使用 DefaultStages
+func NewPipeline(c config.QueryConf) (*Pipeline, error) {
+	names := c.Stages
+	if len(names) == 0 {
+		names = DefaultStages
+	}
+	p := &Pipeline{stages: make([]Stage, 0, len(names))}
+	for _, name := range names {
+		factory, ok := stageFactories[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown query stage %q, available: %v", name, DefaultStages)
+		}
+		p.stages = append(p.stages, factory(c))
+	}
+	return p, nil
+}
+
+// Process 依次执行各阶段，返回处理后的查询和各阶段的输出；流水线为 nil 时原样返回
+func (p *Pipeline) Process(text string) (*Query, *types.QueryDebug) {
+	q := &Query{Text: text, Mode: types.SearchModeVector}
+	debug := &types.QueryDebug{Original: text}
+	if p != nil {
+		for _, stage := range p.stages {
+			stage.Process(q)
+			debug.Stages = append(debug.Stages, &types.QueryStageTrace{Stage: stage.Name(), Query: q.Text, Mode: q.Mode})
+		}
+	}
+	debug.Query = q.Text
+	debug.Mode = q.Mode
+	return q, debug
+}
@@ -0,0 +1,79 @@
+package preprocess
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+func TestStages(t *testing.T) {
+	q := &Query{Text: "  查询　索引状态\n\tＡＰＩ  ", Mode: types.SearchModeVector}
+	normalizeStage{}.Process(q)
+	assert.Equal(t, "查询 索引状态 API", q.Text)
+
+	for text, mode := range map[string]string{
+		"how is the index status reported":  types.SearchModeVector,
+		"getUserID":                         types.SearchModeKeyword,
+		"vector.NewReranker()":              types.SearchModeKeyword,
+		"where is parse_config called?":     types.SearchModeHybrid,
+		"internal/job/embedding.go 做了什么":    types.SearchModeHybrid,
+		"登录失败 (login) 的原因":                  types.SearchModeVector,
+		"err != nil":                        types.SearchModeHybrid,
+		"RUN the HTTP server. e.g. locally": types.SearchModeVector,
+	} {
+		q := &Query{Text: text}
+		detectCodeStage{}.Process(q)
+		assert.Equal(t, mode, q.Mode, text)
+	}
+
+	q = &Query{Text: "getUserID 和 HTTPServer_v2 的 user"}
+	splitIdentifiersStage{}.Process(q)
+	assert.Equal(t, "getUserID 和 HTTPServer_v2 的 user get id http server v2", q.Text)
+	assert.Equal(t, []string{"parse", "json", "stream"}, SplitIdentifier("parseJSONStream"))
+
+	glossary := newGlossaryStage(config.QueryConf{MaxExpansions: 2, Glossary: []config.GlossaryConf{
+		{Terms: []string{"登录", "login", "sign in"}},
+		{Terms: []string{"限流", "rate limit", "throttle"}},
+		{Terms: []string{"鉴权", "auth"}},
+	}})
+	q = &Query{Text: "用户登录时的 rate limit"}
+	glossary.Process(q)
+	assert.Equal(t, "用户登录时的 rate limit login sign in", q.Text)
+	// 英文术语按完整单词匹配
+	q = &Query{Text: "authorization header"}
+	glossary.Process(q)
+	assert.Equal(t, "authorization header", q.Text)
+}
+
+func TestPipeline(t *testing.T) {
+	p, err := NewPipeline(config.QueryConf{Glossary: []config.GlossaryConf{{Terms: []string{"login", "登录"}}}})
+	require.NoError(t, err)
+
+	q, debug := p.Process("  userLogin  ")
+	assert.Equal(t, "userLogin user login 登录", q.Text)
+	assert.Equal(t, types.SearchModeKeyword, q.Mode)
+	assert.Equal(t, "  userLogin  ", debug.Original)
+	assert.Equal(t, q.Text, debug.Query)
+	assert.Equal(t, []*types.QueryStageTrace{
+		{Stage: StageNormalize, Query: "userLogin", Mode: types.SearchModeVector},
+		{Stage: StageDetectCode, Query: "userLogin", Mode: types.SearchModeKeyword},
+		{Stage: StageSplitIdentifiers, Query: "userLogin user login", Mode: types.SearchModeKeyword},
+		{Stage: StageGlossary, Query: "userLogin user login 登录", Mode: types.SearchModeKeyword},
+	}, debug.Stages)
+
+	p, err = NewPipeline(config.QueryConf{Stages: []string{StageNormalize}})
+	require.NoError(t, err)
+	q, _ = p.Process(" a  b ")
+	assert.Equal(t, &Query{Text: "a b", Mode: types.SearchModeVector}, q)
+
+	_, err = NewPipeline(config.QueryConf{Stages: []string{"stem"}})
+	assert.ErrorContains(t, err, "unknown query stage")
+
+	var nilPipeline *Pipeline
+	q, _ = nilPipeline.Process("getUserID")
+	assert.Equal(t, "getUserID", q.Text)
+}
@@ -0,0 +1,249 @@
+package preprocess
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+// normalizeStage 全角字母、数字和符号转为半角，合并连续空白
+type normalizeStage struct{}
+
+func (normalizeStage) Name() string { return StageNormalize }
+
+func (normalizeStage) Process(q *Query) {
+	q.Text = strings.Join(strings.Fields(strings.Map(foldWidth, q.Text)), " ")
+}
+
+func foldWidth(r rune) rune {
+	switch {
+	case r == '　':
+		return ' '
+	case r >= '！' && r <= '～':
+		return r - 0xFEE0
+	}
+	return r
+}
+
+// detectCodeStage 全部由代码片段组成的查询（如标识符、调用表达式、文件路径）使用关键词检索，
+// 代码与自然语言混合的查询使用混合检索，其余使用向量检索。未存储源码时关键词检索由调用方改为混合检索
+type detectCodeStage struct{}
+
+func (detectCodeStage) Name() string { return StageDetectCode }
+
+func (detectCodeStage) Process(q *Query) {
+	words := strings.Fields(q.Text)
+	code := 0
+	for _, word := range words {
+		if IsCodeLike(word) {
+			code++
+		}
+	}
+	switch {
+	case code == 0:
+		q.Mode = types.SearchModeVector
+	case code == len(words):
+		q.Mode = types.SearchModeKeyword
+	default:
+		q.Mode = types.SearchModeHybrid
+	}
+}
+
+// codeOperators 只出现在代码中的符号
+var codeOperators = []string{"::", "->", "=>", ":=", "==", "!=", "&&", "||", "<-"}
+
+// IsCodeLike 判断单个词是否像代码：包含代码符号、函数调用、驼峰或下划线标识符、成员访问或路径
+func IsCodeLike(word string) bool {
+	for _, op := range codeOperators {
+		if strings.Contains(word, op) {
+			return true
+		}
+	}
+	word = strings.Trim(word, ".,;:!?\"'`，。；：！？“”‘’")
+	runes := []rune(word)
+	for i := 1; i < len(runes); i++ {
+		// 函数调用，如 Run( 或 init()
+		if runes[i] == '(' && isIdentRune(runes[i-1]) {
+			return true
+		}
+	}
+	for i := 1; i < len(runes)-1; i++ {
+		if !isIdentRune(runes[i-1]) || !isIdentRune(runes[i+1]) {
+			continue
+		}
+		switch runes[i] {
+		case '_', '/', '\\':
+			// 下划线连接或路径：两侧都是标识符字符
+			return true
+		case '.':
+			// 成员访问或文件扩展名，排除 e.g.、v1.2 等缩写和版本号
+			if unicode.IsLetter(runes[i+1]) && max(identRunLen(runes[:i], true), identRunLen(runes[i+1:], false)) >= 2 {
+				return true
+			}
+		}
+	}
+	for i := 1; i < len(runes); i++ {
+		if unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i]) && runes[i] < unicode.MaxASCII {
+			return true
+		}
+	}
+	return false
+}
+
+// identRunLen 从片段末尾（backward）或开头起连续标识符字符的个数
+func identRunLen(runes []rune, backward bool) int {
+	n := 0
+	for i := range runes {
+		r := runes[i]
+		if backward {
+			r = runes[len(runes)-1-i]
+		}
+		if !isIdentRune(r) {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// splitIdentifiersStage 在查询后追加驼峰和下划线标识符拆分出的词，保留原标识符用于精确匹配
+type splitIdentifiersStage struct{}
+
+func (splitIdentifiersStage) Name() string { return StageSplitIdentifiers }
+
+func (splitIdentifiersStage) Process(q *Query) {
+	seen := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(q.Text, isNotIdentRune) {
+		seen[strings.ToLower(word)] = true
+	}
+	var extra []string
+	for _, word := range strings.FieldsFunc(q.Text, isNotIdentRune) {
+		parts := SplitIdentifier(word)
+		if len(parts) < 2 {
+			continue
+		}
+		for _, part := range parts {
+			if !seen[part] {
+				seen[part] = true
+				extra = append(extra, part)
+			}
+		}
+	}
+	if len(extra) > 0 {
+		q.Text += " " + strings.Join(extra, " ")
+	}
+}
+
+// SplitIdentifier 将标识符拆分为小写的词：getUserID -> get user id，HTTPServer_v2 -> http server v2
+func SplitIdentifier(word string) []string {
+	var parts []string
+	runes := []rune(word)
+	start := 0
+	flush := func(end int) {
+		if end > start {
+			parts = append(parts, strings.ToLower(string(runes[start:end])))
+		}
+		start = end
+	}
+	for i, r := range runes {
+		switch {
+		case r == '_':
+			flush(i)
+			start = i + 1
+		case i > start && unicode.IsUpper(r):
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush(i)
+			}
+		}
+	}
+	flush(len(runes))
+	return parts
+}
+
+// glossaryStage 查询包含术语组中的任一术语时，追加同组的其余术语
+type glossaryStage struct {
+	groups        [][]string
+	maxExpansions int
+}
+
+func newGlossaryStage(c config.QueryConf) Stage {
+	s := &glossaryStage{maxExpansions: c.MaxExpansions}
+	for _, g := range c.Glossary {
+		var terms []string
+		for _, term := range g.Terms {
+			if term = strings.TrimSpace(term); term != "" {
+				terms = append(terms, term)
+			}
+		}
+		if len(terms) > 1 {
+			s.groups = append(s.groups, terms)
+		}
+	}
+	return s
+}
+
+func (*glossaryStage) Name() string { return StageGlossary }
+
+func (s *glossaryStage) Process(q *Query) {
+	// 只按原查询匹配术语组，追加的术语不再触发其他术语组
+	original := strings.ToLower(q.Text)
+	lower := original
+	var extra []string
+	for _, group := range s.groups {
+		matched := false
+		for _, term := range group {
+			if containsTerm(original, strings.ToLower(term)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		for _, term := range group {
+			if s.maxExpansions > 0 && len(extra) >= s.maxExpansions {
+				break
+			}
+			if t := strings.ToLower(term); !containsTerm(lower, t) {
+				extra = append(extra, term)
+				lower += " " + t
+			}
+		}
+	}
+	if len(extra) > 0 {
+		q.Text += " " + strings.Join(extra, " ")
+	}
+}
+
+// containsTerm 查找术语，英文术语需按完整单词匹配（login 不匹配 logins），中文术语按子串匹配
+func containsTerm(text, term string) bool {
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], term)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(term)
+		before := start == 0 || !isIdentByte(text[start-1]) || !isIdentByte(term[0])
+		after := end == len(text) || !isIdentByte(text[end]) || !isIdentByte(term[len(term)-1])
+		if before && after {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+func isIdentByte(b byte) bool {
+	return b < unicode.MaxASCII && isIdentRune(rune(b))
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || (r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)))
+}
+
+func isNotIdentRune(r rune) bool {
+	return !isIdentRune(r)
+}
@@ -0,0 +1,23 @@
+package redact
+
+import "github.com/zgsm-ai/codebase-indexer/internal/types"
+
+// RedactChunk 对分块中发送到嵌入模型或写入向量库的文本脱敏：内容、文档注释和上下文头。
+// 上下文头中的签名和导入行取自原始代码，滑动窗口切分时会复制到每个窗口，需要单独脱敏。
+// 返回内容中的命中记录，行号相对于分块内容；文档注释和签名同时包含在内容中，不重复记录
+func (r *Redactor) RedactChunk(chunk *types.CodeChunk) []Finding {
+	if !r.Enabled() {
+		return nil
+	}
+	if chunk.Docstring != "" {
+		docstring, _ := r.Redact([]byte(chunk.Docstring))
+		chunk.Docstring = string(docstring)
+	}
+	if chunk.EmbeddingHeader != "" {
+		header, _ := r.Redact([]byte(chunk.EmbeddingHeader))
+		chunk.EmbeddingHeader = string(header)
+	}
+	content, findings := r.Redact(chunk.Content)
+	chunk.Content = content
+	return findings
+}
@@ -0,0 +1,118 @@
+package redact
+
+import (
+	"bytes"
+	"sort"
+)
+
+const (
+	defaultEntropyThreshold = 3.5
+	defaultMinSecretLength  = 20
+)
+
+// Options 脱敏配置
+type Options struct {
+	Enabled          bool
+	EntropyThreshold float64  // 通用规则的最小香农熵，高熵字符串规则在此基础上加 1
+	MinSecretLength  int      // 高熵字符串的最小长度
+	DisabledRules    []string // 禁用的内置规则名称
+}
+
+// Finding 单条脱敏记录，不包含原始敏感内容
+type Finding struct {
+	Rule string
+	Line int // 从 1 开始，相对于被脱敏内容的行号
+}
+
+// Redactor 在内容发送到外部模型或写入向量库前替换其中的密钥、凭证等敏感信息
+type Redactor struct {
+	enabled bool
+	rules   []Rule
+}
+
+// New 创建脱敏器，extra 为追加的自定义规则
+func New(options Options, extra ...Rule) *Redactor {
+	if options.EntropyThreshold <= 0 {
+		options.EntropyThreshold = defaultEntropyThreshold
+	}
+	if options.MinSecretLength <= 0 {
+		options.MinSecretLength = defaultMinSecretLength
+	}
+	disabled := make(map[string]struct{}, len(options.DisabledRules))
+	for _, name := range options.DisabledRules {
+		disabled[name] = struct{}{}
+	}
+
+	r := &Redactor{enabled: options.Enabled}
+	for _, rule := range builtinRules(options.EntropyThreshold, options.MinSecretLength) {
+		if _, ok := disabled[rule.Name()]; !ok {
+			r.rules = append(r.rules, rule)
+		}
+	}
+	r.rules = append(r.rules, extra...)
+	return r
+}
+
+// Enabled 是否启用脱敏
+func (r *Redactor) Enabled() bool {
+	return r != nil && r.enabled && len(r.rules) > 0
+}
+
+// Placeholder 返回规则对应的占位符
+func Placeholder(rule string) string {
+	return "[REDACTED:" + rule + "]"
+}
+
+type ruleMatch struct {
+	Match
+	rule string
+}
+
+// Redact 返回脱敏后的内容及命中记录；未命中时直接返回原内容
+func (r *Redactor) Redact(content []byte) ([]byte, []Finding) {
+	if !r.Enabled() || len(content) == 0 {
+		return content, nil
+	}
+
+	var matches []ruleMatch
+	for _, rule := range r.rules {
+		for _, m := range rule.Find(content) {
+			matches = append(matches, ruleMatch{Match: m, rule: rule.Name()})
+		}
+	}
+	if len(matches) == 0 {
+		return content, nil
+	}
+
+	// 按起始位置排序，起始位置相同时优先更长的匹配，重叠区间只保留先出现的一个
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Start != matches[j].Start {
+			return matches[i].Start < matches[j].Start
+		}
+		return matches[i].End > matches[j].End
+	})
+
+	var (
+		buf      bytes.Buffer
+		findings []Finding
+		last     int
+		line     = 1
+	)
+	buf.Grow(len(content))
+	for _, m := range matches {
+		if m.Start < last {
+			continue
+		}
+		line += bytes.Count(content[last:m.Start], []byte("\n"))
+		buf.Write(content[last:m.Start])
+		buf.WriteString(Placeholder(m.rule))
+		// 保留被替换内容中的换行，避免分块的行号范围与内容错位
+		newlines := bytes.Count(content[m.Start:m.End], []byte("\n"))
+		buf.Write(bytes.Repeat([]byte("\n"), newlines))
+		findings = append(findings, Finding{Rule: m.rule, Line: line})
+		line += newlines
+		last = m.End
+	}
+	buf.Write(content[last:])
+	return buf.Bytes(), findings
+}
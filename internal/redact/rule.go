@@ -0,0 +1,116 @@
+package redact
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+)
+
+// Match 规则在内容中命中的字节区间 [Start, End)
+type Match struct {
+	Start int
+	End   int
+}
+
+// Rule 敏感信息检测规则，可通过 Redactor 注册自定义实现
+type Rule interface {
+	// Name 规则名称，同时用于生成占位符
+	Name() string
+	// Find 返回内容中所有需要脱敏的区间
+	Find(content []byte) []Match
+}
+
+// regexRule 基于正则表达式的检测规则，可选地对命中内容做熵值校验
+type regexRule struct {
+	name       string
+	re         *regexp.Regexp
+	group      int     // 需要脱敏的捕获组，0 表示整个匹配
+	minEntropy float64 // 命中内容的最小香农熵（bit/字符），0 表示不校验
+	minLength  int     // 命中内容的最小长度，0 表示不校验
+}
+
+// NewRegexRule 创建正则规则，group 指定需要替换的捕获组，minEntropy 大于 0 时过滤低熵的命中
+func NewRegexRule(name, pattern string, group int, minEntropy float64) (Rule, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("compile redaction rule %s: %w", name, err)
+	}
+	if group < 0 || group > re.NumSubexp() {
+		return nil, fmt.Errorf("redaction rule %s: group %d out of range", name, group)
+	}
+	return &regexRule{name: name, re: re, group: group, minEntropy: minEntropy}, nil
+}
+
+func mustRegexRule(name, pattern string, group int, minEntropy float64, minLength int) *regexRule {
+	return &regexRule{name: name, re: regexp.MustCompile(pattern), group: group, minEntropy: minEntropy, minLength: minLength}
+}
+
+func (r *regexRule) Name() string {
+	return r.name
+}
+
+func (r *regexRule) Find(content []byte) []Match {
+	var matches []Match
+	for _, loc := range r.re.FindAllSubmatchIndex(content, -1) {
+		start, end := loc[2*r.group], loc[2*r.group+1]
+		if start < 0 || end <= start {
+			continue
+		}
+		value := content[start:end]
+		if r.minLength > 0 && len(value) < r.minLength {
+			continue
+		}
+		if r.minEntropy > 0 && ShannonEntropy(value) < r.minEntropy {
+			continue
+		}
+		matches = append(matches, Match{Start: start, End: end})
+	}
+	return matches
+}
+
+// ShannonEntropy 计算字节序列的香农熵（bit/字符）
+func ShannonEntropy(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	var freq [256]int
+	for _, b := range data {
+		freq[b]++
+	}
+	var entropy float64
+	n := float64(len(data))
+	for _, c := range freq {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// builtinRules 内置规则，entropyThreshold 和 minLength 仅作用于通用的赋值和高熵字符串规则
+func builtinRules(entropyThreshold float64, minLength int) []Rule {
+	return []Rule{
+		mustRegexRule("private_key",
+			`-----BEGIN[A-Z0-9 ]*PRIVATE KEY(?: BLOCK)?-----[\s\S]*?-----END[A-Z0-9 ]*PRIVATE KEY(?: BLOCK)?-----`, 0, 0, 0),
+		mustRegexRule("aws_access_key", `\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[0-9A-Z]{16}\b`, 0, 0, 0),
+		mustRegexRule("github_token", `\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`, 0, 0, 0),
+		mustRegexRule("gitlab_token", `\bglpat-[A-Za-z0-9_-]{20,}\b`, 0, 0, 0),
+		mustRegexRule("slack_token", `\bxox[abposr]-[A-Za-z0-9-]{10,}\b`, 0, 0, 0),
+		mustRegexRule("google_api_key", `\bAIza[0-9A-Za-z_-]{35}\b`, 0, 0, 0),
+		mustRegexRule("stripe_key", `\b[rs]k_(?:live|test)_[0-9A-Za-z]{24,}\b`, 0, 0, 0),
+		mustRegexRule("openai_api_key", `\bsk-(?:proj-|ant-)?[A-Za-z0-9_-]{20,}`, 0, 0, 0),
+		mustRegexRule("jwt", `\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`, 0, 0, 0),
+		// 只替换连接串中的密码部分，保留协议、用户名和地址便于理解代码
+		mustRegexRule("connection_string",
+			`\b[A-Za-z][A-Za-z0-9+.-]*://[^\s:/@'"]+:([^\s@/'"]+)@[^\s'"]+`, 1, 0, 0),
+		mustRegexRule("connection_string_password",
+			`(?i)\b(?:password|pwd)\s*=\s*([^;'"\s]+)`, 1, 0, 0),
+		mustRegexRule("secret_assignment",
+			`(?i)\b[\w.-]*(?:secret|passw(?:or)?d|token|api[_-]?key|access[_-]?key|private[_-]?key|credential)[\w.-]*["']?\s*(?::=|=>|[:=])\s*["'`+"`"+`]([^\s"'`+"`"+`;,)]+)`,
+			1, entropyThreshold, minLength/2),
+		mustRegexRule("high_entropy_string",
+			`["'`+"`"+`]([A-Za-z0-9+/=_-]{`+fmt.Sprint(minLength)+`,})["'`+"`"+`]`, 1, entropyThreshold+1, 0),
+	}
+}
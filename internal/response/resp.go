@@ -72,3 +72,8 @@ func wrapResponse(v any) Response[any] {
 
 	return resp
 }
+
+// Unavailable 服务未就绪时返回 503，便于探针判断，响应体与 Json 相同
+func Unavailable(w http.ResponseWriter, v any) {
+	httpx.WriteJson(w, http.StatusServiceUnavailable, wrapResponse(v))
+}
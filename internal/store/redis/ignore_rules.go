@@ -0,0 +1,71 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ignoreRulesPrefix 代码库忽略规则文件的键前缀，值为 文件路径 -> 文件内容 的哈希
+const ignoreRulesPrefix = "codebase:ignore_rules:"
+
+// IgnoreRuleStore 按代码库保存 .gitignore 等忽略规则文件，增量同步没有重新上传规则文件时沿用已保存的规则
+type IgnoreRuleStore struct {
+	client *redis.Client
+}
+
+// NewIgnoreRuleStore 创建忽略规则文件存储，规则文件随代码库存在，不设置过期时间
+func NewIgnoreRuleStore(client *redis.Client) *IgnoreRuleStore {
+	return &IgnoreRuleStore{client: client}
+}
+
+// Load 返回代码库已保存的规则文件
+func (s *IgnoreRuleStore) Load(ctx context.Context, codebaseId int32) (map[string][]byte, error) {
+	values, err := s.client.HGetAll(ctx, s.key(codebaseId)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ignore rule files: %w", err)
+	}
+	files := make(map[string][]byte, len(values))
+	for filePath, content := range values {
+		files[filePath] = []byte(content)
+	}
+	return files, nil
+}
+
+// Save 写入本次上传的规则文件并删除已被删除的规则文件
+func (s *IgnoreRuleStore) Save(ctx context.Context, codebaseId int32, uploaded map[string][]byte, deleted []string) error {
+	if len(uploaded) == 0 && len(deleted) == 0 {
+		return nil
+	}
+	key := s.key(codebaseId)
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if len(deleted) > 0 {
+			pipe.HDel(ctx, key, deleted...)
+		}
+		if len(uploaded) > 0 {
+			values := make(map[string]interface{}, len(uploaded))
+			for filePath, content := range uploaded {
+				values[filePath] = content
+			}
+			pipe.HSet(ctx, key, values)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save ignore rule files: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除代码库的全部规则文件
+func (s *IgnoreRuleStore) Delete(ctx context.Context, codebaseId int32) error {
+	if err := s.client.Del(ctx, s.key(codebaseId)).Err(); err != nil {
+		return fmt.Errorf("failed to delete ignore rule files: %w", err)
+	}
+	return nil
+}
+
+func (s *IgnoreRuleStore) key(codebaseId int32) string {
+	return fmt.Sprintf("%s%d", ignoreRulesPrefix, codebaseId)
+}
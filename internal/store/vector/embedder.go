@@ -2,11 +2,15 @@ package vector
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/zgsm-ai/codebase-indexer/internal/config"
 	"github.com/zgsm-ai/codebase-indexer/internal/store/redis"
+	"github.com/zgsm-ai/codebase-indexer/internal/tokenizer"
 	"github.com/zgsm-ai/codebase-indexer/internal/tracer"
 	"github.com/zgsm-ai/codebase-indexer/internal/types"
 )
@@ -17,6 +21,10 @@ type Embedder interface {
 	EmbedCodeChunks(ctx context.Context, chunks []*types.CodeChunk) ([]*CodeChunkEmbedding, error)
 	// EmbedQuery creates an embedding for a single query string
 	EmbedQuery(ctx context.Context, query string) ([]float32, error)
+	// Dimensions returns the dimensions of the embedding vectors, 0 means unknown
+	Dimensions() int
+	// MaxInputTokens returns the max number of tokens accepted for a single input
+	MaxInputTokens() int
 }
 
 // CodeChunkEmbedding represents a code chunk with its embedding vector
@@ -29,6 +37,7 @@ type CodeChunkEmbedding struct {
 type customEmbedder struct {
 	config          config.EmbedderConf
 	embeddingClient EmbeddingClient
+	tokenizer       tokenizer.Tokenizer
 	statusManager   *redis.StatusManager
 	requestId       string
 	totalFiles      int
@@ -36,20 +45,36 @@ type customEmbedder struct {
 
 // NewEmbedder creates a new instance of Embedder
 func NewEmbedder(cfg config.EmbedderConf) (Embedder, error) {
-	embeddingClient := NewEmbeddingClient(cfg)
+	embeddingClient, err := NewEmbeddingClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := tokenizer.Get(cfg.Tokenizer.Type, cfg.Tokenizer.Path)
+	if err != nil {
+		return nil, err
+	}
 
 	return &customEmbedder{
 		embeddingClient: embeddingClient,
+		tokenizer:       tok,
 		config:          cfg,
 	}, nil
 }
 
 // NewEmbedderWithStatusManager creates a new instance of Embedder with status manager
 func NewEmbedderWithStatusManager(cfg config.EmbedderConf, statusManager *redis.StatusManager, requestId string, totalFiles int) (Embedder, error) {
-	embeddingClient := NewEmbeddingClient(cfg)
+	embeddingClient, err := NewEmbeddingClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := tokenizer.Get(cfg.Tokenizer.Type, cfg.Tokenizer.Path)
+	if err != nil {
+		return nil, err
+	}
 
 	return &customEmbedder{
 		embeddingClient: embeddingClient,
+		tokenizer:       tok,
 		config:          cfg,
 		statusManager:   statusManager,
 		requestId:       requestId,
@@ -57,152 +82,320 @@ func NewEmbedderWithStatusManager(cfg config.EmbedderConf, statusManager *redis.
 	}, nil
 }
 
-// EmbedCodeChunks implements the Embedder interface
+// withStatusManager 返回带状态管理器的 embedder；customEmbedder 复用同一个 EmbeddingClient，
+// 使端点池的健康和熔断状态在各任务间共享
+func withStatusManager(embedder Embedder, cfg config.EmbedderConf, statusManager *redis.StatusManager, requestId string, totalFiles int) (Embedder, error) {
+	if e, ok := embedder.(*customEmbedder); ok {
+		return &customEmbedder{
+			embeddingClient: e.embeddingClient,
+			tokenizer:       e.tokenizer,
+			config:          e.config,
+			statusManager:   statusManager,
+			requestId:       requestId,
+			totalFiles:      totalFiles,
+		}, nil
+	}
+	return NewEmbedderWithStatusManager(cfg, statusManager, requestId, totalFiles)
+}
+
+// embeddingBatch 一次嵌入请求包含的分块
+type embeddingBatch struct {
+	chunks []*types.CodeChunk
+	tokens int
+}
+
+// EmbedCodeChunks implements the Embedder interface.
+// 分块按 token 预算打包成批并发请求，失败的批次二分重试以隔离超长或异常的输入；
+// 仍然失败的分块所属文件通过 *EmbeddingFailedError 返回，这些文件的其余分块也不会返回，
+// 避免文件只被部分索引。
 func (e *customEmbedder) EmbedCodeChunks(ctx context.Context, chunks []*types.CodeChunk) ([]*CodeChunkEmbedding, error) {
 	if len(chunks) == 0 {
 		return []*CodeChunkEmbedding{}, nil
 	}
 
-	embeds := make([]*CodeChunkEmbedding, 0, len(chunks))
-	batchSize := e.config.BatchSize
 	start := time.Now()
-	tracer.WithTrace(ctx).Infof("start to embedding %d chunks for codebase:%s, batchSize: %d, requestId:%s", len(chunks), chunks[0].CodebasePath, batchSize, e.requestId)
-
-	// 用于跟踪已处理的文件数量
-	processedFiles := 0
-	// 用于跟踪已处理的文件路径，避免重复计数
-	processedFilePaths := make(map[string]bool)
-	// 用于跟踪当前批次的文件，每10个文件更新一批
-	currentBatchFiles := make([]string, 0, 10)
-
-	for start := 0; start < len(chunks); start += batchSize {
-		end := start + batchSize
-		if end > len(chunks) {
-			end = len(chunks)
+	batches := e.packBatches(chunks)
+	concurrency := e.config.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	tracer.WithTrace(ctx).Infof("start to embedding %d chunks in %d batches for codebase:%s, batchSize: %d, maxBatchTokens: %d, concurrency: %d, requestId:%s",
+		len(chunks), len(batches), chunks[0].CodebasePath, e.config.BatchSize, e.config.MaxBatchTokens, concurrency, e.requestId)
+
+	progress := newEmbeddingProgress(e, chunks)
+	var (
+		mu          sync.Mutex
+		wg          sync.WaitGroup
+		sem         = make(chan struct{}, concurrency)
+		embeds      = make([]*CodeChunkEmbedding, 0, len(chunks))
+		failedFiles = make(map[string]error)
+	)
+	for _, batch := range batches {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			for _, c := range batch.chunks {
+				if _, ok := failedFiles[c.FilePath]; !ok {
+					failedFiles[c.FilePath] = ctx.Err()
+				}
+			}
+			mu.Unlock()
+			continue
 		}
-
-		// 准备当前批次的内容
-		batch := make([][]byte, end-start)
-		for i := 0; i < end-start; i++ {
-			batch[i] = chunks[start+i].Content
-			filePath := chunks[start+i].FilePath
-			if !processedFilePaths[filePath] {
-				tracer.WithTrace(ctx).Infof("execute to %s embedding lens: %d, requestid %s", chunks[start+i].FilePath, len(batch[i]), e.requestId)
+		wg.Add(1)
+		go func(batch embeddingBatch) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			succeeded, failed := e.embedWithBisection(ctx, batch.chunks)
+
+			mu.Lock()
+			embeds = append(embeds, succeeded...)
+			for filePath, err := range failed {
+				if _, ok := failedFiles[filePath]; !ok {
+					failedFiles[filePath] = err
+				}
 			}
+			mu.Unlock()
+			progress.done(ctx, batch.chunks, failed)
+		}(batch)
+	}
+	wg.Wait()
 
+	// 按输入顺序返回，并剔除失败文件的全部分块
+	order := make(map[*types.CodeChunk]int, len(chunks))
+	for i, c := range chunks {
+		order[c] = i
+	}
+	result := make([]*CodeChunkEmbedding, 0, len(embeds))
+	for _, em := range embeds {
+		if _, failed := failedFiles[em.FilePath]; !failed {
+			result = append(result, em)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return order[result[i].CodeChunk] < order[result[j].CodeChunk]
+	})
+
+	progress.finish(ctx)
+
+	if len(failedFiles) > 0 {
+		for filePath, err := range failedFiles {
+			tracer.WithTrace(ctx).Errorf("embedding file %s failed, requestId %s, err: %v", filePath, e.requestId, err)
+		}
+		tracer.WithTrace(ctx).Errorf("embedding %d chunks for codebase:%s finished with %d failed files, cost %d ms", len(chunks),
+			chunks[0].CodebasePath, len(failedFiles), time.Since(start).Milliseconds())
+		return result, &EmbeddingFailedError{FailedFiles: failedFiles}
+	}
+
+	tracer.WithTrace(ctx).Infof("embedding %d chunks for codebase:%s successfully, cost %d ms", len(chunks),
+		chunks[0].CodebasePath, time.Since(start).Milliseconds())
+
+	return result, nil
+}
+
+// packBatches 按 BatchSize 和 MaxBatchTokens 顺序打包，单个超出预算的分块独占一批
+func (e *customEmbedder) packBatches(chunks []*types.CodeChunk) []embeddingBatch {
+	batchSize := e.config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	maxTokens := e.config.MaxBatchTokens
+
+	var (
+		batches []embeddingBatch
+		current embeddingBatch
+	)
+	for _, c := range chunks {
+		tokens := e.chunkTokens(c)
+		full := len(current.chunks) >= batchSize ||
+			(maxTokens > 0 && len(current.chunks) > 0 && current.tokens+tokens > maxTokens)
+		if full {
+			batches = append(batches, current)
+			current = embeddingBatch{}
 		}
+		current.chunks = append(current.chunks, c)
+		current.tokens += tokens
+	}
+	if len(current.chunks) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
 
-		// 执行嵌入
-		startTime := time.Now()
-		embeddings, err := e.doEmbeddings(ctx, batch)
-		duration := time.Since(startTime)
-		tracer.WithTrace(ctx).Infof("doEmbeddings execution time: %v", duration)
-		if err != nil {
-			tracer.WithTrace(ctx).Errorf("e.doEmbeddings(ctx, batch) filed: %v ", err)
-			break
+// chunkTokens 优先使用切分时统计的 token 数；有上下文头或缺少统计时用模型的分词器计算嵌入文本，
+// 没有分词器时按 4 字节/token 估算
+func (e *customEmbedder) chunkTokens(c *types.CodeChunk) int {
+	if c.EmbeddingHeader == "" && c.TokenCount > 0 {
+		return c.TokenCount
+	}
+	if e.tokenizer != nil {
+		if tokens, err := e.tokenizer.Count(string(embeddingText(c))); err == nil {
+			return tokens
 		}
+	}
+	tokens := len(c.Content)/4 + 1
+	if c.TokenCount > 0 {
+		tokens = c.TokenCount
+	}
+	if c.EmbeddingHeader != "" {
+		tokens += len(c.EmbeddingHeader)/4 + 1
+	}
+	return tokens
+}
 
-		// 将嵌入结果与原始块关联
+// embeddingText 返回用于生成向量的文本，上下文头只参与嵌入，不改变存储的分块内容
+func embeddingText(c *types.CodeChunk) []byte {
+	if c.EmbeddingHeader == "" {
+		return c.Content
+	}
+	text := make([]byte, 0, len(c.EmbeddingHeader)+1+len(c.Content))
+	text = append(text, c.EmbeddingHeader...)
+	text = append(text, '\n')
+	return append(text, c.Content...)
+}
+
+// embedWithBisection 嵌入一批分块，失败时对半拆分重试，直到定位到单个失败的分块
+func (e *customEmbedder) embedWithBisection(ctx context.Context, chunks []*types.CodeChunk) ([]*CodeChunkEmbedding, map[string]error) {
+	batch := make([][]byte, len(chunks))
+	for i, c := range chunks {
+		batch[i] = embeddingText(c)
+	}
+
+	startTime := time.Now()
+	embeddings, err := e.doEmbeddings(ctx, batch)
+	tracer.WithTrace(ctx).Debugf("doEmbeddings %d chunks execution time: %v", len(chunks), time.Since(startTime))
+	if err == nil && len(embeddings) != len(chunks) {
+		err = fmt.Errorf("%w: expected %d embeddings, got %d", ErrInvalidResponse, len(chunks), len(embeddings))
+	}
+	if err == nil {
+		embeds := make([]*CodeChunkEmbedding, len(chunks))
 		for i, em := range embeddings {
-			embeds = append(embeds, &CodeChunkEmbedding{
-				CodeChunk: chunks[start+i],
-				Embedding: em,
-			})
-			filePath := chunks[start+i].FilePath
-			if !processedFilePaths[filePath] {
-				processedFilePaths[filePath] = true
-				processedFiles++
-				// 将文件添加到当前批次
-				currentBatchFiles = append(currentBatchFiles, filePath)
-
-				// 每处理10个文件就同步一次进度，并将这批文件状态改为completed
-				// 或者处理完所有文件时，如果当前批次有文件也要更新（处理最后一批不满10个的情况）
-				shouldUpdate := (processedFiles%10 == 0) || (processedFiles == len(processedFilePaths) && len(currentBatchFiles) > 0)
-
-				if shouldUpdate && e.statusManager != nil && e.requestId != "" {
-					// 使用总文件数计算进度，如果总文件数为0则使用已处理的文件路径数量作为分母
-					var denominator int
-					if e.totalFiles > 0 {
-						denominator = e.totalFiles
-					} else {
-						denominator = len(processedFilePaths)
-					}
-					progress := int(float64(processedFiles) / float64(denominator) * 100)
-
-					// 复制当前批次文件作为要更新的文件列表
-					completedFiles := make([]string, len(currentBatchFiles))
-					copy(completedFiles, currentBatchFiles)
-
-					err := e.statusManager.UpdateFileStatus(ctx, e.requestId, func(status *types.FileStatusResponseData) {
-						status.Process = "processing"
-						status.TotalProgress = progress
-
-						// tracer.WithTrace(ctx).Infof(" requestid %s  ,finish before %v", e.requestId, status.FileList)
-
-						// 将这批文件的状态添加到FileList中
-						for _, filePath := range completedFiles {
-							// 检查文件是否已在FileList中
-							// found := false
-							for i, item := range status.FileList {
-								if item.Path == filePath {
-									// 更新现有文件状态
-									status.FileList[i].Status = "completed"
-									// found = true
-									break
-								}
-							}
-							// 如果文件不在FileList中，则添加新项
-							// if !found {
-							// 	tracer.WithTrace(ctx).Infof("not found in filePath: %v  ,fileList %v", filePath, status.FileList)
-							// 	status.FileList = append(status.FileList, types.FileStatusItem{
-							// 		Path:    filePath,
-							// 		Status:  "completed",
-							// 		Operate: "add", // 默认操作类型为add
-							// 	})
-							// }
-						}
-
-						// tracer.WithTrace(ctx).Infof(" requestid %s  ,finish %v", e.requestId, status.FileList)
-
-					})
-					if err != nil {
-						tracer.WithTrace(ctx).Errorf("failed to update progress: %v", err)
-					} else {
-						tracer.WithTrace(ctx).Infof("updated progress: %d%% (%d/%d files), marked %d files as completed", progress, processedFiles, len(processedFilePaths), len(completedFiles))
-					}
-
-					// 清空当前批次，为下一批做准备
-					currentBatchFiles = make([]string, 0, 10)
-				}
-			}
+			embeds[i] = &CodeChunkEmbedding{CodeChunk: chunks[i], Embedding: em}
+		}
+		return embeds, nil
+	}
+
+	if len(chunks) == 1 || ctx.Err() != nil {
+		failed := make(map[string]error, len(chunks))
+		for _, c := range chunks {
+			failed[c.FilePath] = err
 		}
+		return nil, failed
 	}
 
-	// 最终更新一次进度
-	if e.statusManager != nil && e.requestId != "" {
-		progress := 100
-		// 计算最终的分母用于日志显示
-		var denominator int
-		if e.totalFiles > 0 {
-			denominator = e.totalFiles
-		} else {
-			denominator = len(processedFilePaths)
+	tracer.WithTrace(ctx).Errorf("embedding batch of %d chunks failed, bisect and retry, err: %v", len(chunks), err)
+	mid := len(chunks) / 2
+	left, leftFailed := e.embedWithBisection(ctx, chunks[:mid])
+	right, rightFailed := e.embedWithBisection(ctx, chunks[mid:])
+	for filePath, err := range rightFailed {
+		if leftFailed == nil {
+			leftFailed = make(map[string]error)
 		}
-		err := e.statusManager.UpdateFileStatus(ctx, e.requestId, func(status *types.FileStatusResponseData) {
-			status.Process = "complete"
-			status.TotalProgress = progress
-		})
-		if err != nil {
-			tracer.WithTrace(ctx).Errorf("failed to update final progress: %v", err)
-		} else {
-			tracer.WithTrace(ctx).Infof("updated final progress: %d%% (%d/%d files)", progress, processedFiles, denominator)
+		if _, ok := leftFailed[filePath]; !ok {
+			leftFailed[filePath] = err
 		}
 	}
+	return append(left, right...), leftFailed
+}
 
-	tracer.WithTrace(ctx).Infof("embedding %d chunks for codebase:%s successfully, cost %d ms", len(chunks),
-		chunks[0].CodebasePath, time.Since(start).Milliseconds())
+// embeddingProgress 跟踪各文件剩余分块数，文件的全部分块嵌入成功后标记为 completed，每 10 个文件同步一次进度
+type embeddingProgress struct {
+	e              *customEmbedder
+	mu             sync.Mutex
+	remaining      map[string]int
+	failed         map[string]bool
+	processedFiles int
+	pendingFiles   []string
+}
+
+func newEmbeddingProgress(e *customEmbedder, chunks []*types.CodeChunk) *embeddingProgress {
+	p := &embeddingProgress{
+		e:         e,
+		remaining: make(map[string]int),
+		failed:    make(map[string]bool),
+	}
+	for _, c := range chunks {
+		p.remaining[c.FilePath]++
+	}
+	return p
+}
 
-	return embeds, nil
+func (p *embeddingProgress) enabled() bool {
+	return p.e.statusManager != nil && p.e.requestId != ""
+}
+
+// done 记录一批分块完成，failed 为该批中失败的文件
+func (p *embeddingProgress) done(ctx context.Context, chunks []*types.CodeChunk, failed map[string]error) {
+	p.mu.Lock()
+	for filePath := range failed {
+		p.failed[filePath] = true
+	}
+	for _, c := range chunks {
+		p.remaining[c.FilePath]--
+		if p.remaining[c.FilePath] == 0 {
+			p.processedFiles++
+			if !p.failed[c.FilePath] {
+				p.pendingFiles = append(p.pendingFiles, c.FilePath)
+			}
+		}
+	}
+	var completedFiles []string
+	if len(p.pendingFiles) >= 10 {
+		completedFiles = p.pendingFiles
+		p.pendingFiles = nil
+	}
+	processed := p.processedFiles
+	p.mu.Unlock()
+
+	if len(completedFiles) > 0 {
+		p.update(ctx, completedFiles, processed, "processing")
+	}
+}
+
+// finish 同步剩余文件状态并将整体进度置为 100%
+func (p *embeddingProgress) finish(ctx context.Context) {
+	p.mu.Lock()
+	completedFiles := p.pendingFiles
+	p.pendingFiles = nil
+	processed := p.processedFiles
+	p.mu.Unlock()
+	p.update(ctx, completedFiles, processed, "complete")
+}
+
+func (p *embeddingProgress) update(ctx context.Context, completedFiles []string, processed int, process string) {
+	if !p.enabled() {
+		return
+	}
+	// 使用总文件数计算进度，如果总文件数为0则使用本次嵌入的文件数作为分母
+	denominator := p.e.totalFiles
+	if denominator <= 0 {
+		denominator = len(p.remaining)
+	}
+	progress := 100
+	if process != "complete" {
+		progress = int(float64(processed) / float64(denominator) * 100)
+	}
+
+	err := p.e.statusManager.UpdateFileStatus(ctx, p.e.requestId, func(status *types.FileStatusResponseData) {
+		status.Process = process
+		status.TotalProgress = progress
+		for _, filePath := range completedFiles {
+			for i, item := range status.FileList {
+				if item.Path == filePath {
+					status.FileList[i].Status = "completed"
+					break
+				}
+			}
+		}
+	})
+	if err != nil {
+		tracer.WithTrace(ctx).Errorf("failed to update progress: %v", err)
+	} else {
+		tracer.WithTrace(ctx).Infof("updated progress: %d%% (%d/%d files), marked %d files as completed", progress, processed, denominator, len(completedFiles))
+	}
 }
 
 // EmbedQuery implements the Embedder interface
@@ -223,6 +416,24 @@ func (e *customEmbedder) EmbedQuery(ctx context.Context, query string) ([]float3
 	return vectors[0], nil
 }
 
+// Dimensions implements the Embedder interface
+func (e *customEmbedder) Dimensions() int {
+	return e.embeddingClient.Dimensions()
+}
+
+// MaxInputTokens implements the Embedder interface
+func (e *customEmbedder) MaxInputTokens() int {
+	return e.embeddingClient.MaxInputTokens()
+}
+
+// EndpointHealth implements the EndpointHealthReporter interface, returns nil if the client has no endpoints
+func (e *customEmbedder) EndpointHealth() []types.EndpointHealth {
+	if reporter, ok := e.embeddingClient.(EndpointHealthReporter); ok {
+		return reporter.EndpointHealth()
+	}
+	return nil
+}
+
 // doEmbeddings performs the actual embedding operation
 func (e *customEmbedder) doEmbeddings(ctx context.Context, textsByte [][]byte) ([][]float32, error) {
 	texts := make([]string, len(textsByte))
@@ -241,7 +452,7 @@ func (e *customEmbedder) doEmbeddings(ctx context.Context, textsByte [][]byte) (
 
 	vectors := make([][]float32, len(textsByte))
 	for i, embedding := range embeddings {
-		transferredVector := make([]float32, 0, 768) // 768维
+		transferredVector := make([]float32, 0, len(embedding))
 		for _, v := range embedding {
 			transferredVector = append(transferredVector, float32(v))
 		}
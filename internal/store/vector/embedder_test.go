@@ -0,0 +1,114 @@
+package vector
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+// poisonEmbeddingClient 输入包含 poison 时整批失败，模拟超长或异常输入
+type poisonEmbeddingClient struct {
+	mu         sync.Mutex
+	batchSizes []int
+}
+
+func (c *poisonEmbeddingClient) CreateEmbeddings(ctx context.Context, texts []string, model string) ([][]float64, error) {
+	c.mu.Lock()
+	c.batchSizes = append(c.batchSizes, len(texts))
+	c.mu.Unlock()
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		if strings.Contains(text, "poison") {
+			return nil, errors.New("input too long")
+		}
+		vectors[i] = []float64{float64(len(text)), 1}
+	}
+	return vectors, nil
+}
+
+func (c *poisonEmbeddingClient) Dimensions() int     { return 2 }
+func (c *poisonEmbeddingClient) MaxInputTokens() int { return 512 }
+
+func newTestChunks(files map[string][]string) []*types.CodeChunk {
+	var chunks []*types.CodeChunk
+	for _, filePath := range []string{"a.go", "b.go", "c.go"} {
+		for _, content := range files[filePath] {
+			chunks = append(chunks, &types.CodeChunk{FilePath: filePath, CodebasePath: "/repo", Content: []byte(content), TokenCount: 10})
+		}
+	}
+	return chunks
+}
+
+func TestEmbedCodeChunksBisection(t *testing.T) {
+	client := &poisonEmbeddingClient{}
+	e := &customEmbedder{
+		config:          config.EmbedderConf{BatchSize: 8, MaxConcurrency: 2},
+		embeddingClient: client,
+	}
+	chunks := newTestChunks(map[string][]string{
+		"a.go": {"a1", "a2", "a3"},
+		"b.go": {"b1", "poison", "b3"},
+		"c.go": {"c1", "c2"},
+	})
+
+	embeds, err := e.EmbedCodeChunks(context.Background(), chunks)
+
+	var failedErr *EmbeddingFailedError
+	require.ErrorAs(t, err, &failedErr)
+	assert.Len(t, failedErr.FailedFiles, 1)
+	assert.Contains(t, failedErr.FailedFiles, "b.go")
+
+	// 失败文件的其余分块不返回，其它文件按输入顺序全部返回
+	var contents []string
+	for _, em := range embeds {
+		contents = append(contents, string(em.Content))
+		assert.Len(t, em.Embedding, 2)
+	}
+	assert.Equal(t, []string{"a1", "a2", "a3", "c1", "c2"}, contents)
+}
+
+func TestEmbedCodeChunksTokenBudget(t *testing.T) {
+	client := &poisonEmbeddingClient{}
+	e := &customEmbedder{
+		config:          config.EmbedderConf{BatchSize: 100, MaxBatchTokens: 25, MaxConcurrency: 1},
+		embeddingClient: client,
+	}
+	chunks := newTestChunks(map[string][]string{"a.go": {"1", "2", "3", "4", "5"}})
+	chunks[2].TokenCount = 100 // 超出预算的分块独占一批
+
+	batches := e.packBatches(chunks)
+	var sizes []int
+	for _, b := range batches {
+		sizes = append(sizes, len(b.chunks))
+	}
+	assert.Equal(t, []int{2, 1, 2}, sizes)
+
+	embeds, err := e.EmbedCodeChunks(context.Background(), chunks)
+	require.NoError(t, err)
+	assert.Len(t, embeds, 5)
+	assert.Equal(t, []int{2, 1, 2}, client.batchSizes)
+}
+
+func TestEmbedCodeChunksWithHeader(t *testing.T) {
+	client := &poisonEmbeddingClient{}
+	e := &customEmbedder{
+		config:          config.EmbedderConf{BatchSize: 8, MaxConcurrency: 1},
+		embeddingClient: client,
+	}
+	chunks := newTestChunks(map[string][]string{"a.go": {"func A() {}"}})
+	chunks[0].EmbeddingHeader = "File: a.go"
+
+	embeds, err := e.EmbedCodeChunks(context.Background(), chunks)
+	require.NoError(t, err)
+	require.Len(t, embeds, 1)
+	// 上下文头参与嵌入，分块内容保持不变
+	assert.Equal(t, float32(len("File: a.go\nfunc A() {}")), embeds[0].Embedding[0])
+	assert.Equal(t, "func A() {}", string(embeds[0].Content))
+}
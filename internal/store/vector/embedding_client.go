@@ -4,107 +4,265 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
 )
 
-// embeddingRequest represents the request body for the embedding API
-type embeddingRequest struct {
-	Input          []string `json:"input"`           // The text to embed
-	Model          string   `json:"model"`           // The model to use for embedding
-	EncodingFormat string   `json:"encoding_format"` // The format of the output embeddings
-}
+const (
+	EmbeddingProviderOpenAI = "openai" // OpenAI 兼容接口
+	EmbeddingProviderOllama = "ollama" // Ollama /api/embed
+	EmbeddingProviderTEI    = "tei"    // HuggingFace Text Embeddings Inference /embed
+	EmbeddingProviderHash   = "hash"   // 本地确定性哈希嵌入，用于测试
 
-// embeddingResponse represents the response body from the embedding API
-type embeddingResponse struct {
-	Object string `json:"object"`
-	Data   []struct {
-		Object    string    `json:"object"`
-		Embedding []float64 `json:"embedding"`
-		Index     int       `json:"index"`
-	} `json:"data"`
-	Model string `json:"model"`
-	Usage struct {
-		PromptTokens int `json:"prompt_tokens"`
-		TotalTokens  int `json:"total_tokens"`
-	} `json:"usage"`
-}
+	defaultMaxInputTokens = 512
+)
 
 // EmbeddingClient defines the interface for embedding service clients
 type EmbeddingClient interface {
 	// CreateEmbeddings creates embeddings for the given texts using the specified model
 	CreateEmbeddings(ctx context.Context, texts []string, model string) ([][]float64, error)
+	// Dimensions returns the dimensions of the embedding vectors, 0 means unknown
+	Dimensions() int
+	// MaxInputTokens returns the max number of tokens accepted for a single input
+	MaxInputTokens() int
+}
+
+// EndpointHealthReporter 由基于端点池的客户端实现，用于就绪检查
+type EndpointHealthReporter interface {
+	EndpointHealth() []types.EndpointHealth
 }
 
-// httpEmbeddingClient implements the EmbeddingClient interface using HTTP requests
-type httpEmbeddingClient struct {
+// EmbeddingClientFactory 根据配置创建 EmbeddingClient
+type EmbeddingClientFactory func(cfg config.EmbedderConf) (EmbeddingClient, error)
+
+var (
+	embeddingProvidersMu sync.RWMutex
+	embeddingProviders   = map[string]EmbeddingClientFactory{
+		EmbeddingProviderOpenAI: newOpenAIEmbeddingClient,
+		EmbeddingProviderOllama: newOllamaEmbeddingClient,
+		EmbeddingProviderTEI:    newTEIEmbeddingClient,
+		EmbeddingProviderHash:   newHashEmbeddingClient,
+	}
+)
+
+// RegisterEmbeddingProvider 注册嵌入服务提供方，同名提供方会被覆盖
+func RegisterEmbeddingProvider(name string, factory EmbeddingClientFactory) {
+	embeddingProvidersMu.Lock()
+	defer embeddingProvidersMu.Unlock()
+	embeddingProviders[strings.ToLower(name)] = factory
+}
+
+// EmbeddingProviders 返回已注册的提供方名称
+func EmbeddingProviders() []string {
+	embeddingProvidersMu.RLock()
+	defer embeddingProvidersMu.RUnlock()
+	names := make([]string, 0, len(embeddingProviders))
+	for name := range embeddingProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewEmbeddingClient creates a new instance of EmbeddingClient for the configured provider
+func NewEmbeddingClient(cfg config.EmbedderConf) (EmbeddingClient, error) {
+	provider := strings.ToLower(cfg.Provider)
+	if provider == "" {
+		provider = EmbeddingProviderOpenAI
+	}
+	embeddingProvidersMu.RLock()
+	factory, ok := embeddingProviders[provider]
+	embeddingProvidersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown embedding provider %q, available: %v", cfg.Provider, EmbeddingProviders())
+	}
+	return factory(cfg)
+}
+
+// modelSpec 模型默认的向量维度和最大输入 token 数
+type modelSpec struct {
+	dimensions     int
+	maxInputTokens int
+}
+
+// resolveModelSpec 配置优先，其次按模型名称查找提供方的默认值
+func resolveModelSpec(cfg config.EmbedderConf, known map[string]modelSpec, fallback modelSpec) modelSpec {
+	spec, ok := known[strings.ToLower(cfg.Model)]
+	if !ok {
+		spec = fallback
+	}
+	if cfg.Dimensions > 0 {
+		spec.dimensions = cfg.Dimensions
+	}
+	if cfg.MaxInputTokens > 0 {
+		spec.maxInputTokens = cfg.MaxInputTokens
+	}
+	if spec.maxInputTokens <= 0 {
+		spec.maxInputTokens = defaultMaxInputTokens
+	}
+	return spec
+}
+
+// httpEmbeddingTransport 各 HTTP 提供方共用的请求发送、负载均衡和故障切换逻辑
+type httpEmbeddingTransport struct {
 	config     config.EmbedderConf
 	httpClient *http.Client
+	pool       *endpointPool
 }
 
-// NewEmbeddingClient creates a new instance of EmbeddingClient
-func NewEmbeddingClient(cfg config.EmbedderConf) EmbeddingClient {
-	return &httpEmbeddingClient{
+func newHttpEmbeddingTransport(cfg config.EmbedderConf, defaultPath string) (*httpEmbeddingTransport, error) {
+	pool, err := newEndpointPool(cfg, defaultPath)
+	if err != nil {
+		return nil, err
+	}
+	return &httpEmbeddingTransport{
 		config: cfg,
 		httpClient: &http.Client{
 			Timeout: cfg.Timeout,
 		},
-	}
+		pool: pool,
+	}, nil
 }
 
-// CreateEmbeddings implements the EmbeddingClient interface
-func (c *httpEmbeddingClient) CreateEmbeddings(ctx context.Context, texts []string, model string) ([][]float64, error) {
-	requestBody := &embeddingRequest{
-		Input:          texts,
-		Model:          model,
-		EncodingFormat: "float",
+// resolveEndpoint APIBase 只包含地址（无路径）时补全提供方的默认路径，否则原样使用
+func resolveEndpoint(apiBase, defaultPath string) (string, error) {
+	if apiBase == "" {
+		return "", fmt.Errorf("embedding APIBase is required")
 	}
-
-	jsonBody, err := json.Marshal(requestBody)
+	u, err := url.Parse(apiBase)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal embedding request body: %w", err)
+		return "", fmt.Errorf("invalid embedding APIBase %s: %w", apiBase, err)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = defaultPath
 	}
+	return u.String(), nil
+}
+
+// httpStatusError 服务端返回的非 200 响应
+type httpStatusError struct {
+	statusCode int
+	status     string
+	body       string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("embedding API returned non-OK status %d: %s, body: %s", e.statusCode, e.status, e.body)
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.APIBase, bytes.NewBuffer(jsonBody))
+// retryable 限流和服务端错误可以切换到其它端点重试，其余 4xx 为请求本身的问题
+func (e *httpStatusError) retryable() bool {
+	return e.statusCode == http.StatusTooManyRequests || e.statusCode >= http.StatusInternalServerError
+}
+
+// postJSON 发送 JSON 请求并解析响应；网络错误、限流和服务端错误时切换端点重试，最多 MaxRetries 次
+func (t *httpEmbeddingTransport) postJSON(ctx context.Context, body any, out any, bearerAuth bool) error {
+	jsonBody, err := json.Marshal(body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create embedding request: %w", err)
+		return fmt.Errorf("failed to marshal embedding request body: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	var (
+		lastErr error
+		tried   = make(map[*endpoint]bool)
+	)
+	for attempt := 0; attempt <= t.config.MaxRetries; attempt++ {
+		if attempt > 0 && len(tried) >= len(t.pool.endpoints) {
+			// 所有端点都已失败过，退避后再重试
+			select {
+			case <-time.After(time.Second * time.Duration(attempt)):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			tried = make(map[*endpoint]bool)
+		}
 
-	var attempt int
-	var resp *http.Response
-	for attempt = 0; attempt <= c.config.MaxRetries; attempt++ {
-		resp, err = c.httpClient.Do(req)
+		ep, err := t.pool.acquire(ctx, tried)
+		if err != nil {
+			if errors.Is(err, ErrNoAvailableEndpoint) && attempt < t.config.MaxRetries {
+				lastErr = err
+				tried = make(map[*endpoint]bool, len(t.pool.endpoints))
+				for _, e := range t.pool.endpoints {
+					tried[e] = true
+				}
+				continue
+			}
+			return fmt.Errorf("failed to acquire embedding endpoint: %w", err)
+		}
+		tried[ep] = true
+
+		err = t.send(ctx, ep, jsonBody, out, bearerAuth)
 		if err == nil {
-			break
+			t.pool.release(ep, nil)
+			return nil
 		}
-		if attempt < c.config.MaxRetries {
-			time.Sleep(time.Second * time.Duration(attempt+1))
-			continue
+		var statusErr *httpStatusError
+		if ctx.Err() != nil || (errors.As(err, &statusErr) && !statusErr.retryable()) {
+			// 调用方取消或请求本身错误，不计入端点失败
+			t.pool.release(ep, nil)
+			return err
 		}
-		return nil, fmt.Errorf("failed to send embedding request after %d retries: %w", attempt, err)
+		t.pool.release(ep, err)
+		lastErr = err
+	}
+	return fmt.Errorf("failed to send embedding request after %d retries: %w", t.config.MaxRetries, lastErr)
+}
+
+// send 向指定端点发送一次请求
+func (t *httpEmbeddingTransport) send(ctx context.Context, ep *endpoint, jsonBody []byte, out any, bearerAuth bool) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create embedding request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if bearerAuth && ep.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+ep.apiKey)
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send embedding request to %s: %w", ep.url, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		errorBody := new(bytes.Buffer)
-		_, _ = errorBody.ReadFrom(resp.Body)
-		return nil, fmt.Errorf("embedding API returned non-OK status %d: %s, body: %s", resp.StatusCode, resp.Status, errorBody.String())
+		errorBody, _ := io.ReadAll(resp.Body)
+		return &httpStatusError{statusCode: resp.StatusCode, status: resp.Status, body: string(errorBody)}
 	}
 
-	var responseBody embeddingResponse
-	if err := json.NewDecoder(resp.Body).Decode(&responseBody); err != nil {
-		return nil, fmt.Errorf("failed to decode embedding response body: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode embedding response body: %w", err)
 	}
+	return nil
+}
+
+// EndpointHealth 返回各端点的健康状态
+func (t *httpEmbeddingTransport) EndpointHealth() []types.EndpointHealth {
+	return t.pool.health()
+}
 
-	vectors := make([][]float64, len(texts))
-	for _, d := range responseBody.Data {
-		vectors[d.Index] = d.Embedding
+// checkEmbeddings 校验返回的向量数量和维度
+func checkEmbeddings(vectors [][]float64, count int, dimensions int) error {
+	if len(vectors) != count {
+		return fmt.Errorf("%w: expected %d embeddings, got %d", ErrInvalidResponse, count, len(vectors))
+	}
+	for i, v := range vectors {
+		if len(v) == 0 {
+			return fmt.Errorf("%w: embedding %d is empty", ErrInvalidResponse, i)
+		}
+		if dimensions > 0 && len(v) != dimensions {
+			return fmt.Errorf("%w: embedding %d has %d dimensions, expected %d", ErrInvalidResponse, i, len(v), dimensions)
+		}
 	}
-	return vectors, nil
+	return nil
 }
@@ -0,0 +1,137 @@
+package vector
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+)
+
+func fakeVector(text string, dims int) []float64 {
+	v := make([]float64, dims)
+	v[len(text)%dims] = 1
+	return v
+}
+
+func TestOpenAIEmbeddingClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/embeddings", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		var req embeddingRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "text-embedding-3-small", req.Model)
+
+		var resp embeddingResponse
+		// 逆序返回，客户端需按 index 还原顺序
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			resp.Data = append(resp.Data, struct {
+				Object    string    `json:"object"`
+				Embedding []float64 `json:"embedding"`
+				Index     int       `json:"index"`
+			}{Object: "embedding", Embedding: fakeVector(req.Input[i], 1536), Index: i})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client, err := NewEmbeddingClient(config.EmbedderConf{Provider: "openai", APIBase: server.URL, APIKey: "test-key", Model: "text-embedding-3-small"})
+	require.NoError(t, err)
+	assert.Equal(t, 1536, client.Dimensions())
+	assert.Equal(t, 8191, client.MaxInputTokens())
+
+	vectors, err := client.CreateEmbeddings(context.Background(), []string{"a", "bb"}, "text-embedding-3-small")
+	require.NoError(t, err)
+	assert.Equal(t, fakeVector("a", 1536), vectors[0])
+	assert.Equal(t, fakeVector("bb", 1536), vectors[1])
+}
+
+func TestOllamaEmbeddingClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/embed", r.URL.Path)
+		assert.Empty(t, r.Header.Get("Authorization"))
+		var req ollamaEmbedRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		resp := ollamaEmbedResponse{Model: req.Model}
+		for _, input := range req.Input {
+			resp.Embeddings = append(resp.Embeddings, fakeVector(input, 768))
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client, err := NewEmbeddingClient(config.EmbedderConf{Provider: "ollama", APIBase: server.URL, Model: "nomic-embed-text"})
+	require.NoError(t, err)
+	assert.Equal(t, 768, client.Dimensions())
+	assert.Equal(t, 2048, client.MaxInputTokens())
+
+	vectors, err := client.CreateEmbeddings(context.Background(), []string{"x", "yy", "zzz"}, "nomic-embed-text")
+	require.NoError(t, err)
+	assert.Len(t, vectors, 3)
+}
+
+func TestTEIEmbeddingClient(t *testing.T) {
+	dims := 384
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/embed", r.URL.Path)
+		var req teiEmbedRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.True(t, req.Truncate)
+		vectors := make([][]float64, 0, len(req.Inputs))
+		for _, input := range req.Inputs {
+			vectors = append(vectors, fakeVector(input, dims))
+		}
+		_ = json.NewEncoder(w).Encode(vectors)
+	}))
+	defer server.Close()
+
+	client, err := NewEmbeddingClient(config.EmbedderConf{Provider: "tei", APIBase: server.URL, Model: "BAAI/bge-small-en-v1.5", MaxInputTokens: 256})
+	require.NoError(t, err)
+	assert.Equal(t, 384, client.Dimensions())
+	assert.Equal(t, 256, client.MaxInputTokens())
+
+	vectors, err := client.CreateEmbeddings(context.Background(), []string{"hello"}, "")
+	require.NoError(t, err)
+	assert.Len(t, vectors[0], 384)
+
+	// 服务端返回的维度与声明不一致时报错
+	dims = 768
+	_, err = client.CreateEmbeddings(context.Background(), []string{"hello"}, "")
+	assert.ErrorIs(t, err, ErrInvalidResponse)
+}
+
+func TestHashEmbeddingClient(t *testing.T) {
+	client, err := NewEmbeddingClient(config.EmbedderConf{Provider: "hash", Dimensions: 64})
+	require.NoError(t, err)
+	assert.Equal(t, 64, client.Dimensions())
+
+	texts := []string{"func getUserID() int", "func getUserID() int", "SELECT * FROM orders"}
+	vectors, err := client.CreateEmbeddings(context.Background(), texts, "")
+	require.NoError(t, err)
+	assert.Equal(t, vectors[0], vectors[1])
+	assert.NotEqual(t, vectors[0], vectors[2])
+
+	var norm float64
+	for _, v := range vectors[0] {
+		norm += v * v
+	}
+	assert.InDelta(t, 1, math.Sqrt(norm), 1e-9)
+
+	assert.Equal(t, []string{"getuserid", "get", "user", "id"}, hashTokens("getUserID"))
+	assert.Equal(t, []string{"httpserver_v2", "http", "server", "v2"}, hashTokens("HTTPServer_v2"))
+}
+
+func TestNewEmbeddingClientUnknownProvider(t *testing.T) {
+	_, err := NewEmbeddingClient(config.EmbedderConf{Provider: "unknown"})
+	assert.Error(t, err)
+
+	RegisterEmbeddingProvider("custom", newHashEmbeddingClient)
+	client, err := NewEmbeddingClient(config.EmbedderConf{Provider: "custom"})
+	require.NoError(t, err)
+	assert.Equal(t, defaultHashDimensions, client.Dimensions())
+}
@@ -0,0 +1,94 @@
+package vector
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/preprocess"
+)
+
+const (
+	defaultHashDimensions     = 256
+	defaultHashMaxInputTokens = 8192
+)
+
+// hashEmbeddingClient 本地确定性哈希嵌入：对标识符拆分后的词做特征哈希并归一化，
+// 不依赖外部服务，相同输入始终得到相同向量，用于测试和离线环境
+type hashEmbeddingClient struct {
+	spec modelSpec
+}
+
+func newHashEmbeddingClient(cfg config.EmbedderConf) (EmbeddingClient, error) {
+	spec := resolveModelSpec(cfg, nil, modelSpec{dimensions: defaultHashDimensions, maxInputTokens: defaultHashMaxInputTokens})
+	return &hashEmbeddingClient{spec: spec}, nil
+}
+
+// CreateEmbeddings implements the EmbeddingClient interface
+func (c *hashEmbeddingClient) CreateEmbeddings(ctx context.Context, texts []string, model string) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		vectors[i] = c.embed(text)
+	}
+	return vectors, nil
+}
+
+func (c *hashEmbeddingClient) embed(text string) []float64 {
+	vec := make([]float64, c.spec.dimensions)
+	tokens := hashTokens(text)
+	for _, token := range tokens {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(token))
+		sum := h.Sum64()
+		// 低位决定维度，最高位决定符号，减少哈希冲突带来的偏差
+		idx := int(sum % uint64(len(vec)))
+		if sum>>63 == 1 {
+			vec[idx]--
+		} else {
+			vec[idx]++
+		}
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += v * v
+	}
+	if norm == 0 {
+		return vec
+	}
+	norm = math.Sqrt(norm)
+	for i := range vec {
+		vec[i] /= norm
+	}
+	return vec
+}
+
+// hashTokens 按非字母数字字符分词，并将驼峰和下划线标识符拆成小写单词，同时保留完整标识符
+func hashTokens(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	tokens := make([]string, 0, len(fields)*2)
+	for _, field := range fields {
+		tokens = append(tokens, strings.ToLower(field))
+		parts := preprocess.SplitIdentifier(field)
+		if len(parts) > 1 {
+			tokens = append(tokens, parts...)
+		}
+	}
+	return tokens
+}
+
+func (c *hashEmbeddingClient) Dimensions() int {
+	return c.spec.dimensions
+}
+
+func (c *hashEmbeddingClient) MaxInputTokens() int {
+	return c.spec.maxInputTokens
+}
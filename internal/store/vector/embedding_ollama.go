@@ -0,0 +1,74 @@
+package vector
+
+import (
+	"context"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/usage"
+)
+
+// Ollama 常见嵌入模型的默认规格
+var ollamaModelSpecs = map[string]modelSpec{
+	"nomic-embed-text":       {dimensions: 768, maxInputTokens: 2048},
+	"mxbai-embed-large":      {dimensions: 1024, maxInputTokens: 512},
+	"all-minilm":             {dimensions: 384, maxInputTokens: 256},
+	"snowflake-arctic-embed": {dimensions: 1024, maxInputTokens: 512},
+	"bge-m3":                 {dimensions: 1024, maxInputTokens: 8192},
+}
+
+// ollamaEmbedRequest Ollama /api/embed 请求体
+type ollamaEmbedRequest struct {
+	Model    string   `json:"model"`
+	Input    []string `json:"input"`
+	Truncate bool     `json:"truncate"`
+}
+
+// ollamaEmbedResponse Ollama /api/embed 响应体
+type ollamaEmbedResponse struct {
+	Model           string      `json:"model"`
+	Embeddings      [][]float64 `json:"embeddings"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+}
+
+// ollamaEmbeddingClient implements the EmbeddingClient interface for Ollama
+type ollamaEmbeddingClient struct {
+	*httpEmbeddingTransport
+	spec modelSpec
+}
+
+func newOllamaEmbeddingClient(cfg config.EmbedderConf) (EmbeddingClient, error) {
+	transport, err := newHttpEmbeddingTransport(cfg, "/api/embed")
+	if err != nil {
+		return nil, err
+	}
+	return &ollamaEmbeddingClient{
+		httpEmbeddingTransport: transport,
+		spec:                   resolveModelSpec(cfg, ollamaModelSpecs, modelSpec{}),
+	}, nil
+}
+
+// CreateEmbeddings implements the EmbeddingClient interface
+func (c *ollamaEmbeddingClient) CreateEmbeddings(ctx context.Context, texts []string, model string) ([][]float64, error) {
+	var responseBody ollamaEmbedResponse
+	if err := c.postJSON(ctx, &ollamaEmbedRequest{Model: model, Input: texts, Truncate: true}, &responseBody, false); err != nil {
+		return nil, err
+	}
+	if err := checkEmbeddings(responseBody.Embeddings, len(texts), c.spec.dimensions); err != nil {
+		return nil, err
+	}
+	if responseBody.PromptEvalCount > 0 {
+		usage.Report(ctx, usage.TypeEmbedding, model, responseBody.PromptEvalCount, responseBody.PromptEvalCount, false)
+	} else {
+		tokens := usage.EstimateTokens(texts...)
+		usage.Report(ctx, usage.TypeEmbedding, model, tokens, tokens, true)
+	}
+	return responseBody.Embeddings, nil
+}
+
+func (c *ollamaEmbeddingClient) Dimensions() int {
+	return c.spec.dimensions
+}
+
+func (c *ollamaEmbeddingClient) MaxInputTokens() int {
+	return c.spec.maxInputTokens
+}
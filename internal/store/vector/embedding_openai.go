@@ -0,0 +1,101 @@
+package vector
+
+import (
+	"context"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/usage"
+)
+
+// OpenAI 兼容接口常见模型的默认规格
+var openAIModelSpecs = map[string]modelSpec{
+	"text-embedding-ada-002": {dimensions: 1536, maxInputTokens: 8191},
+	"text-embedding-3-small": {dimensions: 1536, maxInputTokens: 8191},
+	"text-embedding-3-large": {dimensions: 3072, maxInputTokens: 8191},
+	"gte-modernbert-base":    {dimensions: 768, maxInputTokens: 8192},
+}
+
+// embeddingRequest represents the request body for the embedding API
+type embeddingRequest struct {
+	Input          []string `json:"input"`           // The text to embed
+	Model          string   `json:"model"`           // The model to use for embedding
+	EncodingFormat string   `json:"encoding_format"` // The format of the output embeddings
+	Dimensions     int      `json:"dimensions,omitempty"`
+}
+
+// embeddingResponse represents the response body from the embedding API
+type embeddingResponse struct {
+	Object string `json:"object"`
+	Data   []struct {
+		Object    string    `json:"object"`
+		Embedding []float64 `json:"embedding"`
+		Index     int       `json:"index"`
+	} `json:"data"`
+	Model string `json:"model"`
+	Usage struct {
+		PromptTokens int `json:"prompt_tokens"`
+		TotalTokens  int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// openAIEmbeddingClient implements the EmbeddingClient interface for OpenAI compatible APIs
+type openAIEmbeddingClient struct {
+	*httpEmbeddingTransport
+	spec modelSpec
+	// 仅在显式配置维度时传给服务端（text-embedding-3 系列支持降维）
+	requestDimensions int
+}
+
+func newOpenAIEmbeddingClient(cfg config.EmbedderConf) (EmbeddingClient, error) {
+	transport, err := newHttpEmbeddingTransport(cfg, "/v1/embeddings")
+	if err != nil {
+		return nil, err
+	}
+	return &openAIEmbeddingClient{
+		httpEmbeddingTransport: transport,
+		spec:                   resolveModelSpec(cfg, openAIModelSpecs, modelSpec{}),
+		requestDimensions:      cfg.Dimensions,
+	}, nil
+}
+
+// CreateEmbeddings implements the EmbeddingClient interface
+func (c *openAIEmbeddingClient) CreateEmbeddings(ctx context.Context, texts []string, model string) ([][]float64, error) {
+	requestBody := &embeddingRequest{
+		Input:          texts,
+		Model:          model,
+		EncodingFormat: "float",
+		Dimensions:     c.requestDimensions,
+	}
+
+	var responseBody embeddingResponse
+	if err := c.postJSON(ctx, requestBody, &responseBody, true); err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float64, len(texts))
+	for _, d := range responseBody.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, ErrInvalidResponse
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	if err := checkEmbeddings(vectors, len(texts), c.spec.dimensions); err != nil {
+		return nil, err
+	}
+	// 部分兼容服务不返回 usage，按输入长度估算
+	if responseBody.Usage.TotalTokens > 0 {
+		usage.Report(ctx, usage.TypeEmbedding, model, responseBody.Usage.PromptTokens, responseBody.Usage.TotalTokens, false)
+	} else {
+		tokens := usage.EstimateTokens(texts...)
+		usage.Report(ctx, usage.TypeEmbedding, model, tokens, tokens, true)
+	}
+	return vectors, nil
+}
+
+func (c *openAIEmbeddingClient) Dimensions() int {
+	return c.spec.dimensions
+}
+
+func (c *openAIEmbeddingClient) MaxInputTokens() int {
+	return c.spec.maxInputTokens
+}
@@ -0,0 +1,67 @@
+package vector
+
+import (
+	"context"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/usage"
+)
+
+// TEI 常见部署模型的默认规格，模型由服务端启动参数决定，请求中的模型名称仅用于查找规格
+var teiModelSpecs = map[string]modelSpec{
+	"baai/bge-small-en-v1.5":              {dimensions: 384, maxInputTokens: 512},
+	"baai/bge-base-en-v1.5":               {dimensions: 768, maxInputTokens: 512},
+	"baai/bge-large-en-v1.5":              {dimensions: 1024, maxInputTokens: 512},
+	"baai/bge-m3":                         {dimensions: 1024, maxInputTokens: 8192},
+	"jinaai/jina-embeddings-v2-base-code": {dimensions: 768, maxInputTokens: 8192},
+	"alibaba-nlp/gte-modernbert-base":     {dimensions: 768, maxInputTokens: 8192},
+	"nomic-ai/nomic-embed-text-v1.5":      {dimensions: 768, maxInputTokens: 8192},
+}
+
+// teiEmbedRequest TEI /embed 请求体
+type teiEmbedRequest struct {
+	Inputs    []string `json:"inputs"`
+	Truncate  bool     `json:"truncate"`
+	Normalize bool     `json:"normalize"`
+}
+
+// teiEmbeddingClient implements the EmbeddingClient interface for HuggingFace Text Embeddings Inference
+type teiEmbeddingClient struct {
+	*httpEmbeddingTransport
+	spec modelSpec
+}
+
+func newTEIEmbeddingClient(cfg config.EmbedderConf) (EmbeddingClient, error) {
+	transport, err := newHttpEmbeddingTransport(cfg, "/embed")
+	if err != nil {
+		return nil, err
+	}
+	return &teiEmbeddingClient{
+		httpEmbeddingTransport: transport,
+		spec:                   resolveModelSpec(cfg, teiModelSpecs, modelSpec{}),
+	}, nil
+}
+
+// CreateEmbeddings implements the EmbeddingClient interface
+func (c *teiEmbeddingClient) CreateEmbeddings(ctx context.Context, texts []string, model string) ([][]float64, error) {
+	var vectors [][]float64
+	// TEI 开启 API Key 时同样使用 Bearer 认证
+	if err := c.postJSON(ctx, &teiEmbedRequest{Inputs: texts, Truncate: true, Normalize: true}, &vectors, true); err != nil {
+		return nil, err
+	}
+	if err := checkEmbeddings(vectors, len(texts), c.spec.dimensions); err != nil {
+		return nil, err
+	}
+	// TEI 响应不包含用量，按输入长度估算
+	tokens := usage.EstimateTokens(texts...)
+	usage.Report(ctx, usage.TypeEmbedding, model, tokens, tokens, true)
+	return vectors, nil
+}
+
+func (c *teiEmbeddingClient) Dimensions() int {
+	return c.spec.dimensions
+}
+
+func (c *teiEmbeddingClient) MaxInputTokens() int {
+	return c.spec.maxInputTokens
+}
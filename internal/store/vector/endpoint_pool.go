@@ -0,0 +1,253 @@
+package vector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half_open"
+
+	defaultFailureThreshold = 5
+	defaultOpenDuration     = 30 * time.Second
+)
+
+var ErrNoAvailableEndpoint = errors.New("no available endpoint")
+
+// endpoint 单个服务端点及其熔断、并发状态
+type endpoint struct {
+	url            string
+	apiKey         string
+	weight         int
+	maxConcurrency int
+	slots          chan struct{} // 并发令牌，nil 表示不限制
+
+	mu                  sync.Mutex
+	state               string
+	consecutiveFailures int
+	openedAt            time.Time
+	probing             bool // 半开状态下是否已有探测请求
+	inFlight            int
+	lastError           string
+	lastErrorAt         time.Time
+}
+
+// endpointPool 按权重选择端点，跟踪健康状态并对连续失败的端点熔断
+type endpointPool struct {
+	endpoints        []*endpoint
+	failureThreshold int
+	openDuration     time.Duration
+	now              func() time.Time
+}
+
+// newEndpointPool 根据配置创建端点池，未配置 Endpoints 时使用 APIBase 作为唯一端点
+func newEndpointPool(cfg config.EmbedderConf, defaultPath string) (*endpointPool, error) {
+	endpointConfs := cfg.Endpoints
+	if len(endpointConfs) == 0 {
+		endpointConfs = []config.EmbedderEndpointConf{{APIBase: cfg.APIBase, APIKey: cfg.APIKey, Weight: 1}}
+	}
+
+	pool := &endpointPool{
+		failureThreshold: cfg.Breaker.FailureThreshold,
+		openDuration:     cfg.Breaker.OpenDuration,
+		now:              time.Now,
+	}
+	if pool.failureThreshold <= 0 {
+		pool.failureThreshold = defaultFailureThreshold
+	}
+	if pool.openDuration <= 0 {
+		pool.openDuration = defaultOpenDuration
+	}
+
+	for _, ec := range endpointConfs {
+		u, err := resolveEndpoint(ec.APIBase, defaultPath)
+		if err != nil {
+			return nil, err
+		}
+		ep := &endpoint{
+			url:            u,
+			apiKey:         ec.APIKey,
+			weight:         ec.Weight,
+			maxConcurrency: ec.MaxConcurrency,
+			state:          breakerClosed,
+		}
+		if ep.apiKey == "" {
+			ep.apiKey = cfg.APIKey
+		}
+		if ep.weight <= 0 {
+			ep.weight = 1
+		}
+		if ep.maxConcurrency > 0 {
+			ep.slots = make(chan struct{}, ep.maxConcurrency)
+		}
+		pool.endpoints = append(pool.endpoints, ep)
+	}
+	return pool, nil
+}
+
+// acquire 选择一个可用端点并占用并发令牌，exclude 中的端点（本次调用已失败的）仅在没有其它选择时使用
+func (p *endpointPool) acquire(ctx context.Context, exclude map[*endpoint]bool) (*endpoint, error) {
+	candidates := p.available(exclude)
+	if len(candidates) == 0 {
+		candidates = p.available(nil)
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNoAvailableEndpoint
+	}
+
+	// 按权重随机排序后依次尝试非阻塞获取令牌，全部满载时阻塞等待首选端点
+	ordered := weightedShuffle(candidates)
+	for _, ep := range ordered {
+		if ep.tryAcquire() {
+			return ep, nil
+		}
+	}
+	ep := ordered[0]
+	if err := ep.waitAcquire(ctx); err != nil {
+		return nil, err
+	}
+	return ep, nil
+}
+
+// available 返回熔断器允许请求的端点，半开状态的端点只放行一个探测请求
+func (p *endpointPool) available(exclude map[*endpoint]bool) []*endpoint {
+	now := p.now()
+	var candidates []*endpoint
+	for _, ep := range p.endpoints {
+		if exclude[ep] {
+			continue
+		}
+		ep.mu.Lock()
+		p.halfOpenIfExpired(ep, now)
+		allowed := ep.state == breakerClosed || (ep.state == breakerHalfOpen && !ep.probing)
+		ep.mu.Unlock()
+		if allowed {
+			candidates = append(candidates, ep)
+		}
+	}
+	return candidates
+}
+
+// halfOpenIfExpired 熔断时间已过的端点转为半开状态，调用方需持有 ep.mu
+func (p *endpointPool) halfOpenIfExpired(ep *endpoint, now time.Time) {
+	if ep.state == breakerOpen && now.Sub(ep.openedAt) >= p.openDuration {
+		ep.state = breakerHalfOpen
+		ep.probing = false
+	}
+}
+
+// release 归还并发令牌并记录请求结果，err 为 nil 表示成功
+func (p *endpointPool) release(ep *endpoint, err error) {
+	if ep.slots != nil {
+		<-ep.slots
+	}
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	ep.inFlight--
+	if err == nil {
+		ep.state = breakerClosed
+		ep.consecutiveFailures = 0
+		ep.probing = false
+		return
+	}
+	ep.consecutiveFailures++
+	ep.lastError = err.Error()
+	ep.lastErrorAt = p.now()
+	if ep.state == breakerHalfOpen || ep.consecutiveFailures >= p.failureThreshold {
+		ep.state = breakerOpen
+		ep.openedAt = p.now()
+		ep.probing = false
+	}
+}
+
+// health 返回各端点的健康状态。熔断时间已过的端点报告为半开（健康），
+// 否则所有端点熔断后就绪检查一直失败，不再有请求进入，熔断器也就无法恢复
+func (p *endpointPool) health() []types.EndpointHealth {
+	now := p.now()
+	result := make([]types.EndpointHealth, 0, len(p.endpoints))
+	for _, ep := range p.endpoints {
+		ep.mu.Lock()
+		p.halfOpenIfExpired(ep, now)
+		h := types.EndpointHealth{
+			Endpoint:            ep.url,
+			Healthy:             ep.state != breakerOpen,
+			State:               ep.state,
+			Weight:              ep.weight,
+			InFlight:            ep.inFlight,
+			MaxConcurrency:      ep.maxConcurrency,
+			ConsecutiveFailures: ep.consecutiveFailures,
+			LastError:           ep.lastError,
+		}
+		if !ep.lastErrorAt.IsZero() {
+			lastErrorAt := ep.lastErrorAt
+			h.LastErrorAt = &lastErrorAt
+		}
+		ep.mu.Unlock()
+		result = append(result, h)
+	}
+	return result
+}
+
+func (ep *endpoint) tryAcquire() bool {
+	if ep.slots != nil {
+		select {
+		case ep.slots <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	ep.markAcquired()
+	return true
+}
+
+func (ep *endpoint) waitAcquire(ctx context.Context) error {
+	if ep.slots != nil {
+		select {
+		case ep.slots <- struct{}{}:
+		case <-ctx.Done():
+			return fmt.Errorf("wait for endpoint %s: %w", ep.url, ctx.Err())
+		}
+	}
+	ep.markAcquired()
+	return nil
+}
+
+func (ep *endpoint) markAcquired() {
+	ep.mu.Lock()
+	ep.inFlight++
+	if ep.state == breakerHalfOpen {
+		ep.probing = true
+	}
+	ep.mu.Unlock()
+}
+
+// weightedShuffle 按权重做不放回随机抽样，权重越大越可能排在前面
+func weightedShuffle(endpoints []*endpoint) []*endpoint {
+	remaining := append([]*endpoint(nil), endpoints...)
+	ordered := make([]*endpoint, 0, len(endpoints))
+	for len(remaining) > 0 {
+		total := 0
+		for _, ep := range remaining {
+			total += ep.weight
+		}
+		n := rand.Intn(total)
+		for i, ep := range remaining {
+			n -= ep.weight
+			if n < 0 {
+				ordered = append(ordered, ep)
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				break
+			}
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,124 @@
+package vector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+)
+
+func newTEIStub(t *testing.T, status *atomic.Int32, calls *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if code := int(status.Load()); code != http.StatusOK {
+			w.WriteHeader(code)
+			return
+		}
+		var req teiEmbedRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		vectors := make([][]float64, len(req.Inputs))
+		for i := range vectors {
+			vectors[i] = []float64{1, 0}
+		}
+		_ = json.NewEncoder(w).Encode(vectors)
+	}))
+}
+
+func TestEndpointPoolFailover(t *testing.T) {
+	var (
+		badStatus, goodStatus atomic.Int32
+		badCalls, goodCalls   atomic.Int32
+	)
+	badStatus.Store(http.StatusServiceUnavailable)
+	goodStatus.Store(http.StatusOK)
+	bad := newTEIStub(t, &badStatus, &badCalls)
+	defer bad.Close()
+	good := newTEIStub(t, &goodStatus, &goodCalls)
+	defer good.Close()
+
+	client, err := NewEmbeddingClient(config.EmbedderConf{
+		Provider:   "tei",
+		MaxRetries: 1,
+		Endpoints: []config.EmbedderEndpointConf{
+			{APIBase: bad.URL, Weight: 100},
+			{APIBase: good.URL, Weight: 1},
+		},
+		Breaker: config.EndpointBreakerConf{FailureThreshold: 2, OpenDuration: time.Hour},
+	})
+	require.NoError(t, err)
+
+	// 故障端点失败后自动切换到健康端点
+	for i := 0; i < 20; i++ {
+		_, err := client.CreateEmbeddings(context.Background(), []string{"x"}, "")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(20), goodCalls.Load())
+	// 连续失败 2 次后熔断，不再请求故障端点
+	assert.Equal(t, int32(2), badCalls.Load())
+
+	health := client.(EndpointHealthReporter).EndpointHealth()
+	require.Len(t, health, 2)
+	assert.False(t, health[0].Healthy)
+	assert.Equal(t, breakerOpen, health[0].State)
+	assert.NotNil(t, health[0].LastErrorAt)
+	assert.True(t, health[1].Healthy)
+}
+
+func TestEndpointPoolHalfOpen(t *testing.T) {
+	var status, calls atomic.Int32
+	status.Store(http.StatusInternalServerError)
+	server := newTEIStub(t, &status, &calls)
+	defer server.Close()
+
+	cfg := config.EmbedderConf{APIBase: server.URL, Breaker: config.EndpointBreakerConf{FailureThreshold: 1, OpenDuration: time.Minute}}
+	pool, err := newEndpointPool(cfg, "/embed")
+	require.NoError(t, err)
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+	transport := &httpEmbeddingTransport{config: cfg, httpClient: http.DefaultClient, pool: pool}
+
+	var out [][]float64
+	assert.Error(t, transport.postJSON(context.Background(), &teiEmbedRequest{Inputs: []string{"x"}}, &out, false))
+	_, err = pool.acquire(context.Background(), nil)
+	assert.ErrorIs(t, err, ErrNoAvailableEndpoint)
+
+	assert.False(t, pool.health()[0].Healthy)
+
+	// 熔断时间过后无请求时也报告为半开，就绪检查恢复后放行探测请求，成功则恢复
+	now = now.Add(2 * time.Minute)
+	assert.True(t, pool.health()[0].Healthy)
+	assert.Equal(t, breakerHalfOpen, pool.health()[0].State)
+	status.Store(http.StatusOK)
+	assert.NoError(t, transport.postJSON(context.Background(), &teiEmbedRequest{Inputs: []string{"x"}}, &out, false))
+	assert.Equal(t, breakerClosed, pool.health()[0].State)
+}
+
+func TestEndpointPoolConcurrencyLimit(t *testing.T) {
+	pool, err := newEndpointPool(config.EmbedderConf{
+		Endpoints: []config.EmbedderEndpointConf{{APIBase: "http://a", MaxConcurrency: 1}, {APIBase: "http://b", MaxConcurrency: 1}},
+	}, "/embed")
+	require.NoError(t, err)
+
+	first, err := pool.acquire(context.Background(), nil)
+	require.NoError(t, err)
+	second, err := pool.acquire(context.Background(), nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.url, second.url)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = pool.acquire(ctx, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	pool.release(first, nil)
+	third, err := pool.acquire(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, first.url, third.url)
+}
@@ -3,6 +3,8 @@ package vector
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/weaviate/weaviate/entities/models"
 	"github.com/zgsm-ai/codebase-indexer/internal/types"
@@ -13,6 +15,23 @@ var ErrInvalidClientId = errors.New("invalid clientId")
 var ErrEmptyResponse = errors.New("response is empty")
 var ErrInvalidResponse = errors.New("response is invalid")
 
+// EmbeddingFailedError 部分文件的分块在重试后仍嵌入失败，成功文件的嵌入结果仍会返回
+type EmbeddingFailedError struct {
+	FailedFiles map[string]error // 失败文件路径及原因
+}
+
+func (e *EmbeddingFailedError) Error() string {
+	paths := make([]string, 0, len(e.FailedFiles))
+	for p := range e.FailedFiles {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	if len(paths) > 3 {
+		paths = append(paths[:3], "...")
+	}
+	return fmt.Sprintf("failed to embed %d files: %s", len(e.FailedFiles), strings.Join(paths, ", "))
+}
+
 // CheckBatchErrors 检查批量操作的结果并返回第一个错误
 func CheckBatchErrors(responses []models.ObjectsGetResponse) error {
 	for _, resp := range responses {
@@ -0,0 +1,253 @@
+package vector
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+// resultParams 合并配置默认值与请求参数后的后处理参数
+type resultParams struct {
+	mergeAdjacent bool
+	diversity     float32
+	maxPerFile    int
+}
+
+func resolveResultParams(c config.QueryResultConf, o ResultOptions) resultParams {
+	p := resultParams{
+		mergeAdjacent: c.MergeAdjacent,
+		diversity:     c.Diversity,
+		maxPerFile:    c.MaxPerFile,
+	}
+	if o.MergeAdjacent != nil {
+		p.mergeAdjacent = *o.MergeAdjacent
+	}
+	if o.Diversity != nil {
+		p.diversity = *o.Diversity
+	}
+	if o.MaxPerFile != nil {
+		p.maxPerFile = *o.MaxPerFile
+	}
+	p.diversity = min(max(p.diversity, 0), 1)
+	return p
+}
+
+// postProcessResults 合并相邻结果后，按 MMR 和单文件结果数上限选出 topK 个结果，explain 不为 nil 时记录各步骤去掉的结果数
+func postProcessResults(docs []*types.SemanticFileItem, topK int, p resultParams, explain *types.SearchExplain) []*types.SemanticFileItem {
+	if p.mergeAdjacent {
+		before := len(docs)
+		docs = mergeAdjacentResults(docs)
+		explain.AddFilter("mergeAdjacent", "true", before-len(docs))
+	}
+	selected := selectResults(docs, topK, p.diversity, p.maxPerFile)
+	if explain == nil {
+		return selected
+	}
+
+	if p.diversity > 0 {
+		explain.AddFilter("diversity", strconv.FormatFloat(float64(p.diversity), 'f', -1, 32), 0)
+	}
+	capped := 0
+	if p.maxPerFile > 0 {
+		perFile := make(map[string]int)
+		for _, doc := range selected {
+			perFile[doc.FilePath]++
+		}
+		for _, doc := range docs {
+			if !slices.Contains(selected, doc) && perFile[doc.FilePath] >= p.maxPerFile {
+				capped++
+			}
+		}
+		explain.AddFilter("perFile", strconv.Itoa(p.maxPerFile), capped)
+	}
+	explain.AddFilter("topK", strconv.Itoa(topK), len(docs)-len(selected)-capped)
+	return selected
+}
+
+// mergeAdjacentResults 合并同一文件中行范围重叠或相邻的结果（如滑动窗口切分出的重叠分块），
+// 合并结果取最高分并位于其中最高分结果的位置。docs 需已按分数降序排列
+func mergeAdjacentResults(docs []*types.SemanticFileItem) []*types.SemanticFileItem {
+	merged := make([]*types.SemanticFileItem, 0, len(docs))
+	byFile := make(map[string][]int) // 文件 -> 在 merged 中的下标
+	for _, doc := range docs {
+		target := -1
+		if doc.SummaryLevel == types.EmptyString {
+			for _, i := range byFile[doc.FilePath] {
+				if m, ok := mergeResult(merged[i], doc); ok {
+					merged[i] = m
+					target = i
+					break
+				}
+			}
+		}
+		if target >= 0 {
+			continue
+		}
+		byFile[doc.FilePath] = append(byFile[doc.FilePath], len(merged))
+		merged = append(merged, doc)
+	}
+	// 合并后的范围可能与同文件的其他结果相邻，继续合并直到不再变化
+	if len(merged) < len(docs) {
+		return mergeAdjacentResults(merged)
+	}
+	return merged
+}
+
+// mergeResult 合并行范围重叠或相邻的两个结果，按行号拼接内容；
+// 内容行数与行范围不一致（如内容被截断）时无法对齐，不合并
+func mergeResult(best, doc *types.SemanticFileItem) (*types.SemanticFileItem, bool) {
+	if best.SummaryLevel != types.EmptyString || best.StartLine > doc.EndLine+1 || doc.StartLine > best.EndLine+1 {
+		return nil, false
+	}
+	first, second := best, doc
+	if doc.StartLine < best.StartLine {
+		first, second = doc, best
+	}
+	firstLines, ok := contentLines(first)
+	if !ok {
+		return nil, false
+	}
+	secondLines, ok := contentLines(second)
+	if !ok {
+		return nil, false
+	}
+	lines := firstLines
+	if second.EndLine > first.EndLine {
+		lines = append(slices.Clone(firstLines), secondLines[first.EndLine+1-second.StartLine:]...)
+	}
+
+	m := *best
+	if best.Explain != nil {
+		e := *best.Explain
+		e.MergedRanks = slices.Clone(e.MergedRanks)
+		if doc.Explain != nil {
+			e.MergedRanks = append(append(e.MergedRanks, doc.Explain.RetrievalRank), doc.Explain.MergedRanks...)
+			slices.Sort(e.MergedRanks)
+		}
+		m.Explain = &e
+	}
+	m.Content = strings.Join(lines, "\n")
+	m.StartLine = first.StartLine
+	m.EndLine = max(first.EndLine, second.EndLine)
+	m.Score = max(best.Score, doc.Score)
+	m.SubRanges = append(resultRanges(best), resultRanges(doc)...)
+	slices.SortFunc(m.SubRanges, func(a, b types.LineRange) int {
+		return cmp.Or(cmp.Compare(a.StartLine, b.StartLine), cmp.Compare(a.EndLine, b.EndLine))
+	})
+	m.SubRanges = slices.Compact(m.SubRanges)
+	m.Links = unionStrings(best.Links, doc.Links)
+	m.FenceLanguages = unionStrings(best.FenceLanguages, doc.FenceLanguages)
+	m.APIOperations = append(slices.Clone(best.APIOperations), doc.APIOperations...)
+	return &m, true
+}
+
+// contentLines 按行切分内容，行数需与行范围（从 0 开始，包含结束行）一致
+func contentLines(doc *types.SemanticFileItem) ([]string, bool) {
+	lines := strings.Split(strings.TrimSuffix(doc.Content, "\n"), "\n")
+	return lines, len(lines) == doc.EndLine-doc.StartLine+1
+}
+
+// resultRanges 结果包含的原始分块行范围
+func resultRanges(doc *types.SemanticFileItem) []types.LineRange {
+	if len(doc.SubRanges) > 0 {
+		return doc.SubRanges
+	}
+	return []types.LineRange{{StartLine: doc.StartLine, EndLine: doc.EndLine}}
+}
+
+func unionStrings(a, b []string) []string {
+	if len(b) == 0 {
+		return a
+	}
+	union := slices.Clone(a)
+	for _, s := range b {
+		if !slices.Contains(union, s) {
+			union = append(union, s)
+		}
+	}
+	return union
+}
+
+// selectResults 按 MMR 依次选出结果：λ·相关性 - (1-λ)·与已选结果的最大相似度，λ = 1 - diversity；
+// 相关性为归一化后的分数，相似度为词集合的 Jaccard 系数。已达到单文件上限的文件不再选入
+func selectResults(docs []*types.SemanticFileItem, topK int, diversity float32, maxPerFile int) []*types.SemanticFileItem {
+	if topK <= 0 || topK > len(docs) {
+		topK = len(docs)
+	}
+	relevance := make([]float32, len(docs))
+	for i, doc := range docs {
+		relevance[i] = doc.Score
+	}
+	normalizeScores(relevance)
+
+	var terms []map[string]struct{}
+	if diversity > 0 {
+		terms = make([]map[string]struct{}, len(docs))
+		for i, doc := range docs {
+			terms[i] = termSet(doc.Content)
+		}
+	}
+
+	selected := make([]*types.SemanticFileItem, 0, topK)
+	selectedIdx := make([]int, 0, topK)
+	picked := make([]bool, len(docs))
+	perFile := make(map[string]int)
+	for len(selected) < topK {
+		best, bestScore := -1, float32(0)
+		for i, doc := range docs {
+			if picked[i] || (maxPerFile > 0 && perFile[doc.FilePath] >= maxPerFile) {
+				continue
+			}
+			if diversity <= 0 {
+				// 不做多样性处理时保持原有顺序
+				best = i
+				break
+			}
+			maxSim := float32(0)
+			for _, j := range selectedIdx {
+				maxSim = max(maxSim, jaccard(terms[i], terms[j]))
+			}
+			score := (1-diversity)*relevance[i] - diversity*maxSim
+			if best < 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			break
+		}
+		if docs[best].Explain != nil && diversity > 0 {
+			score := bestScore
+			docs[best].Explain.MMRScore = &score
+		}
+		picked[best] = true
+		perFile[docs[best].FilePath]++
+		selected = append(selected, docs[best])
+		selectedIdx = append(selectedIdx, best)
+	}
+	return selected
+}
+
+func termSet(text string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, term := range lexicalTerms(text) {
+		set[term] = struct{}{}
+	}
+	return set
+}
+
+func jaccard(a, b map[string]struct{}) float32 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	inter := 0
+	for term := range a {
+		if _, ok := b[term]; ok {
+			inter++
+		}
+	}
+	return float32(inter) / float32(len(a)+len(b)-inter)
+}
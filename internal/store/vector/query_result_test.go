@@ -0,0 +1,95 @@
+package vector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+func TestMergeAdjacentResults(t *testing.T) {
+	docs := []*types.SemanticFileItem{
+		// 滑动窗口切分出的重叠分块
+		{FilePath: "a.go", StartLine: 12, EndLine: 14, Content: "line12\nline13\nline14", Score: 0.9},
+		{FilePath: "b.go", StartLine: 0, EndLine: 1, Content: "b0\nb1", Score: 0.8},
+		{FilePath: "a.go", StartLine: 10, EndLine: 12, Content: "line10\nline11\nline12", Score: 0.7},
+		// 与合并后的范围相邻
+		{FilePath: "a.go", StartLine: 15, EndLine: 15, Content: "line15\n", Score: 0.6},
+		// 不相邻
+		{FilePath: "a.go", StartLine: 30, EndLine: 31, Content: "line30\nline31", Score: 0.5},
+		// 内容与行范围不一致时不合并
+		{FilePath: "b.go", StartLine: 2, EndLine: 9, Content: "b2", Score: 0.4},
+	}
+
+	merged := mergeAdjacentResults(docs)
+	require.Len(t, merged, 4)
+	assert.Equal(t, "a.go", merged[0].FilePath)
+	assert.Equal(t, 10, merged[0].StartLine)
+	assert.Equal(t, 15, merged[0].EndLine)
+	assert.Equal(t, "line10\nline11\nline12\nline13\nline14\nline15", merged[0].Content)
+	assert.Equal(t, float32(0.9), merged[0].Score)
+	assert.Equal(t, []types.LineRange{{StartLine: 10, EndLine: 12}, {StartLine: 12, EndLine: 14}, {StartLine: 15, EndLine: 15}}, merged[0].SubRanges)
+	assert.Equal(t, "b.go", merged[1].FilePath)
+	assert.Equal(t, 30, merged[2].StartLine)
+	assert.Equal(t, "b2", merged[3].Content)
+	// 原结果不被修改
+	assert.Equal(t, 12, docs[0].StartLine)
+}
+
+func TestSelectResults(t *testing.T) {
+	newDocs := func() []*types.SemanticFileItem {
+		return []*types.SemanticFileItem{
+			{FilePath: "limiter.go", Content: "func NewRateLimiter(limit int) *RateLimiter", Score: 0.95},
+			{FilePath: "limiter_copy.go", Content: "func NewRateLimiter(limit int) *RateLimiter", Score: 0.94},
+			{FilePath: "limiter.go", Content: "func (l *RateLimiter) Allow() bool", Score: 0.9},
+			{FilePath: "bucket.go", Content: "type TokenBucket struct { tokens int }", Score: 0.6},
+		}
+	}
+
+	docs := selectResults(newDocs(), 3, 0, 0)
+	assert.Equal(t, []string{"limiter.go", "limiter_copy.go", "limiter.go"}, resultPaths(docs))
+
+	docs = selectResults(newDocs(), 3, 0, 1)
+	assert.Equal(t, []string{"limiter.go", "limiter_copy.go", "bucket.go"}, resultPaths(docs))
+
+	// 与已选结果内容相同的结果被推后
+	docs = selectResults(newDocs(), 2, 0.5, 0)
+	assert.Equal(t, "func (l *RateLimiter) Allow() bool", docs[1].Content)
+
+	merge := false
+	diversity := float32(2)
+	p := resolveResultParams(config.QueryResultConf{MergeAdjacent: true, MaxPerFile: 3}, ResultOptions{MergeAdjacent: &merge, Diversity: &diversity})
+	assert.Equal(t, resultParams{mergeAdjacent: false, diversity: 1, maxPerFile: 3}, p)
+}
+
+func resultPaths(docs []*types.SemanticFileItem) []string {
+	paths := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		paths = append(paths, doc.FilePath)
+	}
+	return paths
+}
+
+func TestPostProcessResultsExplain(t *testing.T) {
+	docs := []*types.SemanticFileItem{
+		{FilePath: "a.go", StartLine: 0, EndLine: 1, Content: "a0\na1", Score: 0.9, Explain: &types.ResultExplain{RetrievalRank: 2}},
+		{FilePath: "a.go", StartLine: 2, EndLine: 2, Content: "a2", Score: 0.8, Explain: &types.ResultExplain{RetrievalRank: 1}},
+		{FilePath: "a.go", StartLine: 20, EndLine: 20, Content: "a20", Score: 0.7, Explain: &types.ResultExplain{RetrievalRank: 3}},
+		{FilePath: "b.go", StartLine: 0, EndLine: 0, Content: "b0", Score: 0.6, Explain: &types.ResultExplain{RetrievalRank: 4}},
+		{FilePath: "c.go", StartLine: 0, EndLine: 0, Content: "c0", Score: 0.5, Explain: &types.ResultExplain{RetrievalRank: 5}},
+	}
+	explain := &types.SearchExplain{}
+	results := postProcessResults(docs, 2, resultParams{mergeAdjacent: true, maxPerFile: 1}, explain)
+
+	require.Len(t, results, 2)
+	assert.Equal(t, []int{1}, results[0].Explain.MergedRanks)
+	assert.Equal(t, 2, results[0].Explain.RetrievalRank)
+	assert.Empty(t, docs[0].Explain.MergedRanks)
+	assert.Equal(t, []*types.FilterExplain{
+		{Name: "mergeAdjacent", Value: "true", Removed: 1},
+		{Name: "perFile", Value: "1", Removed: 1},
+		{Name: "topK", Value: "2", Removed: 1},
+	}, explain.Filters)
+}
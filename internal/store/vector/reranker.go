@@ -6,10 +6,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/tracer"
 	"github.com/zgsm-ai/codebase-indexer/internal/types"
+	"github.com/zgsm-ai/codebase-indexer/internal/usage"
 )
 
 const (
@@ -18,10 +23,90 @@ const (
 	rerankScores    = "scores"
 )
 
+const (
+	RerankerProviderHTTP  = "http"  // 重排服务
+	RerankerProviderLocal = "local" // 本地 BM25、标识符重合度和路径匹配
+
+	RerankFusionNone   = "none"
+	RerankFusionRRF    = "rrf"
+	RerankFusionLinear = "linear"
+)
+
 type Reranker interface {
 	Rerank(ctx context.Context, query string, docs []*types.SemanticFileItem) ([]*types.SemanticFileItem, error)
 }
 
+// RerankerFactory 根据配置创建 Reranker
+type RerankerFactory func(cfg config.RerankerConf) (Reranker, error)
+
+var (
+	rerankerProvidersMu sync.RWMutex
+	rerankerProviders   = map[string]RerankerFactory{
+		RerankerProviderHTTP:  newHTTPReranker,
+		RerankerProviderLocal: newLocalReranker,
+	}
+)
+
+// RegisterRerankerProvider 注册重排提供方，同名提供方会被覆盖
+func RegisterRerankerProvider(name string, factory RerankerFactory) {
+	rerankerProvidersMu.Lock()
+	defer rerankerProvidersMu.Unlock()
+	rerankerProviders[strings.ToLower(name)] = factory
+}
+
+// RerankerProviders 返回已注册的提供方名称
+func RerankerProviders() []string {
+	rerankerProvidersMu.RLock()
+	defer rerankerProvidersMu.RUnlock()
+	names := make([]string, 0, len(rerankerProviders))
+	for name := range rerankerProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newRerankerProvider(name string, cfg config.RerankerConf) (Reranker, error) {
+	rerankerProvidersMu.RLock()
+	factory, ok := rerankerProviders[strings.ToLower(name)]
+	rerankerProvidersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown reranker provider %q, available: %v", name, RerankerProviders())
+	}
+	return factory(cfg)
+}
+
+// NewReranker 创建配置的重排提供方，配置了备用提供方或分数融合时返回组合的 Reranker
+func NewReranker(c config.RerankerConf) (Reranker, error) {
+	provider := strings.ToLower(c.Provider)
+	if provider == "" {
+		provider = RerankerProviderHTTP
+	}
+	primary, err := newRerankerProvider(provider, c)
+	if err != nil {
+		return nil, err
+	}
+	fallbackName := strings.ToLower(c.Fallback)
+	fusion := strings.ToLower(c.Fusion)
+	if (fallbackName == "" || fallbackName == provider) && (fusion == "" || fusion == RerankFusionNone) {
+		return primary, nil
+	}
+
+	chain := &chainReranker{primary: primary, primaryName: provider, fusion: fusion, fusionWeight: c.FusionWeight}
+	if fallbackName != "" && fallbackName != provider {
+		chain.fallbackName = fallbackName
+		if chain.fallback, err = newRerankerProvider(fallbackName, c); err != nil {
+			return nil, err
+		}
+	}
+	switch fusion {
+	case "", RerankFusionNone, RerankFusionRRF, RerankFusionLinear:
+	default:
+		return nil, fmt.Errorf("unknown rerank fusion %q", c.Fusion)
+	}
+	return chain, nil
+}
+
 type customReranker struct {
 	config config.RerankerConf
 }
@@ -118,7 +203,7 @@ func (r *customReranker) Rerank(ctx context.Context, query string, docs []*types
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+r.config.APIKey)
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := &http.Client{Timeout: r.config.Timeout}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send customReranker request to %s: %w", rerankEndpoint, err)
@@ -140,22 +225,134 @@ func (r *customReranker) Rerank(ctx context.Context, query string, docs []*types
 		return nil, fmt.Errorf("reranker returned empty results")
 	}
 
+	if responseBody.Usage.TotalTokens > 0 {
+		usage.Report(ctx, usage.TypeRerank, r.config.Model, responseBody.Usage.PromptTokens, responseBody.Usage.TotalTokens, false)
+	} else {
+		tokens := usage.EstimateTokens(contents...) + usage.EstimateTokens(query)*len(contents)
+		usage.Report(ctx, usage.TypeRerank, r.config.Model, tokens, tokens, true)
+	}
+
 	// Create a mapping from original index to reranked position
-	rerankedDocs := make([]*types.SemanticFileItem, len(docs))
-	for i, result := range responseBody.Results {
-		if result.Index >= len(docs) {
+	rerankedDocs := make([]*types.SemanticFileItem, 0, len(responseBody.Results))
+	for _, result := range responseBody.Results {
+		if result.Index < 0 || result.Index >= len(docs) {
 			return nil, fmt.Errorf("invalid index %d in reranker response", result.Index)
 		}
-		rerankedDocs[i] = docs[result.Index]
-		// Store the relevance score in the document if needed
+		rerankedDocs = append(rerankedDocs, docs[result.Index])
+	}
+	// 全部校验通过后再写入分数，失败时不影响备用提供方和向量检索顺序
+	for i, result := range responseBody.Results {
 		rerankedDocs[i].Score = result.RelevanceScore
 	}
 
 	return rerankedDocs, nil
 }
 
-func NewReranker(c config.RerankerConf) Reranker {
+func newHTTPReranker(c config.RerankerConf) (Reranker, error) {
+	if c.APIBase == "" {
+		return nil, fmt.Errorf("reranker provider %s requires APIBase", RerankerProviderHTTP)
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 30 * time.Second
+	}
 	return &customReranker{
 		config: c,
+	}, nil
+}
+
+// rrfK 倒数排名融合的平滑常数
+const rrfK = 60
+
+// chainReranker 主提供方失败时使用备用提供方，并按配置融合重排分数与向量检索分数
+type chainReranker struct {
+	primary      Reranker
+	primaryName  string
+	fallback     Reranker
+	fallbackName string
+	fusion       string
+	fusionWeight float32
+}
+
+func (r *chainReranker) Rerank(ctx context.Context, query string, docs []*types.SemanticFileItem) ([]*types.SemanticFileItem, error) {
+	if len(docs) == 0 {
+		return docs, nil
+	}
+	// 重排会覆盖分数，先记录向量检索的分数和排名
+	vectorScores := make(map[*types.SemanticFileItem]float32, len(docs))
+	vectorRanks := make(map[*types.SemanticFileItem]int, len(docs))
+	for i, doc := range docs {
+		vectorScores[doc] = doc.Score
+		vectorRanks[doc] = i
+	}
+
+	provider := r.primaryName
+	reranked, err := r.primary.Rerank(ctx, query, docs)
+	if err != nil && r.fallback != nil {
+		tracer.WithTrace(ctx).Errorf("primary reranker failed, use fallback: %v", err)
+		provider = r.fallbackName
+		reranked, err = r.fallback.Rerank(ctx, query, docs)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, doc := range reranked {
+		if doc.Explain != nil {
+			score := doc.Score
+			doc.Explain.RerankScore = &score
+			doc.Explain.RerankRank = i + 1
+			doc.Explain.Reranker = provider
+		}
+	}
+
+	switch r.fusion {
+	case RerankFusionRRF:
+		// 除以两路均排第 1 时的最大值，缩放到 (0,1]，使请求的分数阈值仍然适用
+		maxScore := 2 / float64(rrfK+1)
+		for i, doc := range reranked {
+			doc.Score = float32((1/float64(rrfK+vectorRanks[doc]+1) + 1/float64(rrfK+i+1)) / maxScore)
+		}
+	case RerankFusionLinear:
+		rerankScores := make([]float32, len(reranked))
+		originScores := make([]float32, len(reranked))
+		for i, doc := range reranked {
+			rerankScores[i] = doc.Score
+			originScores[i] = vectorScores[doc]
+		}
+		normalizeScores(rerankScores)
+		normalizeScores(originScores)
+		for i, doc := range reranked {
+			doc.Score = r.fusionWeight*rerankScores[i] + (1-r.fusionWeight)*originScores[i]
+		}
+	default:
+		return reranked, nil
+	}
+	sort.SliceStable(reranked, func(i, j int) bool {
+		return reranked[i].Score > reranked[j].Score
+	})
+	for i, doc := range reranked {
+		if doc.Explain != nil {
+			score := doc.Score
+			doc.Explain.FusionScore = &score
+			doc.Explain.FusionRank = i + 1
+		}
+	}
+	return reranked, nil
+}
+
+// normalizeScores 将分数按最小值、最大值线性缩放到[0,1]，分数全部相同时均为 1
+func normalizeScores(scores []float32) {
+	if len(scores) == 0 {
+		return
+	}
+	lo, hi := scores[0], scores[0]
+	for _, s := range scores {
+		lo, hi = min(lo, s), max(hi, s)
+	}
+	for i, s := range scores {
+		if hi == lo {
+			scores[i] = 1
+			continue
+		}
+		scores[i] = (s - lo) / (hi - lo)
 	}
 }
@@ -0,0 +1,154 @@
+package vector
+
+import (
+	"context"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/preprocess"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+// localReranker 不依赖重排服务，在候选集内按 BM25、查询标识符的重合度和文件路径匹配重新排序
+type localReranker struct {
+	config config.LocalRerankerConf
+}
+
+func newLocalReranker(c config.RerankerConf) (Reranker, error) {
+	return &localReranker{config: c.Local}, nil
+}
+
+func (r *localReranker) Rerank(_ context.Context, query string, docs []*types.SemanticFileItem) ([]*types.SemanticFileItem, error) {
+	if len(docs) == 0 {
+		return docs, nil
+	}
+	queryTerms := uniqueTerms(lexicalTerms(query))
+	queryIdents := uniqueTerms(identifiers(query))
+
+	docTerms := make([][]string, len(docs))
+	df := make(map[string]int)
+	totalLen := 0
+	for i, doc := range docs {
+		text := doc.Content
+		if doc.Summary != "" {
+			text += "\n" + doc.Summary
+		}
+		docTerms[i] = lexicalTerms(text)
+		totalLen += len(docTerms[i])
+		for _, term := range uniqueTerms(docTerms[i]) {
+			df[term]++
+		}
+	}
+	avgLen := max(float64(totalLen)/float64(len(docs)), 1)
+
+	bm25 := make([]float64, len(docs))
+	maxBM25 := 0.0
+	for i, terms := range docTerms {
+		tf := make(map[string]int, len(terms))
+		for _, term := range terms {
+			tf[term]++
+		}
+		for _, term := range queryTerms {
+			f := float64(tf[term])
+			if f == 0 {
+				continue
+			}
+			n := float64(df[term])
+			idf := math.Log(1 + (float64(len(docs))-n+0.5)/(n+0.5))
+			norm := r.config.K1 * (1 - r.config.B + r.config.B*float64(len(terms))/avgLen)
+			bm25[i] += idf * f * (r.config.K1 + 1) / (f + norm)
+		}
+		maxBM25 = max(maxBM25, bm25[i])
+	}
+
+	// 各项均归一化到[0,1]，总分再按权重之和缩放到[0,1]，便于与向量分数融合
+	total := 1 + r.config.IdentifierWeight + r.config.PathWeight
+	for i, doc := range docs {
+		score := 0.0
+		if maxBM25 > 0 {
+			score = bm25[i] / maxBM25
+		}
+		score += r.config.IdentifierWeight * overlap(queryIdents, identifiers(doc.Content))
+		score += r.config.PathWeight * overlap(queryTerms, lexicalTerms(doc.FilePath))
+		doc.Score = float32(score / total)
+	}
+
+	reranked := slices.Clone(docs)
+	slices.SortStableFunc(reranked, func(a, b *types.SemanticFileItem) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+	return reranked, nil
+}
+
+// overlap 查询词在文档词中出现的比例
+func overlap(queryTerms, docTerms []string) float64 {
+	if len(queryTerms) == 0 {
+		return 0
+	}
+	set := make(map[string]struct{}, len(docTerms))
+	for _, term := range docTerms {
+		set[term] = struct{}{}
+	}
+	matched := 0
+	for _, term := range queryTerms {
+		if _, ok := set[term]; ok {
+			matched++
+		}
+	}
+	return float64(matched) / float64(len(queryTerms))
+}
+
+// identifiers 提取文本中的标识符（小写），用于精确匹配查询中的函数名、类型名
+func identifiers(text string) []string {
+	var idents []string
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !isIdentRune(r)
+	}) {
+		if len(word) > 1 && !unicode.IsDigit([]rune(word)[0]) {
+			idents = append(idents, strings.ToLower(word))
+		}
+	}
+	return idents
+}
+
+// lexicalTerms 将文本切分为小写的词：标识符按驼峰和下划线拆分，中文按字切分
+func lexicalTerms(text string) []string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !isIdentRune(r)
+	}) {
+		terms = append(terms, preprocess.SplitIdentifier(word)...)
+	}
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			terms = append(terms, string(r))
+		}
+	}
+	return terms
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || (r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)))
+}
+
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]struct{}, len(terms))
+	unique := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if _, ok := seen[term]; ok {
+			continue
+		}
+		seen[term] = struct{}{}
+		unique = append(unique, term)
+	}
+	return unique
+}
@@ -0,0 +1,100 @@
+package vector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+func rerankCandidates() []*types.SemanticFileItem {
+	return []*types.SemanticFileItem{
+		{FilePath: "internal/job/cleaner.go", Content: "func (c *Cleaner) Run(ctx context.Context) error { return c.clean(ctx) }", Score: 0.9},
+		{FilePath: "internal/logic/status.go", Content: "// 查询索引状态\nfunc getIndexStatus(id int) string { return status }", Score: 0.8},
+		{FilePath: "internal/store/redis/rate_limiter.go", Content: "func NewRateLimiter(limit int) *RateLimiter { return &RateLimiter{limit: limit} }", Score: 0.7},
+	}
+}
+
+func localRerankerConf() config.RerankerConf {
+	return config.RerankerConf{
+		Provider: RerankerProviderLocal,
+		Local:    config.LocalRerankerConf{K1: 1.2, B: 0.75, IdentifierWeight: 0.5, PathWeight: 0.3},
+	}
+}
+
+func TestLocalReranker(t *testing.T) {
+	r, err := NewReranker(localRerankerConf())
+	require.NoError(t, err)
+
+	docs, err := r.Rerank(context.Background(), "where is NewRateLimiter created", rerankCandidates())
+	require.NoError(t, err)
+	require.Len(t, docs, 3)
+	assert.Equal(t, "internal/store/redis/rate_limiter.go", docs[0].FilePath)
+	assert.Greater(t, docs[0].Score, docs[1].Score)
+
+	// 中文按字匹配，驼峰标识符拆分后匹配
+	docs, err = r.Rerank(context.Background(), "索引状态 index status", rerankCandidates())
+	require.NoError(t, err)
+	assert.Equal(t, "internal/logic/status.go", docs[0].FilePath)
+
+	assert.Equal(t, []string{"get", "user", "id", "http", "server", "v2", "索", "引"}, lexicalTerms("getUserID HTTPServer_v2 索引"))
+}
+
+func TestRerankerFallbackAndFusion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	conf := localRerankerConf()
+	conf.Provider = RerankerProviderHTTP
+	conf.APIBase = server.URL
+
+	primaryOnly, err := NewReranker(conf)
+	require.NoError(t, err)
+	_, err = primaryOnly.Rerank(context.Background(), "NewRateLimiter", rerankCandidates())
+	require.Error(t, err)
+
+	conf.Fallback = RerankerProviderLocal
+	withFallback, err := NewReranker(conf)
+	require.NoError(t, err)
+	docs, err := withFallback.Rerank(context.Background(), "NewRateLimiter", rerankCandidates())
+	require.NoError(t, err)
+	assert.Equal(t, "internal/store/redis/rate_limiter.go", docs[0].FilePath)
+
+	// 倒数排名融合：向量排名第 1 与重排排名第 1 的分数之和最高者排在最前
+	conf.Fusion = RerankFusionRRF
+	fused, err := NewReranker(conf)
+	require.NoError(t, err)
+	candidates := rerankCandidates()
+	for _, doc := range candidates {
+		doc.Explain = &types.ResultExplain{}
+	}
+	docs, err = fused.Rerank(context.Background(), "Cleaner Run", candidates)
+	require.NoError(t, err)
+	assert.Equal(t, "internal/job/cleaner.go", docs[0].FilePath)
+	assert.InDelta(t, 1, docs[0].Score, 1e-6)
+	// explain 记录实际生效的重排提供方及重排、融合后的分数和排名
+	assert.Equal(t, RerankerProviderLocal, docs[0].Explain.Reranker)
+	assert.Equal(t, 1, docs[0].Explain.RerankRank)
+	assert.Equal(t, 1, docs[0].Explain.FusionRank)
+	assert.InDelta(t, 1, *docs[0].Explain.FusionScore, 1e-6)
+
+	// 线性融合：重排权重为 0 时保持向量检索顺序
+	conf.Fusion = RerankFusionLinear
+	conf.FusionWeight = 0
+	linear, err := NewReranker(conf)
+	require.NoError(t, err)
+	docs, err = linear.Rerank(context.Background(), "NewRateLimiter", rerankCandidates())
+	require.NoError(t, err)
+	assert.Equal(t, "internal/job/cleaner.go", docs[0].FilePath)
+	assert.Equal(t, float32(1), docs[0].Score)
+
+	_, err = NewReranker(config.RerankerConf{Provider: "unknown"})
+	assert.ErrorContains(t, err, "unknown reranker provider")
+}
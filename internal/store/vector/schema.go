@@ -7,15 +7,32 @@ import (
 )
 
 const (
-	MetadataCodebaseId   = "codebase_id"
-	MetadataCodebaseName = "codebase_name"
-	MetadataSyncId       = "sync_id"
-	MetadataCodebasePath = "codebase_path"
-	MetadataFilePath     = "file_path"
-	MetadataLanguage     = "language"
-	MetadataRange        = "range"
-	MetadataTokenCount   = "token_count"
-	Content              = "content"
+	MetadataCodebaseId      = "codebase_id"
+	MetadataCodebaseName    = "codebase_name"
+	MetadataSyncId          = "sync_id"
+	MetadataCodebasePath    = "codebase_path"
+	MetadataFilePath        = "file_path"
+	MetadataLanguage        = "language"
+	MetadataRange           = "range"
+	MetadataTokenCount      = "token_count"
+	MetadataGenerated       = "generated"
+	MetadataVendored        = "vendored"
+	MetadataEmbeddingHeader = "embedding_header"
+	MetadataSubRanges       = "sub_ranges"
+	MetadataDocstring       = "docstring"
+	MetadataBreadcrumb      = "breadcrumb"
+	MetadataCellIndex       = "cell_index"
+	MetadataFenceLanguages  = "fence_languages"
+	MetadataLinks           = "links"
+	MetadataAPIPath         = "api_path"
+	MetadataAPIMethods      = "api_methods"
+	MetadataAPIOperationIds = "api_operation_ids"
+	MetadataAPITags         = "api_tags"
+	MetadataAPISchemas      = "api_schemas"
+	MetadataAPIOperations   = "api_operations"
+	MetadataSummary         = "summary"
+	MetadataSummaryLevel    = "summary_level"
+	Content                 = "content"
 )
 
 const (
@@ -61,6 +78,109 @@ var classProperties = []*models.Property{
 		Name:     MetadataRange,
 		DataType: schema.DataTypeIntArray.PropString(),
 	},
+	{
+		Name:            MetadataGenerated,
+		DataType:        schema.DataTypeBoolean.PropString(),
+		IndexFilterable: utils.BoolPtr(true),
+	},
+	{
+		Name:            MetadataVendored,
+		DataType:        schema.DataTypeBoolean.PropString(),
+		IndexFilterable: utils.BoolPtr(true),
+	},
+	{
+		// 合并分块中各原始分块的范围，每 4 个数为一组：起始行、起始列、结束行、结束列
+		Name:     MetadataSubRanges,
+		DataType: schema.DataTypeIntArray.PropString(),
+	},
+	{
+		// 嵌入时拼接在代码前的上下文头，重新嵌入时用于还原相同的嵌入文本
+		Name:            MetadataEmbeddingHeader,
+		DataType:        schema.DataTypeText.PropString(),
+		IndexFilterable: utils.BoolPtr(false),
+		IndexSearchable: utils.BoolPtr(false),
+	},
+	{
+		// 声明的文档注释和文档字符串，单独建立全文索引
+		Name:            MetadataDocstring,
+		DataType:        schema.DataTypeText.PropString(),
+		IndexSearchable: utils.BoolPtr(true),
+	},
+	{
+		// 大模型生成的函数、类分块描述，单独建立全文索引
+		Name:            MetadataSummary,
+		DataType:        schema.DataTypeText.PropString(),
+		IndexSearchable: utils.BoolPtr(true),
+	},
+	{
+		// 层级摘要记录的级别（file、directory、repo），代码分块为空
+		Name:            MetadataSummaryLevel,
+		DataType:        schema.DataTypeText.PropString(),
+		IndexFilterable: utils.BoolPtr(true),
+		IndexSearchable: utils.BoolPtr(false),
+		Tokenization:    models.PropertyTokenizationField,
+	},
+	{
+		// 结构化文件中分块的键路径，可按键名检索
+		Name:            MetadataBreadcrumb,
+		DataType:        schema.DataTypeText.PropString(),
+		IndexSearchable: utils.BoolPtr(true),
+	},
+	{
+		// Markdown 分块中代码围栏的语言，可按语言过滤文档
+		Name:            MetadataFenceLanguages,
+		DataType:        schema.DataTypeTextArray.PropString(),
+		IndexFilterable: utils.BoolPtr(true),
+	},
+	{
+		// Markdown 分块中指向代码库内文件的链接
+		Name:            MetadataLinks,
+		DataType:        schema.DataTypeTextArray.PropString(),
+		IndexFilterable: utils.BoolPtr(true),
+	},
+	{
+		// API 描述文档分块的路径模板（AsyncAPI 为通道地址），按完整值过滤
+		Name:            MetadataAPIPath,
+		DataType:        schema.DataTypeText.PropString(),
+		IndexFilterable: utils.BoolPtr(true),
+		Tokenization:    models.PropertyTokenizationField,
+	},
+	{
+		Name:            MetadataAPIMethods,
+		DataType:        schema.DataTypeTextArray.PropString(),
+		IndexFilterable: utils.BoolPtr(true),
+		Tokenization:    models.PropertyTokenizationField,
+	},
+	{
+		Name:            MetadataAPIOperationIds,
+		DataType:        schema.DataTypeTextArray.PropString(),
+		IndexFilterable: utils.BoolPtr(true),
+		Tokenization:    models.PropertyTokenizationField,
+	},
+	{
+		Name:            MetadataAPITags,
+		DataType:        schema.DataTypeTextArray.PropString(),
+		IndexFilterable: utils.BoolPtr(true),
+		Tokenization:    models.PropertyTokenizationField,
+	},
+	{
+		Name:            MetadataAPISchemas,
+		DataType:        schema.DataTypeTextArray.PropString(),
+		IndexFilterable: utils.BoolPtr(true),
+		Tokenization:    models.PropertyTokenizationField,
+	},
+	{
+		// 分块中各操作的完整元数据（JSON），用于在结果中还原操作
+		Name:            MetadataAPIOperations,
+		DataType:        schema.DataTypeText.PropString(),
+		IndexFilterable: utils.BoolPtr(false),
+		IndexSearchable: utils.BoolPtr(false),
+	},
+	{
+		// Jupyter notebook 分块所在的单元格序号
+		Name:     MetadataCellIndex,
+		DataType: schema.DataTypeInt.PropString(),
+	},
 	{
 		Name:            Content,
 		DataType:        schema.DataTypeText.PropString(),
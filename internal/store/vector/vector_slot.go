@@ -0,0 +1,58 @@
+package vector
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+)
+
+// ErrLegacyVectorClass 租户仍在旧版本创建的单向量类中，需先迁移到命名向量类
+var ErrLegacyVectorClass = errors.New("tenant is still in the legacy single vector class, migrate it to the named vector class first")
+
+const maxVectorSlotNameLength = 200
+
+// VectorSlotName 返回嵌入模型对应的命名向量槽位名称，满足 Weaviate 命名规则 [_A-Za-z][_0-9A-Za-z]*
+func VectorSlotName(cfg config.EmbedderConf) string {
+	name := "m_" + sanitizeSlotPart(cfg.Model)
+	if cfg.ModelVersion != "" {
+		name += "__" + sanitizeSlotPart(cfg.ModelVersion)
+	}
+	if len(name) > maxVectorSlotNameLength {
+		name = name[:maxVectorSlotNameLength]
+	}
+	return name
+}
+
+func sanitizeSlotPart(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// VectorSlotCoverage 租户中具有目标槽位向量的对象数
+type VectorSlotCoverage struct {
+	Slot    string
+	Total   int
+	Covered int
+	Failed  int // 无法获取内容或嵌入失败的对象数
+}
+
+// Complete 所有对象都已具有目标槽位向量
+func (c *VectorSlotCoverage) Complete() bool {
+	return c.Covered >= c.Total
+}
+
+// Progress 返回覆盖率，取值[0,1]
+func (c *VectorSlotCoverage) Progress() float64 {
+	if c.Total == 0 {
+		return 1
+	}
+	return float64(c.Covered) / float64(c.Total)
+}
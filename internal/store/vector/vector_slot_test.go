@@ -0,0 +1,45 @@
+package vector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+)
+
+func TestVectorSlotName(t *testing.T) {
+	assert.Equal(t, "m_text_embedding_3_small", VectorSlotName(config.EmbedderConf{Model: "text-embedding-3-small"}))
+	assert.Equal(t, "m_baai_bge_m3__v2", VectorSlotName(config.EmbedderConf{Model: "BAAI/bge-m3", ModelVersion: "v2"}))
+	assert.NotEqual(t,
+		VectorSlotName(config.EmbedderConf{Model: "bge-m3", ModelVersion: "1"}),
+		VectorSlotName(config.EmbedderConf{Model: "bge-m3", ModelVersion: "2"}))
+}
+
+func TestVectorSlotCoverage(t *testing.T) {
+	c := &VectorSlotCoverage{}
+	assert.True(t, c.Complete())
+	assert.Equal(t, float64(1), c.Progress())
+
+	c = &VectorSlotCoverage{Total: 4, Covered: 3}
+	assert.False(t, c.Complete())
+	assert.Equal(t, 0.75, c.Progress())
+}
+
+func TestLegacyEmbedderConf(t *testing.T) {
+	cfg := config.VectorStoreConf{
+		Embedder:          config.EmbedderConf{Model: "bge-m3"},
+		PreviousEmbedders: []config.EmbedderConf{{Model: "gte-modernbert-base"}},
+	}
+	conf, err := legacyEmbedderConf(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "bge-m3", conf.Model)
+
+	cfg.Migration.LegacyModel = "gte-modernbert-base"
+	conf, err = legacyEmbedderConf(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "gte-modernbert-base", conf.Model)
+
+	cfg.Migration.LegacyModel = "unknown"
+	_, err = legacyEmbedderConf(cfg)
+	assert.Error(t, err)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/redact"
 	"github.com/zgsm-ai/codebase-indexer/internal/store/redis"
 	"github.com/zgsm-ai/codebase-indexer/internal/types"
 )
@@ -25,6 +26,17 @@ type Store interface {
 	UpdateCodeChunksPaths(ctx context.Context, updates []*types.CodeChunkPathUpdate, options Options) error
 	UpdateCodeChunksDictionary(ctx context.Context, clientId string, codebasePath string, dictionary string, newDictionary string) error
 	Query(ctx context.Context, query string, topK int, options Options) ([]*types.SemanticFileItem, error)
+	// TargetVectorSlot 返回当前嵌入模型的命名向量槽位
+	TargetVectorSlot() string
+	// LegacyVectorClass 返回仍有租户待迁移的旧版本单向量类，没有时为空
+	LegacyVectorClass() string
+	// MigrateLegacyTenant 将租户从旧版本单向量类迁移到命名向量类，切换前调用 beforeSwitch 记录查询槽位，
+	// 返回租户向量所在的槽位；租户不在旧类中时返回空
+	MigrateLegacyTenant(ctx context.Context, options Options, beforeSwitch func(slot string) error) (string, error)
+	// FillVectorSlot 为租户中缺少当前槽位向量的对象重新嵌入，返回覆盖情况
+	FillVectorSlot(ctx context.Context, options Options, progress func(coverage VectorSlotCoverage)) (*VectorSlotCoverage, error)
+	// ReplaceHierarchySummaries 用新生成的文件、目录和代码库摘要记录替换租户中已有的摘要记录
+	ReplaceHierarchySummaries(ctx context.Context, summaries []*types.CodeChunk, options Options) error
 	Close()
 }
 
@@ -40,6 +52,37 @@ type Options struct {
 	ClientId      string
 	Authorization string
 	Language      string
+	VectorSlot    string // 租户当前用于查询的向量槽位，为空时使用当前模型的槽位
+	// 按 API 操作元数据过滤，设置后只检索 API 描述文档（OpenAPI、Swagger、AsyncAPI）的分块
+	APIFilter *APIOperationFilter
+	// 检索结果后处理参数，为 nil 的字段使用配置的默认值
+	Result ResultOptions
+	// 检索模式：types.SearchModeVector(默认)、SearchModeKeyword 或 SearchModeHybrid
+	SearchMode string
+	// 混合检索中向量检索的权重，取值[0,1]
+	HybridAlpha float32
+	// 关键词检索分数的缩放常数，见 config.QueryConf.KeywordScoreScale
+	KeywordScoreScale float32
+	// 非 nil 时记录检索各阶段的耗时，并在每个结果的 Explain 中记录各阶段的分数
+	Explain *types.SearchExplain
+	// 迁移槽位时重新嵌入的分块内容和上下文头在发送到嵌入模型前脱敏，为 nil 时不脱敏
+	Redactor *redact.Redactor
+}
+
+// ResultOptions 检索结果的后处理参数
+type ResultOptions struct {
+	MergeAdjacent *bool    // 合并同一文件中行范围重叠或相邻的结果
+	Diversity     *float32 // MMR 多样性权重，取值[0,1]
+	MaxPerFile    *int     // 每个文件最多返回的结果数，0 表示不限制
+}
+
+// APIOperationFilter API 操作过滤条件，各条件同时满足，为空的条件不参与过滤
+type APIOperationFilter struct {
+	Method      string   // HTTP 方法或 AsyncAPI 操作类型，不区分大小写
+	Path        string   // 路径模板，可使用 * 通配
+	OperationID string   // 操作 ID
+	Tags        []string // 包含任一标签
+	Schema      string   // 引用的 schema 名
 }
 
 func NewVectorStore(cfg config.VectorStoreConf, embedder Embedder, reranker Reranker) (Store, error) {
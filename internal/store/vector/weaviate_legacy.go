@@ -0,0 +1,271 @@
+package vector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	goweaviate "github.com/weaviate/weaviate-go-client/v5/weaviate"
+	"github.com/weaviate/weaviate-go-client/v5/weaviate/fault"
+	"github.com/weaviate/weaviate/entities/models"
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/tracer"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+// slottedClassSuffix 旧版本单向量类迁移时，新建的命名向量类名称后缀
+const slottedClassSuffix = "_Slots"
+
+// maxReconcileRounds 复制租户后重新比对的最大轮数，用于补齐复制期间写入、修改和删除的对象
+const maxReconcileRounds = 3
+
+// legacyClass 旧版本创建的单向量类。租户在迁移前留在旧类中查询和写入，
+// 迁移时复制到命名向量类，删除旧类中的租户即完成切换；所有租户迁移后删除旧类
+type legacyClass struct {
+	mu   sync.RWMutex
+	name string // 旧类名称，全部租户迁移后为空
+	// 旧类中向量对应的嵌入模型及其在命名向量类中的槽位
+	slot     string
+	embedder Embedder
+	// 已确认不在旧类中的租户，租户不会迁回旧类，可以缓存
+	migrated sync.Map
+}
+
+// legacyEmbedderConf 返回旧类向量对应的嵌入模型配置，未配置 LegacyModel 时与当前模型相同
+func legacyEmbedderConf(cfg config.VectorStoreConf) (config.EmbedderConf, error) {
+	model := cfg.Migration.LegacyModel
+	if model == types.EmptyString || model == cfg.Embedder.Model {
+		return cfg.Embedder, nil
+	}
+	for _, c := range cfg.PreviousEmbedders {
+		if c.Model == model {
+			return c, nil
+		}
+	}
+	return config.EmbedderConf{}, fmt.Errorf("legacy model %s is neither Embedder nor one of PreviousEmbedders", model)
+}
+
+// initLegacyClass 配置的类为单向量类时，改为使用同名加后缀的命名向量类，旧类仅服务尚未迁移的租户
+func (r *weaviateWrapper) initLegacyClass(ctx context.Context, client *goweaviate.Client, class *models.Class) error {
+	conf, err := legacyEmbedderConf(r.cfg)
+	if err != nil {
+		return err
+	}
+	slot := VectorSlotName(conf)
+	if _, ok := r.slotEmbedders[slot]; !ok {
+		return fmt.Errorf("vector slot %s of legacy model is not configured", slot)
+	}
+
+	// 关闭自动创建租户：切换后仍在进行的写入失败并由调用方重试，而不是在旧类中重新创建租户
+	if class.MultiTenancyConfig != nil && class.MultiTenancyConfig.AutoTenantCreation {
+		class.MultiTenancyConfig.AutoTenantCreation = false
+		if err := client.Schema().ClassUpdater().WithClass(class).Do(ctx); err != nil {
+			return fmt.Errorf("failed to disable auto tenant creation of legacy class %s: %w", class.Class, err)
+		}
+	}
+
+	r.legacy = &legacyClass{name: r.className, slot: slot, embedder: r.slotEmbedders[slot]}
+	r.className += slottedClassSuffix
+	tracer.WithTrace(ctx).Infof("weaviate class %s uses a single unnamed vector, new tenants are written to %s, vectors of %s are migrated to slot %s",
+		r.legacy.name, r.className, r.legacy.name, slot)
+	return nil
+}
+
+// LegacyVectorClass 返回仍有租户待迁移的旧版本单向量类，没有时为空
+func (r *weaviateWrapper) LegacyVectorClass() string {
+	if r.legacy == nil {
+		return types.EmptyString
+	}
+	r.legacy.mu.RLock()
+	defer r.legacy.mu.RUnlock()
+	return r.legacy.name
+}
+
+// tenantClass 返回租户所在的类：租户仍在旧类中时返回旧类，否则返回命名向量类
+func (r *weaviateWrapper) tenantClass(ctx context.Context, tenant string) (string, bool) {
+	legacyName := r.LegacyVectorClass()
+	if legacyName == types.EmptyString {
+		return r.className, false
+	}
+	if _, ok := r.legacy.migrated.Load(tenant); ok {
+		return r.className, false
+	}
+	exists, err := r.client.Schema().TenantsExists().WithClassName(legacyName).WithTenant(tenant).Do(ctx)
+	if err != nil {
+		// 无法确认时按未迁移处理，避免在命名向量类中写入不完整的租户
+		tracer.WithTrace(ctx).Errorf("failed to check tenant %s in legacy class %s: %v", tenant, legacyName, err)
+		return legacyName, true
+	}
+	if !exists {
+		r.legacy.migrated.Store(tenant, struct{}{})
+		return r.className, false
+	}
+	return legacyName, true
+}
+
+// tenantEmbedder 返回写入租户使用的嵌入模型和槽位，旧类中的租户使用旧模型写入未命名向量，槽位为空
+func (r *weaviateWrapper) tenantEmbedder(legacy bool) (Embedder, string) {
+	if legacy {
+		return r.legacy.embedder, types.EmptyString
+	}
+	return r.embedder, r.targetSlot
+}
+
+// MigrateLegacyTenant 将旧类中的租户连同向量复制到命名向量类的旧模型槽位，比对补齐复制期间的修改后，
+// 调用 beforeSwitch 记录代码库的查询槽位，再删除旧类中的租户完成切换。租户不在旧类中时返回空槽位
+func (r *weaviateWrapper) MigrateLegacyTenant(ctx context.Context, options Options, beforeSwitch func(slot string) error) (string, error) {
+	tenantName, err := r.generateTenantName(options.ClientId, options.CodebasePath)
+	if err != nil {
+		return types.EmptyString, err
+	}
+	legacyName, legacy := r.tenantClass(ctx, tenantName)
+	if !legacy {
+		return types.EmptyString, nil
+	}
+	slot := r.legacy.slot
+	tracer.WithTrace(ctx).Infof("start to migrate tenant %s from legacy class %s to %s slot %s", tenantName, legacyName, r.className, slot)
+
+	// 清理上次中断的迁移留下的对象
+	if err := r.deleteTenant(ctx, r.className, tenantName); err != nil {
+		return types.EmptyString, err
+	}
+	copied := make(map[string]int64)
+	for round := 0; ; round++ {
+		changed, err := r.copyLegacyObjects(ctx, legacyName, tenantName, slot, copied)
+		if err != nil {
+			return types.EmptyString, err
+		}
+		if changed == 0 {
+			break
+		}
+		if round+1 >= maxReconcileRounds {
+			return types.EmptyString, fmt.Errorf("tenant %s is still changing after %d rounds, retry later", tenantName, maxReconcileRounds)
+		}
+	}
+
+	if beforeSwitch != nil {
+		if err := beforeSwitch(slot); err != nil {
+			return types.EmptyString, err
+		}
+	}
+	if err := r.deleteTenant(ctx, legacyName, tenantName); err != nil {
+		return types.EmptyString, err
+	}
+	r.legacy.migrated.Store(tenantName, struct{}{})
+	tracer.WithTrace(ctx).Infof("tenant %s migrated to %s, %d objects", tenantName, r.className, len(copied))
+	r.dropLegacyClassIfEmpty(ctx, legacyName)
+	return slot, nil
+}
+
+// copyLegacyObjects 复制旧类中新增或修改过的对象，并删除已从旧类中删除的对象，返回变更的对象数。
+// copied 记录已复制对象的最后修改时间，在多轮比对之间共享
+func (r *weaviateWrapper) copyLegacyObjects(ctx context.Context, legacyName, tenantName, slot string, copied map[string]int64) (int, error) {
+	pageSize := r.cfg.Migration.PageSize
+	if pageSize <= 0 {
+		pageSize = 200
+	}
+	changed := 0
+	seen := make(map[string]struct{}, len(copied))
+	after := types.EmptyString
+	for {
+		getter := r.client.Data().ObjectsGetter().
+			WithClassName(legacyName).
+			WithTenant(tenantName).
+			WithVector().
+			WithLimit(pageSize)
+		if after != types.EmptyString {
+			getter = getter.WithAfter(after)
+		}
+		objs, err := getter.Do(ctx)
+		if err != nil {
+			return changed, fmt.Errorf("failed to list objects of tenant %s in legacy class: %w", tenantName, err)
+		}
+		if len(objs) == 0 {
+			break
+		}
+		after = objs[len(objs)-1].ID.String()
+
+		batch := make([]*models.Object, 0, len(objs))
+		for _, obj := range objs {
+			id := obj.ID.String()
+			seen[id] = struct{}{}
+			if updated, ok := copied[id]; ok && updated == obj.LastUpdateTimeUnix {
+				continue
+			}
+			copied[id] = obj.LastUpdateTimeUnix
+			o := &models.Object{
+				ID:         obj.ID,
+				Class:      r.className,
+				Tenant:     tenantName,
+				Properties: obj.Properties,
+			}
+			if len(obj.Vector) > 0 {
+				o.Vectors = models.Vectors{slot: obj.Vector}
+			}
+			batch = append(batch, o)
+		}
+		if len(batch) > 0 {
+			resp, err := r.client.Batch().ObjectsBatcher().WithObjects(batch...).Do(ctx)
+			if err == nil {
+				err = CheckBatchErrors(resp)
+			}
+			if err != nil {
+				return changed, fmt.Errorf("failed to copy objects of tenant %s: %w", tenantName, err)
+			}
+			changed += len(batch)
+		}
+		if len(objs) < pageSize {
+			break
+		}
+	}
+
+	for id := range copied {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		err := r.client.Data().Deleter().WithClassName(r.className).WithTenant(tenantName).WithID(id).Do(ctx)
+		var clientErr *fault.WeaviateClientError
+		if err != nil && !(errors.As(err, &clientErr) && clientErr.StatusCode == http.StatusNotFound) {
+			return changed, fmt.Errorf("failed to delete object %s of tenant %s: %w", id, tenantName, err)
+		}
+		delete(copied, id)
+		changed++
+	}
+	return changed, nil
+}
+
+func (r *weaviateWrapper) deleteTenant(ctx context.Context, className, tenantName string) error {
+	exists, err := r.client.Schema().TenantsExists().WithClassName(className).WithTenant(tenantName).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check tenant %s of class %s: %w", tenantName, className, err)
+	}
+	if !exists {
+		return nil
+	}
+	if err := r.client.Schema().TenantsDeleter().WithClassName(className).WithTenants(tenantName).Do(ctx); err != nil {
+		return fmt.Errorf("failed to delete tenant %s of class %s: %w", tenantName, className, err)
+	}
+	return nil
+}
+
+// dropLegacyClassIfEmpty 旧类中没有租户时删除旧类，之后启动直接使用命名向量类
+func (r *weaviateWrapper) dropLegacyClassIfEmpty(ctx context.Context, legacyName string) {
+	tenants, err := r.client.Schema().TenantsGetter().WithClassName(legacyName).Do(ctx)
+	if err != nil {
+		tracer.WithTrace(ctx).Errorf("failed to list tenants of legacy class %s: %v", legacyName, err)
+		return
+	}
+	if len(tenants) > 0 {
+		return
+	}
+	if err := r.client.Schema().ClassDeleter().WithClassName(legacyName).Do(ctx); err != nil {
+		tracer.WithTrace(ctx).Errorf("failed to delete legacy class %s: %v", legacyName, err)
+		return
+	}
+	r.legacy.mu.Lock()
+	r.legacy.name = types.EmptyString
+	r.legacy.mu.Unlock()
+	tracer.WithTrace(ctx).Infof("all tenants migrated, legacy class %s deleted", legacyName)
+}
@@ -0,0 +1,259 @@
+package vector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	goweaviate "github.com/weaviate/weaviate-go-client/v5/weaviate"
+	"github.com/weaviate/weaviate-go-client/v5/weaviate/fault"
+	"github.com/weaviate/weaviate/entities/models"
+	"github.com/weaviate/weaviate/entities/vectorindex/dynamic"
+	"github.com/zgsm-ai/codebase-indexer/internal/tracer"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+// initVectorSlots 初始化当前模型和旧模型的向量槽位
+func (r *weaviateWrapper) initVectorSlots() error {
+	r.targetSlot = VectorSlotName(r.cfg.Embedder)
+	r.slotEmbedders = map[string]Embedder{r.targetSlot: r.embedder}
+	for _, c := range r.cfg.PreviousEmbedders {
+		slot := VectorSlotName(c)
+		if _, ok := r.slotEmbedders[slot]; ok {
+			continue
+		}
+		embedder, err := NewEmbedder(c)
+		if err != nil {
+			return fmt.Errorf("failed to create embedder for vector slot %s: %w", slot, err)
+		}
+		r.slotEmbedders[slot] = embedder
+	}
+	return nil
+}
+
+// vectorConfigs 返回所有槽位的命名向量配置，向量由服务端外部提供
+func (r *weaviateWrapper) vectorConfigs() map[string]models.VectorConfig {
+	configs := make(map[string]models.VectorConfig, len(r.slotEmbedders))
+	for slot := range r.slotEmbedders {
+		dynamicConf := dynamic.NewDefaultUserConfig()
+		configs[slot] = models.VectorConfig{
+			Vectorizer:        map[string]interface{}{"none": map[string]interface{}{}},
+			VectorIndexType:   dynamicConf.IndexType(),
+			VectorIndexConfig: dynamicConf,
+		}
+	}
+	return configs
+}
+
+// ensureVectorSlots 为已存在的命名向量类补齐缺少的槽位
+func (r *weaviateWrapper) ensureVectorSlots(ctx context.Context, client *goweaviate.Client, class *models.Class) error {
+	missing := make(map[string]models.VectorConfig)
+	for slot, conf := range r.vectorConfigs() {
+		if _, ok := class.VectorConfig[slot]; !ok {
+			missing[slot] = conf
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	tracer.WithTrace(ctx).Infof("add %d vector slots to weaviate class %s", len(missing), r.className)
+	if err := client.Schema().VectorAdder().WithClassName(r.className).WithVectors(missing).Do(ctx); err != nil {
+		return fmt.Errorf("failed to add vector slots to class %s: %w", r.className, err)
+	}
+	return nil
+}
+
+// TargetVectorSlot 返回当前嵌入模型的槽位
+func (r *weaviateWrapper) TargetVectorSlot() string {
+	return r.targetSlot
+}
+
+// querySlot 返回租户查询使用的槽位及其嵌入模型，未指定时使用当前模型；旧类中的租户使用旧模型查询未命名向量
+func (r *weaviateWrapper) querySlot(ctx context.Context, slot string, legacy bool) (string, Embedder) {
+	if legacy {
+		return types.EmptyString, r.legacy.embedder
+	}
+	if slot == types.EmptyString {
+		return r.targetSlot, r.embedder
+	}
+	if embedder, ok := r.slotEmbedders[slot]; ok {
+		return slot, embedder
+	}
+	tracer.WithTrace(ctx).Errorf("vector slot %s is not configured, use current slot %s", slot, r.targetSlot)
+	return r.targetSlot, r.embedder
+}
+
+// servingSlotVectors 租户迁移完成前仍使用旧槽位查询，新写入的分块同时生成旧槽位的向量
+func (r *weaviateWrapper) servingSlotVectors(ctx context.Context, slot string, chunks []*CodeChunkEmbedding) (string, map[*types.CodeChunk][]float32) {
+	if slot == types.EmptyString || slot == r.targetSlot {
+		return types.EmptyString, nil
+	}
+	embedder, ok := r.slotEmbedders[slot]
+	if !ok {
+		return types.EmptyString, nil
+	}
+	docs := make([]*types.CodeChunk, len(chunks))
+	for i, c := range chunks {
+		docs[i] = c.CodeChunk
+	}
+	embedded, err := embedder.EmbedCodeChunks(ctx, docs)
+	var embedFailedErr *EmbeddingFailedError
+	if err != nil && !errors.As(err, &embedFailedErr) {
+		// 旧槽位缺失的向量在租户切换后不再需要，不影响写入
+		tracer.WithTrace(ctx).Errorf("failed to embed chunks for serving vector slot %s: %v", slot, err)
+		return types.EmptyString, nil
+	}
+	vectors := make(map[*types.CodeChunk][]float32, len(embedded))
+	for _, c := range embedded {
+		vectors[c.CodeChunk] = c.Embedding
+	}
+	return slot, vectors
+}
+
+// FillVectorSlot 遍历租户的所有对象，为缺少当前槽位向量的对象重新嵌入；不修改对象其余的向量和属性
+func (r *weaviateWrapper) FillVectorSlot(ctx context.Context, options Options, progress func(coverage VectorSlotCoverage)) (*VectorSlotCoverage, error) {
+	tenantName, err := r.generateTenantName(options.ClientId, options.CodebasePath)
+	if err != nil {
+		return nil, err
+	}
+	if _, legacy := r.tenantClass(ctx, tenantName); legacy {
+		return nil, ErrLegacyVectorClass
+	}
+	pageSize := r.cfg.Migration.PageSize
+	if pageSize <= 0 {
+		pageSize = 200
+	}
+
+	coverage := &VectorSlotCoverage{Slot: r.targetSlot}
+	after := types.EmptyString
+	for {
+		getter := r.client.Data().ObjectsGetter().
+			WithClassName(r.className).
+			WithTenant(tenantName).
+			WithVector().
+			WithLimit(pageSize)
+		if after != types.EmptyString {
+			getter = getter.WithAfter(after)
+		}
+		objs, err := getter.Do(ctx)
+		if err != nil {
+			return coverage, fmt.Errorf("failed to list objects of tenant %s: %w", tenantName, err)
+		}
+		if len(objs) == 0 {
+			break
+		}
+		after = objs[len(objs)-1].ID.String()
+
+		missing := make([]*models.Object, 0, len(objs))
+		for _, obj := range objs {
+			if _, ok := obj.Vectors[r.targetSlot]; ok {
+				coverage.Covered++
+			} else {
+				missing = append(missing, obj)
+			}
+		}
+		coverage.Total += len(objs)
+		if err := r.fillObjects(ctx, tenantName, options, missing, coverage); err != nil {
+			return coverage, err
+		}
+		if progress != nil {
+			progress(*coverage)
+		}
+		if len(objs) < pageSize {
+			break
+		}
+	}
+	return coverage, nil
+}
+
+// fillObjects 为一页对象生成当前槽位的向量并合并写回
+func (r *weaviateWrapper) fillObjects(ctx context.Context, tenantName string, options Options, objs []*models.Object, coverage *VectorSlotCoverage) error {
+	if len(objs) == 0 {
+		return nil
+	}
+	ids := make(map[*types.CodeChunk]string, len(objs))
+	chunks := make([]*types.CodeChunk, 0, len(objs))
+	for _, obj := range objs {
+		props, _ := obj.Properties.(map[string]interface{})
+		chunk := &types.CodeChunk{
+			CodebaseId:   int32(getFloatValue(props, MetadataCodebaseId)),
+			CodebasePath: getStringValue(props, MetadataCodebasePath),
+			FilePath:     getStringValue(props, MetadataFilePath),
+			Language:     getStringValue(props, MetadataLanguage),
+			Content:      []byte(getStringValue(props, Content)),
+			Range:        getIntSliceValue(props, MetadataRange),
+			TokenCount:   int(getFloatValue(props, MetadataTokenCount)),
+			// 使用与写入时相同的上下文头，保证新旧槽位的嵌入文本一致
+			EmbeddingHeader: getStringValue(props, MetadataEmbeddingHeader),
+		}
+		ids[chunk] = obj.ID.String()
+		chunks = append(chunks, chunk)
+	}
+
+	// 未存储源码时从客户端获取分块内容
+	if err := FetchChunkContents(ctx, r.cfg, options, chunks); err != nil {
+		tracer.WithTrace(ctx).Errorf("failed to fetch chunk contents for vector slot %s: %v", r.targetSlot, err)
+	}
+
+	withContent := chunks[:0]
+	for _, chunk := range chunks {
+		if len(chunk.Content) == 0 {
+			coverage.Failed++
+			continue
+		}
+		// 从客户端获取的是原始源码，与写入时一样在嵌入前脱敏
+		options.Redactor.RedactChunk(chunk)
+		withContent = append(withContent, chunk)
+	}
+	if len(withContent) == 0 {
+		return nil
+	}
+
+	embedded, err := r.embedder.EmbedCodeChunks(ctx, withContent)
+	var embedFailedErr *EmbeddingFailedError
+	if err != nil && !errors.As(err, &embedFailedErr) {
+		return fmt.Errorf("failed to embed chunks for vector slot %s: %w", r.targetSlot, err)
+	}
+	coverage.Failed += len(withContent) - len(embedded)
+
+	for _, c := range embedded {
+		err := r.client.Data().Updater().
+			WithMerge().
+			WithClassName(r.className).
+			WithID(ids[c.CodeChunk]).
+			WithTenant(tenantName).
+			WithVectors(models.Vectors{r.targetSlot: c.Embedding}).
+			Do(ctx)
+		if err == nil {
+			coverage.Covered++
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var clientErr *fault.WeaviateClientError
+		if errors.As(err, &clientErr) && clientErr.StatusCode == http.StatusNotFound {
+			// 对象在迁移过程中被删除，不计入总数
+			coverage.Total--
+			continue
+		}
+		coverage.Failed++
+		tracer.WithTrace(ctx).Errorf("failed to write vector slot %s for object %s: %v", r.targetSlot, ids[c.CodeChunk], err)
+	}
+	return nil
+}
+
+func getIntSliceValue(obj map[string]interface{}, key string) []int {
+	values, ok := obj[key].([]interface{})
+	if !ok {
+		return nil
+	}
+	result := make([]int, 0, len(values))
+	for _, v := range values {
+		if f, ok := v.(float64); ok {
+			result = append(result, int(f))
+		}
+	}
+	return result
+}
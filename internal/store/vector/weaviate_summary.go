@@ -0,0 +1,34 @@
+package vector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/weaviate/weaviate-go-client/v5/weaviate/filters"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+// ReplaceHierarchySummaries 删除租户中已有的层级摘要记录并写入新的摘要，代码分块不受影响。
+// 摘要记录的文件路径可能与代码分块相同，不能使用按路径删除的 UpsertCodeChunks
+func (r *weaviateWrapper) ReplaceHierarchySummaries(ctx context.Context, summaries []*types.CodeChunk, options Options) error {
+	tenantName, err := r.generateTenantName(options.ClientId, options.CodebasePath)
+	if err != nil {
+		return fmt.Errorf("failed to generate tenant name: %w", err)
+	}
+	className, _ := r.tenantClass(ctx, tenantName)
+	do, err := r.client.Batch().ObjectsBatchDeleter().
+		WithClassName(className).
+		WithTenant(tenantName).
+		WithWhere(filters.Where().
+			WithPath([]string{MetadataSummaryLevel}).
+			WithOperator(filters.ContainsAny).
+			WithValueText(types.SummaryLevels...)).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete hierarchy summaries: %w", err)
+	}
+	if err = CheckBatchDeleteErrors(do); err != nil {
+		return err
+	}
+	return r.InsertCodeChunks(ctx, summaries, options)
+}
@@ -2,27 +2,29 @@ package vector
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/zeromicro/go-zero/core/logx"
 	"io"
-	"math"
 	"net/http"
 	"net/url"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/zgsm-ai/codebase-indexer/internal/store/redis"
 	"github.com/zgsm-ai/codebase-indexer/internal/tracer"
 
-	"github.com/weaviate/weaviate/entities/vectorindex/dynamic"
-
 	"github.com/go-openapi/strfmt"
 	"github.com/google/uuid"
 	goweaviate "github.com/weaviate/weaviate-go-client/v5/weaviate"
@@ -31,6 +33,7 @@ import (
 	"github.com/weaviate/weaviate-go-client/v5/weaviate/graphql"
 	"github.com/weaviate/weaviate/entities/models"
 	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/parser"
 	"github.com/zgsm-ai/codebase-indexer/internal/types"
 )
 
@@ -42,6 +45,12 @@ type weaviateWrapper struct {
 	cfg           config.VectorStoreConf
 	statusManager *redis.StatusManager
 	requestId     string
+	// 当前嵌入模型的命名向量槽位，类只有单一未命名向量时为空
+	targetSlot string
+	// 各槽位对应的嵌入模型，包含当前模型和迁移期间的旧模型
+	slotEmbedders map[string]Embedder
+	// 配置的类为旧版本单向量类时不为空，className 为新建的命名向量类
+	legacy *legacyClass
 }
 
 func New(cfg config.VectorStoreConf, embedder Embedder, reranker Reranker) (Store, error) {
@@ -68,6 +77,10 @@ func New(cfg config.VectorStoreConf, embedder Embedder, reranker Reranker) (Stor
 		cfg:       cfg,
 	}
 
+	if err = store.initVectorSlots(); err != nil {
+		return nil, err
+	}
+
 	// init class
 	err = store.createClassWithAutoTenantEnabled(client)
 	if err != nil {
@@ -104,6 +117,10 @@ func NewWithStatusManager(cfg config.VectorStoreConf, embedder Embedder, reranke
 		requestId:     requestId,
 	}
 
+	if err = store.initVectorSlots(); err != nil {
+		return nil, err
+	}
+
 	// init class
 	err = store.createClassWithAutoTenantEnabled(client)
 	if err != nil {
@@ -120,6 +137,7 @@ func (r *weaviateWrapper) GetIndexSummary(ctx context.Context, clientId string,
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate tenant name: %w", err)
 	}
+	className, _ := r.tenantClass(ctx, tenantName)
 
 	// Define GraphQL fields using proper Field type
 	fields := []graphql.Field{
@@ -133,7 +151,7 @@ func (r *weaviateWrapper) GetIndexSummary(ctx context.Context, clientId string,
 	}
 
 	res, err := r.client.GraphQL().Aggregate().
-		WithClassName(r.className).
+		WithClassName(className).
 		WithFields(fields...).
 		WithGroupBy(MetadataFilePath).
 		WithTenant(tenantName).
@@ -143,7 +161,7 @@ func (r *weaviateWrapper) GetIndexSummary(ctx context.Context, clientId string,
 		return nil, fmt.Errorf("failed to get index summary: %w", err)
 	}
 
-	summary, err := r.unmarshalSummarySearchResponse(res)
+	summary, err := r.unmarshalSummarySearchResponse(res, className)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal summary response: %w", err)
 	}
@@ -159,6 +177,7 @@ func (r *weaviateWrapper) GetIndexSummaryWithLanguage(ctx context.Context, clien
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate tenant name: %w", err)
 	}
+	className, _ := r.tenantClass(ctx, tenantName)
 
 	// Define GraphQL fields using proper Field type
 	fields := []graphql.Field{
@@ -173,7 +192,7 @@ func (r *weaviateWrapper) GetIndexSummaryWithLanguage(ctx context.Context, clien
 
 	// 创建基础聚合查询
 	aggregateBuilder := r.client.GraphQL().Aggregate().
-		WithClassName(r.className).
+		WithClassName(className).
 		WithFields(fields...).
 		WithGroupBy(MetadataFilePath).
 		WithTenant(tenantName)
@@ -194,7 +213,7 @@ func (r *weaviateWrapper) GetIndexSummaryWithLanguage(ctx context.Context, clien
 		return nil, fmt.Errorf("failed to get index summary with language: %w", err)
 	}
 
-	summary, err := r.unmarshalSummarySearchResponse(res)
+	summary, err := r.unmarshalSummarySearchResponse(res, className)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal summary response: %w", err)
 	}
@@ -212,6 +231,7 @@ func (r *weaviateWrapper) DeleteCodeChunks(ctx context.Context, chunks []*types.
 	if err != nil {
 		return err
 	}
+	className, _ := r.tenantClass(ctx, tenant)
 	// Build a list of filters, one for each codebaseId and filePath pair
 	chunkFilters := make([]*filters.WhereBuilder, len(chunks))
 	for i, chunk := range chunks {
@@ -240,7 +260,7 @@ func (r *weaviateWrapper) DeleteCodeChunks(ctx context.Context, chunks []*types.
 	do, err := r.client.Batch().ObjectsBatchDeleter().
 		WithTenant(tenant).WithWhere(
 		combinedFilter,
-	).WithClassName(r.className).Do(ctx)
+	).WithClassName(className).Do(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to send delete chunks err:%w", err)
 	}
@@ -248,14 +268,23 @@ func (r *weaviateWrapper) DeleteCodeChunks(ctx context.Context, chunks []*types.
 }
 
 func (r *weaviateWrapper) SimilaritySearch(ctx context.Context, query string, numDocuments int, options Options) ([]*types.SemanticFileItem, error) {
-	embedQuery, err := r.embedder.EmbedQuery(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to embed query: %w", err)
-	}
 	tenantName, err := r.generateTenantName(options.ClientId, options.CodebasePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate tenant name: %w", err)
 	}
+	className, legacy := r.tenantClass(ctx, tenantName)
+	slot, embedder := r.querySlot(ctx, options.VectorSlot, legacy)
+	mode := cmp.Or(options.SearchMode, types.SearchModeVector)
+	// 关键词检索不需要生成查询向量
+	var embedQuery []float32
+	if mode != types.SearchModeKeyword {
+		start := time.Now()
+		var err error
+		if embedQuery, err = embedder.EmbedQuery(ctx, query); err != nil {
+			return nil, fmt.Errorf("failed to embed query: %w", err)
+		}
+		options.Explain.AddStage(types.ExplainStageEmbed, start, 0)
+	}
 
 	// Define GraphQL fields using proper Field type
 	fields := []graphql.Field{
@@ -267,36 +296,82 @@ func (r *weaviateWrapper) SimilaritySearch(ctx context.Context, query string, nu
 		{Name: MetadataLanguage},
 		{Name: MetadataRange},
 		{Name: MetadataTokenCount},
+		{Name: MetadataGenerated},
+		{Name: MetadataVendored},
+		{Name: MetadataSubRanges},
+		{Name: MetadataBreadcrumb},
+		{Name: MetadataFenceLanguages},
+		{Name: MetadataLinks},
+		{Name: MetadataAPIOperations},
+		{Name: MetadataCellIndex},
+		{Name: MetadataSummary},
+		{Name: MetadataSummaryLevel},
 		{Name: Content},
 		{Name: "_additional", Fields: []graphql.Field{
 			{Name: "certainty"},
 			{Name: "distance"},
+			{Name: "score"},
 			{Name: "id"},
 		}},
 	}
-
-	// Build GraphQL query with proper tenant filter
-	nearVector := r.client.GraphQL().NearVectorArgBuilder().
-		WithVector(embedQuery)
+	if options.Explain != nil && mode != types.SearchModeVector {
+		// 关键词和混合检索中各部分的分数
+		fields[len(fields)-1].Fields = append(fields[len(fields)-1].Fields, graphql.Field{Name: "explainScore"})
+	}
 
 	// 创建基础查询
 	queryBuilder := r.client.GraphQL().Get().
-		WithClassName(r.className).
+		WithClassName(className).
 		WithFields(fields...).
-		WithNearVector(nearVector).
 		WithLimit(numDocuments).
 		WithTenant(tenantName)
 
+	switch mode {
+	case types.SearchModeKeyword:
+		queryBuilder = queryBuilder.WithBM25(r.client.GraphQL().Bm25ArgBuilder().WithQuery(query))
+	case types.SearchModeHybrid:
+		// 相对分数融合的分数在[0,1]之间，与向量检索的 certainty 可比
+		hybrid := r.client.GraphQL().HybridArgumentBuilder().
+			WithQuery(query).
+			WithVector(embedQuery).
+			WithAlpha(options.HybridAlpha).
+			WithFusionType(graphql.RelativeScore)
+		if slot != types.EmptyString {
+			hybrid = hybrid.WithTargetVectors(slot)
+		}
+		queryBuilder = queryBuilder.WithHybrid(hybrid)
+	default:
+		// Build GraphQL query with proper tenant filter
+		nearVector := r.client.GraphQL().NearVectorArgBuilder().
+			WithVector(embedQuery)
+		if slot != types.EmptyString {
+			nearVector = nearVector.WithTargetVectors(slot)
+		}
+		queryBuilder = queryBuilder.WithNearVector(nearVector)
+	}
+
 	// 如果指定了语言过滤条件，则添加Where过滤器
+	var conditions []*filters.WhereBuilder
 	if options.Language != "" {
-		whereFilter := filters.Where().
+		conditions = append(conditions, filters.Where().
 			WithPath([]string{MetadataLanguage}). // MetadataLanguage = "language"
 			WithOperator(filters.Equal).
-			WithValueText(options.Language)
-
-		queryBuilder = queryBuilder.WithWhere(whereFilter)
+			WithValueText(options.Language))
+		options.Explain.AddFilter("language", options.Language, 0)
+	}
+	if options.APIFilter != nil {
+		conditions = append(conditions, apiOperationConditions(options.APIFilter)...)
+		options.Explain.AddFilter("api", fmt.Sprintf("%+v", *options.APIFilter), 0)
+	}
+	switch len(conditions) {
+	case 0:
+	case 1:
+		queryBuilder = queryBuilder.WithWhere(conditions[0])
+	default:
+		queryBuilder = queryBuilder.WithWhere(filters.Where().WithOperator(filters.And).WithOperands(conditions))
 	}
 
+	start := time.Now()
 	res, err := queryBuilder.Do(ctx)
 
 	if err != nil {
@@ -311,22 +386,66 @@ func (r *weaviateWrapper) SimilaritySearch(ctx context.Context, query string, nu
 		return nil, fmt.Errorf("query weaviate failed: %w", err)
 	}
 
-	items, err := r.unmarshalSimilarSearchResponse(res, options.CodebasePath, options.ClientId, options.Authorization)
+	items, err := r.unmarshalSimilarSearchResponse(res, className, options.CodebasePath, options.ClientId, options.Authorization, options.Explain != nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
+	for _, item := range items {
+		if mode == types.SearchModeKeyword {
+			if item.Explain != nil {
+				score := item.Score
+				item.Explain.KeywordScore = &score
+			}
+			item.Score = keywordScore(item.Score, options.KeywordScoreScale)
+		}
+		if item.Explain != nil {
+			item.Explain.RetrievalScore = item.Score
+		}
+	}
+	options.Explain.AddStage(types.ExplainStageSearch, start, len(items))
 
 	return items, nil
 }
 
-func (r *weaviateWrapper) unmarshalSimilarSearchResponse(res *models.GraphQLResponse, codebasePath, clientId string, authorization string) ([]*types.SemanticFileItem, error) {
+// defaultKeywordScoreScale 未配置 KeywordScoreScale 时使用的缩放常数
+const defaultKeywordScoreScale = 5
+
+// keywordScore 将没有上限的 BM25 分数单调地转换到[0,1)。与按最高分缩放不同，转换后的分数保留绝对相关性，
+// 只有弱匹配时最高分也较低，分数阈值对关键词检索仍然有效
+func keywordScore(score, scale float32) float32 {
+	if score <= 0 {
+		return 0
+	}
+	if scale <= 0 {
+		scale = defaultKeywordScoreScale
+	}
+	return score / (score + scale)
+}
+
+// resultScore 向量检索返回 certainty，关键词和混合检索返回字符串形式的 score
+func resultScore(additional map[string]interface{}) float32 {
+	if certainty, ok := additional["certainty"].(float64); ok {
+		return float32(certainty)
+	}
+	switch score := additional["score"].(type) {
+	case float64:
+		return float32(score)
+	case string:
+		if v, err := strconv.ParseFloat(score, 32); err == nil {
+			return float32(v)
+		}
+	}
+	return 0
+}
+
+func (r *weaviateWrapper) unmarshalSimilarSearchResponse(res *models.GraphQLResponse, className, codebasePath, clientId string, authorization string, explain bool) ([]*types.SemanticFileItem, error) {
 	// Get the data for our class
 	data, ok := res.Data["Get"].(map[string]interface{})
 	if !ok {
 		return nil, fmt.Errorf("invalid response format: 'Get' field not found or has wrong type")
 	}
 
-	results, ok := data[r.className].([]interface{})
+	results, ok := data[className].([]interface{})
 	if !ok {
 		return nil, fmt.Errorf("invalid response format: class data not found or has wrong type")
 	}
@@ -346,8 +465,8 @@ func (r *weaviateWrapper) unmarshalSimilarSearchResponse(res *models.GraphQLResp
 		// content := getStringValue(obj, Content)
 		filePath := getStringValue(obj, MetadataFilePath)
 
-		// 如果开启获取源码，则从MetadataRange中提取行号信息
-		if r.cfg.FetchSourceCode && filePath != "" && codebasePath != "" {
+		// 如果开启获取源码，则从MetadataRange中提取行号信息，层级摘要记录的内容即摘要，notebook 分块总是存储内容，都不需要获取
+		if r.cfg.FetchSourceCode && filePath != "" && codebasePath != "" && clientFetchable(obj, filePath) {
 			// 从MetadataRange中提取startLine和endLine
 			var startLine, endLine int
 			if rangeValue, ok := obj[MetadataRange].([]interface{}); ok && len(rangeValue) >= 2 {
@@ -408,7 +527,7 @@ func (r *weaviateWrapper) unmarshalSimilarSearchResponse(res *models.GraphQLResp
 		}
 
 		// 如果开启获取源码且有批量获取的内容，则使用获取到的内容
-		if r.cfg.FetchSourceCode && filePath != "" && codebasePath != "" {
+		if r.cfg.FetchSourceCode && filePath != "" && codebasePath != "" && clientFetchable(obj, filePath) {
 
 			// 构建映射键并查找批量获取的内容
 			fullPath := filepath.Join(codebasePath, filePath)
@@ -420,11 +539,38 @@ func (r *weaviateWrapper) unmarshalSimilarSearchResponse(res *models.GraphQLResp
 
 		// Create SemanticFileItem with proper fields
 		item := &types.SemanticFileItem{
-			Content:   content,
-			FilePath:  filePath,
-			StartLine: startLine,
-			EndLine:   endLine,
-			Score:     float32(getFloatValue(additional, "certainty")), // Convert float64 to float32
+			Content:        content,
+			FilePath:       filePath,
+			StartLine:      startLine,
+			EndLine:        endLine,
+			Score:          resultScore(additional),
+			Generated:      getBoolValue(obj, MetadataGenerated),
+			Vendored:       getBoolValue(obj, MetadataVendored),
+			SubRanges:      subLineRanges(getIntSliceValue(obj, MetadataSubRanges)),
+			Breadcrumb:     getStringValue(obj, MetadataBreadcrumb),
+			FenceLanguages: getStringSliceValue(obj, MetadataFenceLanguages),
+			Links:          getStringSliceValue(obj, MetadataLinks),
+			Summary:        getStringValue(obj, MetadataSummary),
+			SummaryLevel:   getStringValue(obj, MetadataSummaryLevel),
+		}
+		if cellIndex, ok := obj[MetadataCellIndex].(float64); ok {
+			index := int(cellIndex)
+			item.CellIndex = &index
+		}
+		if explain {
+			item.Explain = &types.ResultExplain{
+				RetrievalRank:   len(items) + 1,
+				RetrievalDetail: strings.TrimSpace(getStringValue(additional, "explainScore")),
+			}
+			if distance, ok := additional["distance"].(float64); ok {
+				d := float32(distance)
+				item.Explain.VectorDistance = &d
+			}
+		}
+		if operations := getStringValue(obj, MetadataAPIOperations); operations != "" {
+			if err := json.Unmarshal([]byte(operations), &item.APIOperations); err != nil {
+				logx.Errorf("failed to unmarshal api operations of %s: %v", filePath, err)
+			}
 		}
 
 		items = append(items, item)
@@ -433,6 +579,83 @@ func (r *weaviateWrapper) unmarshalSimilarSearchResponse(res *models.GraphQLResp
 	return items, nil
 }
 
+// flattenRanges 将多个分块范围展开为一维数组存储
+func flattenRanges(ranges [][]int) []int {
+	flat := make([]int, 0, len(ranges)*4)
+	for _, r := range ranges {
+		if len(r) == 4 {
+			flat = append(flat, r...)
+		}
+	}
+	return flat
+}
+
+// setAPIOperationProperties 写入 API 操作元数据：路径、方法、操作 ID、标签、schema 分别展开为可过滤的数组，完整元数据以 JSON 存储
+func setAPIOperationProperties(properties map[string]any, operations []types.APIOperation) error {
+	data, err := json.Marshal(operations)
+	if err != nil {
+		return fmt.Errorf("failed to marshal api operations: %w", err)
+	}
+	var methods, operationIds, tags, schemas []string
+	for _, op := range operations {
+		methods = appendUnique(methods, op.Method)
+		operationIds = appendUnique(operationIds, op.OperationID)
+		tags = appendUnique(tags, op.Tags...)
+		schemas = appendUnique(schemas, op.Schemas...)
+	}
+	properties[MetadataAPIPath] = operations[0].Path
+	properties[MetadataAPIMethods] = methods
+	properties[MetadataAPIOperationIds] = operationIds
+	properties[MetadataAPITags] = tags
+	properties[MetadataAPISchemas] = schemas
+	properties[MetadataAPIOperations] = string(data)
+	return nil
+}
+
+func appendUnique(values []string, items ...string) []string {
+	for _, item := range items {
+		if item != "" && !slices.Contains(values, item) {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// apiOperationConditions 将 API 操作过滤条件转换为 Where 条件，路径条件总是存在以限定为 API 描述文档的分块
+func apiOperationConditions(f *APIOperationFilter) []*filters.WhereBuilder {
+	path := cmp.Or(f.Path, "*")
+	pathOperator := filters.Equal
+	if strings.Contains(path, "*") {
+		pathOperator = filters.Like
+	}
+	conditions := []*filters.WhereBuilder{
+		filters.Where().WithPath([]string{MetadataAPIPath}).WithOperator(pathOperator).WithValueText(path),
+	}
+	contains := func(property string, values ...string) {
+		if len(values) == 0 || (len(values) == 1 && values[0] == "") {
+			return
+		}
+		conditions = append(conditions,
+			filters.Where().WithPath([]string{property}).WithOperator(filters.ContainsAny).WithValueText(values...))
+	}
+	if f.Method != "" {
+		contains(MetadataAPIMethods, strings.ToUpper(f.Method))
+	}
+	contains(MetadataAPIOperationIds, f.OperationID)
+	contains(MetadataAPITags, f.Tags...)
+	contains(MetadataAPISchemas, f.Schema)
+	return conditions
+}
+
+// subLineRanges 从展开存储的分块范围中提取各原始分块的行范围
+func subLineRanges(flat []int) []types.LineRange {
+	var ranges []types.LineRange
+	for i := 0; i+3 < len(flat); i += 4 {
+		ranges = append(ranges, types.LineRange{StartLine: flat[i], EndLine: flat[i+2]})
+	}
+	return ranges
+}
+
 // Helper functions for safe type conversion
 func getStringValue(obj map[string]interface{}, key string) string {
 	if val, ok := obj[key].(string); ok {
@@ -441,6 +664,27 @@ func getStringValue(obj map[string]interface{}, key string) string {
 	return ""
 }
 
+func getStringSliceValue(obj map[string]interface{}, key string) []string {
+	values, ok := obj[key].([]interface{})
+	if !ok {
+		return nil
+	}
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func getBoolValue(obj map[string]interface{}, key string) bool {
+	if val, ok := obj[key].(bool); ok {
+		return val
+	}
+	return false
+}
+
 func getFloatValue(obj map[string]interface{}, key string) float64 {
 	if val, ok := obj[key].(float64); ok {
 		return val
@@ -466,6 +710,7 @@ func (r *weaviateWrapper) GetCodebaseRecords(ctx context.Context, clientId strin
 		return nil, fmt.Errorf("failed to generate tenant name: %w", err)
 	}
 	fmt.Printf("[DEBUG] 生成的 tenantName: %s\n", tenantName)
+	className, _ := r.tenantClass(ctx, tenantName)
 
 	// 添加调试日志：检查 Weaviate 连接状态
 	live, err := r.client.Misc().LiveChecker().Do(ctx)
@@ -490,6 +735,8 @@ func (r *weaviateWrapper) GetCodebaseRecords(ctx context.Context, clientId strin
 		{Name: MetadataCodebasePath},
 		{Name: MetadataCodebaseName},
 		{Name: MetadataSyncId},
+		{Name: MetadataSummary},
+		{Name: MetadataSummaryLevel},
 	}
 
 	// 执行查询，获取所有记录
@@ -500,10 +747,10 @@ func (r *weaviateWrapper) GetCodebaseRecords(ctx context.Context, clientId strin
 	for {
 		fmt.Printf("[DEBUG] 执行 GraphQL 查询 - offset: %d, limit: %d\n", offset, limit)
 		fmt.Printf("[DEBUG] GraphQL 查询参数 - className: %s, tenant: %s, clientId: %s\n",
-			r.className, tenantName, clientId)
+			className, tenantName, clientId)
 
 		res, err := r.client.GraphQL().Get().
-			WithClassName(r.className).
+			WithClassName(className).
 			WithFields(fields...).
 			WithLimit(limit).
 			WithOffset(offset).
@@ -521,7 +768,7 @@ func (r *weaviateWrapper) GetCodebaseRecords(ctx context.Context, clientId strin
 		}
 
 		// 解析响应
-		records, err := r.unmarshalCodebaseRecordsResponse(res)
+		records, err := r.unmarshalCodebaseRecordsResponse(res, className)
 		if err != nil {
 			fmt.Printf("[DEBUG] 解析响应失败: %v\n", err)
 			return nil, fmt.Errorf("failed to unmarshal records response: %w", err)
@@ -545,7 +792,7 @@ func (r *weaviateWrapper) GetCodebaseRecords(ctx context.Context, clientId strin
 	return allRecords, nil
 }
 
-func (r *weaviateWrapper) unmarshalCodebaseRecordsResponse(res *models.GraphQLResponse) ([]*types.CodebaseRecord, error) {
+func (r *weaviateWrapper) unmarshalCodebaseRecordsResponse(res *models.GraphQLResponse, className string) ([]*types.CodebaseRecord, error) {
 	if len(res.Errors) > 0 {
 		var errMsg string
 		for _, e := range res.Errors {
@@ -568,9 +815,9 @@ func (r *weaviateWrapper) unmarshalCodebaseRecordsResponse(res *models.GraphQLRe
 	}
 
 	// 获取类名对应的数据
-	results, ok := data[r.className].([]interface{})
+	results, ok := data[className].([]interface{})
 	if !ok {
-		fmt.Printf("[DEBUG] 响应格式错误：类数据不存在或类型错误，类名: %s\n", r.className)
+		fmt.Printf("[DEBUG] 响应格式错误：类数据不存在或类型错误，类名: %s\n", className)
 		return nil, fmt.Errorf("invalid response format: class data not found or has wrong type")
 	}
 
@@ -625,6 +872,8 @@ func (r *weaviateWrapper) unmarshalCodebaseRecordsResponse(res *models.GraphQLRe
 			CodebasePath: getStringValue(obj, MetadataCodebasePath),
 			CodebaseName: getStringValue(obj, MetadataCodebaseName),
 			SyncId:       int32(getFloatValue(obj, MetadataSyncId)),
+			Summary:      getStringValue(obj, MetadataSummary),
+			SummaryLevel: getStringValue(obj, MetadataSummaryLevel),
 		}
 
 		records = append(records, record)
@@ -645,6 +894,7 @@ func (r *weaviateWrapper) DeleteByCodebase(ctx context.Context, clientId string,
 	if err != nil {
 		return err
 	}
+	className, _ := r.tenantClass(ctx, tenant)
 
 	// 构建过滤器：根据 codebasePath 删除所有相关的 chunks
 	filter := filters.Where().
@@ -657,7 +907,7 @@ func (r *weaviateWrapper) DeleteByCodebase(ctx context.Context, clientId string,
 		})
 
 	do, err := r.client.Batch().ObjectsBatchDeleter().
-		WithTenant(tenant).WithWhere(filter).WithClassName(r.className).Do(ctx)
+		WithTenant(tenant).WithWhere(filter).WithClassName(className).Do(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to send delete codebase chunks, err:%w", err)
 	}
@@ -692,6 +942,7 @@ func (r *weaviateWrapper) UpdateCodeChunksPaths(ctx context.Context, updates []*
 	if err != nil {
 		return err
 	}
+	className, _ := r.tenantClass(ctx, tenantName)
 
 	// 对于每个更新，使用GraphQL更新操作
 	for _, update := range updates {
@@ -700,7 +951,7 @@ func (r *weaviateWrapper) UpdateCodeChunksPaths(ctx context.Context, updates []*
 		}
 
 		// 首先获取要更新的对象ID和完整数据
-		records, err := r.getRecordsByPath(ctx, update.OldFilePath, tenantName)
+		records, err := r.getRecordsByPath(ctx, update.OldFilePath, className, tenantName)
 		if err != nil {
 			return fmt.Errorf("failed to get records for path %s: %w", update.OldFilePath, err)
 		}
@@ -716,7 +967,7 @@ func (r *weaviateWrapper) UpdateCodeChunksPaths(ctx context.Context, updates []*
 		for _, record := range records {
 			tracer.WithTrace(ctx).Errorf("%s  ->  %s  updateObjectPath: %v", update.OldFilePath, update.NewFilePath, record.Id)
 
-			err := r.updateObjectPath(ctx, record.Id, update.NewFilePath, tenantName, record)
+			err := r.updateObjectPath(ctx, record.Id, update.NewFilePath, className, tenantName, record)
 			if err != nil {
 				return fmt.Errorf("failed to update object %s path from %s to %s: %w", record.Id, update.OldFilePath, update.NewFilePath, err)
 			}
@@ -728,7 +979,7 @@ func (r *weaviateWrapper) UpdateCodeChunksPaths(ctx context.Context, updates []*
 }
 
 // getRecordsByPath 根据文件路径获取完整的记录
-func (r *weaviateWrapper) getRecordsByPath(ctx context.Context, filePath string, tenantName string) ([]*types.CodebaseRecord, error) {
+func (r *weaviateWrapper) getRecordsByPath(ctx context.Context, filePath string, className, tenantName string) ([]*types.CodebaseRecord, error) {
 	// 定义GraphQL字段
 	fields := []graphql.Field{
 		{Name: "_additional", Fields: []graphql.Field{
@@ -758,7 +1009,7 @@ func (r *weaviateWrapper) getRecordsByPath(ctx context.Context, filePath string,
 
 	// 执行查询
 	res, err := r.client.GraphQL().Get().
-		WithClassName(r.className).
+		WithClassName(className).
 		WithFields(fields...).
 		WithWhere(filter).
 		WithTenant(tenantName).
@@ -773,11 +1024,11 @@ func (r *weaviateWrapper) getRecordsByPath(ctx context.Context, filePath string,
 	}
 
 	// 解析响应获取记录
-	return r.unmarshalRecordsResponse(res)
+	return r.unmarshalRecordsResponse(res, className)
 }
 
 // unmarshalRecordsResponse 从GraphQL响应中解析CodebaseRecord列表
-func (r *weaviateWrapper) unmarshalRecordsResponse(res *models.GraphQLResponse) ([]*types.CodebaseRecord, error) {
+func (r *weaviateWrapper) unmarshalRecordsResponse(res *models.GraphQLResponse, className string) ([]*types.CodebaseRecord, error) {
 	if len(res.Errors) > 0 {
 		var errMsg string
 		for _, e := range res.Errors {
@@ -795,7 +1046,7 @@ func (r *weaviateWrapper) unmarshalRecordsResponse(res *models.GraphQLResponse)
 		return nil, fmt.Errorf("invalid response format: 'Get' field not found or has wrong type")
 	}
 
-	results, ok := data[r.className].([]interface{})
+	results, ok := data[className].([]interface{})
 	if !ok {
 		return nil, fmt.Errorf("invalid response format: class data not found or has wrong type")
 	}
@@ -881,7 +1132,7 @@ func (r *weaviateWrapper) unmarshalRecordsResponse(res *models.GraphQLResponse)
 }
 
 // getObjectIdsByPath 根据文件路径获取对象ID
-func (r *weaviateWrapper) getObjectIdsByPath(ctx context.Context, codebaseId int32, filePath string, tenantName string) ([]string, error) {
+func (r *weaviateWrapper) getObjectIdsByPath(ctx context.Context, codebaseId int32, filePath string, className, tenantName string) ([]string, error) {
 	// 定义GraphQL字段
 	fields := []graphql.Field{
 		{Name: "_additional", Fields: []graphql.Field{
@@ -905,7 +1156,7 @@ func (r *weaviateWrapper) getObjectIdsByPath(ctx context.Context, codebaseId int
 
 	// 执行查询
 	res, err := r.client.GraphQL().Get().
-		WithClassName(r.className).
+		WithClassName(className).
 		WithFields(fields...).
 		WithWhere(filter).
 		WithTenant(tenantName).
@@ -920,11 +1171,11 @@ func (r *weaviateWrapper) getObjectIdsByPath(ctx context.Context, codebaseId int
 	}
 
 	// 解析响应获取ID
-	return r.unmarshalIdsResponse(res)
+	return r.unmarshalIdsResponse(res, className)
 }
 
 // unmarshalIdsResponse 从GraphQL响应中解析ID列表
-func (r *weaviateWrapper) unmarshalIdsResponse(res *models.GraphQLResponse) ([]string, error) {
+func (r *weaviateWrapper) unmarshalIdsResponse(res *models.GraphQLResponse, className string) ([]string, error) {
 	if len(res.Errors) > 0 {
 		var errMsg string
 		for _, e := range res.Errors {
@@ -942,7 +1193,7 @@ func (r *weaviateWrapper) unmarshalIdsResponse(res *models.GraphQLResponse) ([]s
 		return nil, fmt.Errorf("invalid response format: 'Get' field not found or has wrong type")
 	}
 
-	results, ok := data[r.className].([]interface{})
+	results, ok := data[className].([]interface{})
 	if !ok {
 		return nil, fmt.Errorf("invalid response format: class data not found or has wrong type")
 	}
@@ -968,27 +1219,19 @@ func (r *weaviateWrapper) unmarshalIdsResponse(res *models.GraphQLResponse) ([]s
 }
 
 // updateObjectPath 更新单个对象的路径
-func (r *weaviateWrapper) updateObjectPath(ctx context.Context, id string, newFilePath string, tenantName string, record *types.CodebaseRecord) error {
-	// 使用Weaviate的REST API直接更新对象
-	// 构建更新请求体，包含所有原有属性
+func (r *weaviateWrapper) updateObjectPath(ctx context.Context, id string, newFilePath string, className, tenantName string, record *types.CodebaseRecord) error {
+	// 使用合并更新只修改路径，保留对象的向量和其余属性
 	updateData := map[string]interface{}{
-		MetadataFilePath:     newFilePath,
-		MetadataLanguage:     record.Language,
-		Content:              record.Content,
-		MetadataRange:        record.Range,
-		MetadataTokenCount:   record.TokenCount,
-		MetadataCodebaseId:   record.CodebaseId,
-		MetadataCodebasePath: record.CodebasePath,
-		MetadataCodebaseName: record.CodebaseName,
-		MetadataSyncId:       record.SyncId,
+		MetadataFilePath: newFilePath,
 	}
 
 	// 执行更新
 	err := r.client.Data().Updater().
 		WithID(id).
-		WithClassName(r.className).
+		WithClassName(className).
 		WithTenant(tenantName).
 		WithProperties(updateData).
+		WithMerge().
 		Do(ctx)
 
 	if err != nil {
@@ -1006,26 +1249,38 @@ func (r *weaviateWrapper) InsertCodeChunks(ctx context.Context, docs []*types.Co
 	if err != nil {
 		return err
 	}
+	className, legacy := r.tenantClass(ctx, tenantName)
+	embedder, slot := r.tenantEmbedder(legacy)
 
 	tracer.WithTrace(ctx).Infof("InsertCodeChunks options.RequestId: %s ", options.RequestId)
 	// 如果有状态管理器和请求ID，则使用带有状态管理器的 embedder
 	var chunks []*CodeChunkEmbedding
 	if r.statusManager != nil && options.RequestId != "" {
 		// 创建带有状态管理器的临时 embedder
-		embedderWithStatus, err := NewEmbedderWithStatusManager(r.cfg.Embedder, r.statusManager, options.RequestId, options.TotalFiles)
+		embedderWithStatus, err := withStatusManager(embedder, r.cfg.Embedder, r.statusManager, options.RequestId, options.TotalFiles)
 		if err != nil {
 			return fmt.Errorf("failed to create embedder with status manager: %w", err)
 		}
 		chunks, err = embedderWithStatus.EmbedCodeChunks(ctx, docs)
 	} else {
 		// 使用原有的 embedder
-		chunks, err = r.embedder.EmbedCodeChunks(ctx, docs)
+		chunks, err = embedder.EmbedCodeChunks(ctx, docs)
 	}
 
-	if err != nil {
+	// 部分文件嵌入失败时仍写入成功的分块，最后将失败文件返回给调用方
+	var embedFailedErr *EmbeddingFailedError
+	if err != nil && !errors.As(err, &embedFailedErr) {
+		return err
+	}
+	if len(chunks) == 0 {
 		return err
 	}
 	tracer.WithTrace(ctx).Infof("embedded %d chunks for codebase %s successfully", len(chunks), docs[0].CodebaseName)
+	var servingSlot string
+	var servingVectors map[*types.CodeChunk][]float32
+	if !legacy {
+		servingSlot, servingVectors = r.servingSlotVectors(ctx, options.VectorSlot, chunks)
+	}
 
 	objs := make([]*models.Object, len(chunks), len(chunks))
 	for i, c := range chunks {
@@ -1035,29 +1290,69 @@ func (r *weaviateWrapper) InsertCodeChunks(ctx context.Context, docs []*types.Co
 
 		// 根据配置决定是否存储Content代码片段
 		properties := map[string]any{
-			MetadataFilePath:     c.FilePath,
-			MetadataLanguage:     c.Language,
-			MetadataCodebaseId:   c.CodebaseId,
-			MetadataCodebasePath: options.CodebasePath,
-			MetadataCodebaseName: options.CodebaseName,
-			MetadataSyncId:       options.SyncId,
-			MetadataRange:        c.Range,
-			MetadataTokenCount:   c.TokenCount,
-			Content:              "",
+			MetadataFilePath:        c.FilePath,
+			MetadataLanguage:        c.Language,
+			MetadataCodebaseId:      c.CodebaseId,
+			MetadataCodebasePath:    options.CodebasePath,
+			MetadataCodebaseName:    options.CodebaseName,
+			MetadataSyncId:          options.SyncId,
+			MetadataRange:           c.Range,
+			MetadataTokenCount:      c.TokenCount,
+			MetadataGenerated:       c.Generated,
+			MetadataVendored:        c.Vendored,
+			MetadataEmbeddingHeader: c.EmbeddingHeader,
+			MetadataSubRanges:       flattenRanges(c.SubRanges),
+			MetadataBreadcrumb:      c.Breadcrumb,
+			Content:                 "",
 		}
 
 		// 如果配置中启用了StoreSourceCode，则存储源码
 		if r.cfg.StoreSourceCode {
 			properties[Content] = string(c.Content)
 		}
+		if r.cfg.StoreDocstring && c.Docstring != types.EmptyString {
+			properties[MetadataDocstring] = c.Docstring
+		}
+		if c.Summary != types.EmptyString {
+			properties[MetadataSummary] = c.Summary
+		}
+		if c.SummaryLevel != types.EmptyString {
+			// 层级摘要无法从客户端获取，总是存储内容
+			properties[MetadataSummaryLevel] = c.SummaryLevel
+			properties[Content] = string(c.Content)
+		}
+		if c.CellIndex != nil {
+			// notebook 分块的行号对应单元格拼接视图，无法按行号从客户端获取，总是存储内容
+			properties[MetadataCellIndex] = *c.CellIndex
+			properties[Content] = string(c.Content)
+		}
+		if len(c.FenceLanguages) > 0 {
+			properties[MetadataFenceLanguages] = c.FenceLanguages
+		}
+		if len(c.Links) > 0 {
+			properties[MetadataLinks] = c.Links
+		}
+		if len(c.APIOperations) > 0 {
+			if err := setAPIOperationProperties(properties, c.APIOperations); err != nil {
+				return err
+			}
+		}
 
 		objs[i] = &models.Object{
 			ID:         strfmt.UUID(uuid.New().String()),
-			Class:      r.className,
+			Class:      className,
 			Tenant:     tenantName,
-			Vector:     c.Embedding,
 			Properties: properties,
 		}
+		if legacy {
+			objs[i].Vector = c.Embedding
+		} else {
+			vectors := models.Vectors{slot: c.Embedding}
+			if v, ok := servingVectors[c.CodeChunk]; ok {
+				vectors[servingSlot] = v
+			}
+			objs[i].Vectors = vectors
+		}
 	}
 	tracer.WithTrace(ctx).Infof("start to save %d chunks for codebase %s successfully", len(docs), docs[0].CodebaseName)
 	resp, err := r.client.Batch().ObjectsBatcher().WithObjects(objs...).Do(ctx)
@@ -1067,27 +1362,98 @@ func (r *weaviateWrapper) InsertCodeChunks(ctx context.Context, docs []*types.Co
 	if err = CheckBatchErrors(resp); err != nil {
 		return fmt.Errorf("failed to send batch to Weaviate: %w", err)
 	}
-	tracer.WithTrace(ctx).Infof("save %d chunks for codebase %s successfully", len(docs), docs[0].CodebaseName)
+	tracer.WithTrace(ctx).Infof("save %d chunks for codebase %s successfully", len(objs), docs[0].CodebaseName)
+	if embedFailedErr != nil {
+		return embedFailedErr
+	}
 	return nil
 }
 
 func (r *weaviateWrapper) Query(ctx context.Context, query string, topK int, options Options) ([]*types.SemanticFileItem, error) {
+	explain := options.Explain
+	if explain != nil {
+		explain.Mode = cmp.Or(options.SearchMode, types.SearchModeVector)
+		explain.MaxDocuments = r.cfg.Weaviate.MaxDocuments
+		explain.TopK = topK
+	}
 	documents, err := r.SimilaritySearch(ctx, query, r.cfg.Weaviate.MaxDocuments, options)
 
 	if err != nil {
 		return nil, err
 	}
+	if explain != nil {
+		explain.Candidates = len(documents)
+	}
 	//  调用reranker模型进行重排
+	start := time.Now()
 	rerankedDocs, err := r.reranker.Rerank(ctx, query, documents)
 	if err != nil {
 		tracer.WithTrace(ctx).Errorf("failed customReranker docs: %v", err)
+		if explain != nil {
+			explain.RerankError = err.Error()
+		}
 	}
 	if len(rerankedDocs) == 0 {
 		rerankedDocs = documents
+	} else if explain != nil {
+		r.explainRerank(explain, rerankedDocs)
 	}
-	// topK
-	rerankedDocs = rerankedDocs[:int(math.Min(float64(topK), float64(len(rerankedDocs))))]
-	return rerankedDocs, nil
+	explain.AddStage(types.ExplainStageRerank, start, len(rerankedDocs))
+
+	start = time.Now()
+	rerankedDocs = r.downWeightGeneratedCode(rerankedDocs)
+	explain.AddStage(types.ExplainStageBoost, start, len(rerankedDocs))
+
+	// 合并相邻结果，按多样性和单文件上限选出 topK
+	start = time.Now()
+	results := postProcessResults(rerankedDocs, topK, resolveResultParams(r.cfg.Result, options.Result), explain)
+	explain.AddStage(types.ExplainStagePostProcess, start, len(results))
+	return results, nil
+}
+
+// explainRerank 记录重排分数和排名，组合的 Reranker 已记录时（含备用提供方和分数融合）不再覆盖
+func (r *weaviateWrapper) explainRerank(explain *types.SearchExplain, docs []*types.SemanticFileItem) {
+	provider := cmp.Or(strings.ToLower(r.cfg.Reranker.Provider), RerankerProviderHTTP)
+	for i, doc := range docs {
+		if doc.Explain == nil {
+			continue
+		}
+		if doc.Explain.RerankScore == nil {
+			score := doc.Score
+			doc.Explain.RerankScore = &score
+			doc.Explain.RerankRank = i + 1
+			doc.Explain.Reranker = provider
+		}
+		explain.Reranker = doc.Explain.Reranker
+	}
+}
+
+// downWeightGeneratedCode 对生成/第三方代码降权并按分数重新排序
+func (r *weaviateWrapper) downWeightGeneratedCode(docs []*types.SemanticFileItem) []*types.SemanticFileItem {
+	weight := r.cfg.GeneratedCodeWeight
+	if weight <= 0 || weight >= 1 {
+		return docs
+	}
+	changed := false
+	for _, doc := range docs {
+		if doc.Generated || doc.Vendored {
+			doc.Score *= weight
+			changed = true
+			if doc.Explain != nil {
+				name := "generated"
+				if doc.Vendored {
+					name = "vendored"
+				}
+				doc.Explain.Boosts = append(doc.Explain.Boosts, &types.ScoreBoost{Name: name, Factor: weight})
+			}
+		}
+	}
+	if changed {
+		sort.SliceStable(docs, func(i, j int) bool {
+			return docs[i].Score > docs[j].Score
+		})
+	}
+	return docs
 }
 
 // CodeSnippetRequest 代码片段请求结构
@@ -1122,6 +1488,55 @@ type CodeSnippetsBatchResponse struct {
 	} `json:"data"`
 }
 
+// FetchChunkContents 未存储源码时，从客户端获取内容为空的分块的源码，获取失败的分块内容保持为空
+func FetchChunkContents(ctx context.Context, cfg config.VectorStoreConf, options Options, chunks []*types.CodeChunk) error {
+	var snippets []CodeSnippetRequest
+	for _, chunk := range chunks {
+		if len(chunk.Content) == 0 && len(chunk.Range) >= 3 && !isNotebookFile(chunk.FilePath) {
+			snippets = append(snippets, CodeSnippetRequest{
+				FilePath:  filepath.Join(options.CodebasePath, chunk.FilePath),
+				StartLine: chunk.Range[0],
+				EndLine:   chunk.Range[2],
+			})
+		}
+	}
+	if len(snippets) == 0 {
+		return nil
+	}
+	if cfg.BaseURL == types.EmptyString {
+		return errors.New("source code is not stored and code snippet api is not configured")
+	}
+	contents, err := fetchCodeContentsBatch(ctx, cfg, options.ClientId, options.CodebasePath, snippets, options.Authorization)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %d chunk contents: %w", len(snippets), err)
+	}
+	for _, chunk := range chunks {
+		if len(chunk.Content) > 0 || len(chunk.Range) < 3 || isNotebookFile(chunk.FilePath) {
+			continue
+		}
+		key := fmt.Sprintf("%s:%d-%d", filepath.Join(options.CodebasePath, chunk.FilePath), chunk.Range[0], chunk.Range[2])
+		chunk.Content = []byte(contents[key])
+	}
+	return nil
+}
+
+// isNotebookFile notebook 分块的行号对应单元格拼接视图而不是原始 JSON 文件的行号
+func isNotebookFile(filePath string) bool {
+	conf, err := parser.GetLangConfigByFilePath(filePath)
+	return err == nil && conf.Language == parser.Jupyter
+}
+
+// clientFetchable 检索结果的内容能否按行号从客户端获取：层级摘要记录的内容即摘要，notebook 分块的行号不对应原始文件
+func clientFetchable(obj map[string]interface{}, filePath string) bool {
+	if getStringValue(obj, MetadataSummaryLevel) != types.EmptyString {
+		return false
+	}
+	if _, ok := obj[MetadataCellIndex].(float64); ok {
+		return false
+	}
+	return !isNotebookFile(filePath)
+}
+
 // fetchCodeContentsBatch 批量获取代码片段Content
 func fetchCodeContentsBatch(ctx context.Context, cfg config.VectorStoreConf, clientId, codebasePath string, snippets []CodeSnippetRequest, authorization string) (map[string]string, error) {
 	if len(snippets) == 0 {
@@ -1281,11 +1696,38 @@ func (r *weaviateWrapper) createClassWithAutoTenantEnabled(client *goweaviate.Cl
 	}
 	if err == nil && res {
 		tracer.WithTrace(timeout).Infof("weaviate class %s already exists, not create.", r.className)
-		return nil
+		if err := r.ensureClassProperties(timeout, client); err != nil {
+			return err
+		}
+		class, err := client.Schema().ClassGetter().WithClassName(r.className).Do(timeout)
+		if err != nil {
+			return fmt.Errorf("failed to get weaviate class %s: %w", r.className, err)
+		}
+		if len(class.VectorConfig) > 0 {
+			return r.ensureVectorSlots(timeout, client, class)
+		}
+		// 旧版本创建的单向量类无法增加命名向量，新建命名向量类并逐个租户迁移
+		if err := r.initLegacyClass(timeout, client, class); err != nil {
+			return err
+		}
+		res, err = client.Schema().ClassExistenceChecker().WithClassName(r.className).Do(timeout)
+		if err != nil {
+			return fmt.Errorf("failed to check weaviate class %s: %w", r.className, err)
+		}
+		if res {
+			return r.ensureSlottedClass(timeout, client)
+		}
+	} else if err == nil {
+		// 旧类的租户已全部迁移并删除旧类
+		slotted := r.className + slottedClassSuffix
+		if res, err = client.Schema().ClassExistenceChecker().WithClassName(slotted).Do(timeout); err == nil && res {
+			tracer.WithTrace(timeout).Infof("weaviate class %s was migrated to %s", r.className, slotted)
+			r.className = slotted
+			return r.ensureSlottedClass(timeout, client)
+		}
 	}
 
 	// 定义类的属性并配置索引
-	dynamicConf := dynamic.NewDefaultUserConfig()
 	class := &models.Class{
 		Class:      r.className,
 		Properties: classProperties, // fields
@@ -1294,8 +1736,8 @@ func (r *weaviateWrapper) createClassWithAutoTenantEnabled(client *goweaviate.Cl
 			Enabled:            true,
 			AutoTenantCreation: true,
 		},
-		VectorIndexType:   dynamicConf.IndexType(),
-		VectorIndexConfig: dynamicConf,
+		// 每个嵌入模型版本一个命名向量槽位，便于更换模型时在后台迁移
+		VectorConfig: r.vectorConfigs(),
 	}
 
 	tracer.WithTrace(timeout).Infof("class info:%v", class)
@@ -1309,6 +1751,41 @@ func (r *weaviateWrapper) createClassWithAutoTenantEnabled(client *goweaviate.Cl
 	return err
 }
 
+// ensureSlottedClass 为已存在的命名向量类补齐属性和槽位
+func (r *weaviateWrapper) ensureSlottedClass(ctx context.Context, client *goweaviate.Client) error {
+	if err := r.ensureClassProperties(ctx, client); err != nil {
+		return err
+	}
+	class, err := client.Schema().ClassGetter().WithClassName(r.className).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get weaviate class %s: %w", r.className, err)
+	}
+	return r.ensureVectorSlots(ctx, client, class)
+}
+
+// ensureClassProperties 为已存在的类补齐新增的属性
+func (r *weaviateWrapper) ensureClassProperties(ctx context.Context, client *goweaviate.Client) error {
+	class, err := client.Schema().ClassGetter().WithClassName(r.className).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get weaviate class %s: %w", r.className, err)
+	}
+	existing := make(map[string]struct{}, len(class.Properties))
+	for _, prop := range class.Properties {
+		existing[prop.Name] = struct{}{}
+	}
+	for _, prop := range classProperties {
+		if _, ok := existing[prop.Name]; ok {
+			continue
+		}
+		tracer.WithTrace(ctx).Infof("add property %s to weaviate class %s", prop.Name, r.className)
+		err = client.Schema().PropertyCreator().WithClassName(r.className).WithProperty(prop).Do(ctx)
+		if err != nil && !strings.Contains(err.Error(), "already exists") {
+			return fmt.Errorf("failed to add property %s to class %s: %w", prop.Name, r.className, err)
+		}
+	}
+	return nil
+}
+
 // generateTenantName 使用 MD5 哈希生成合规租户名（32字符，纯十六进制）
 func (r *weaviateWrapper) generateTenantName(clientId string, codebasePath string) (string, error) {
 	// 添加调试日志
@@ -1332,7 +1809,7 @@ func (r *weaviateWrapper) generateTenantName(clientId string, codebasePath strin
 	return tenantName, nil
 }
 
-func (r *weaviateWrapper) unmarshalSummarySearchResponse(res *models.GraphQLResponse) (*types.EmbeddingSummary, error) {
+func (r *weaviateWrapper) unmarshalSummarySearchResponse(res *models.GraphQLResponse, className string) (*types.EmbeddingSummary, error) {
 	if len(res.Errors) > 0 {
 		var errMsg string
 		for _, e := range res.Errors {
@@ -1352,7 +1829,7 @@ func (r *weaviateWrapper) unmarshalSummarySearchResponse(res *models.GraphQLResp
 	}
 
 	// 获取类名对应的数据
-	results, ok := data[r.className].([]interface{})
+	results, ok := data[className].([]interface{})
 	// if !ok || len(results) == 0 {
 	if !ok {
 		return nil, fmt.Errorf("invalid response format: class data not found or has wrong type：%s", reflect.TypeOf(results).String())
@@ -1393,9 +1870,10 @@ func (r *weaviateWrapper) GetFileRecords(ctx context.Context, clientId string, c
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate tenant name: %w", err)
 	}
+	className, _ := r.tenantClass(ctx, tenantName)
 
 	// 调用内部方法获取记录
-	return r.getRecordsByPath(ctx, filePath, tenantName)
+	return r.getRecordsByPath(ctx, filePath, className, tenantName)
 }
 
 // GetDictionaryRecords 获取指定目录的记录，通过匹配filePath的前缀
@@ -1406,13 +1884,14 @@ func (r *weaviateWrapper) GetDictionaryRecords(ctx context.Context, clientId str
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate tenant name: %w", err)
 	}
+	className, _ := r.tenantClass(ctx, tenantName)
 
 	// 调用内部方法获取目录记录
-	return r.getRecordsByPathPrefix(ctx, dictionary, tenantName)
+	return r.getRecordsByPathPrefix(ctx, dictionary, className, tenantName)
 }
 
 // getRecordsByPathPrefix 根据路径前缀获取记录
-func (r *weaviateWrapper) getRecordsByPathPrefix(ctx context.Context, pathPrefix string, tenantName string) ([]*types.CodebaseRecord, error) {
+func (r *weaviateWrapper) getRecordsByPathPrefix(ctx context.Context, pathPrefix string, className, tenantName string) ([]*types.CodebaseRecord, error) {
 	// 确保路径前缀以/结尾，以便正确匹配子目录和文件
 	if pathPrefix != "" && !strings.HasSuffix(pathPrefix, "/") {
 		pathPrefix += "/"
@@ -1447,7 +1926,7 @@ func (r *weaviateWrapper) getRecordsByPathPrefix(ctx context.Context, pathPrefix
 
 	// 执行查询
 	res, err := r.client.GraphQL().Get().
-		WithClassName(r.className).
+		WithClassName(className).
 		WithFields(fields...).
 		WithWhere(filter).
 		WithTenant(tenantName).
@@ -1462,7 +1941,7 @@ func (r *weaviateWrapper) getRecordsByPathPrefix(ctx context.Context, pathPrefix
 	}
 
 	// 解析响应获取记录
-	return r.unmarshalRecordsResponse(res)
+	return r.unmarshalRecordsResponse(res, className)
 }
 
 // DeleteDictionary 删除指定目录的记录，通过匹配filePath的前缀
@@ -1472,6 +1951,7 @@ func (r *weaviateWrapper) DeleteDictionary(ctx context.Context, dictionary strin
 	if err != nil {
 		return fmt.Errorf("failed to generate tenant name: %w", err)
 	}
+	className, _ := r.tenantClass(ctx, tenantName)
 
 	// 确保路径前缀以/结尾，以便正确匹配子目录和文件
 	if dictionary != "" && !strings.HasSuffix(dictionary, "/") {
@@ -1479,7 +1959,7 @@ func (r *weaviateWrapper) DeleteDictionary(ctx context.Context, dictionary strin
 	}
 
 	// 获取所有匹配目录前缀的记录
-	records, err := r.getRecordsByPathPrefix(ctx, dictionary, tenantName)
+	records, err := r.getRecordsByPathPrefix(ctx, dictionary, className, tenantName)
 	if err != nil {
 		return fmt.Errorf("failed to get records by path prefix: %w", err)
 	}
@@ -1527,7 +2007,7 @@ func (r *weaviateWrapper) DeleteDictionary(ctx context.Context, dictionary strin
 		do, err := r.client.Batch().ObjectsBatchDeleter().
 			WithTenant(tenantName).WithWhere(
 			combinedFilter,
-		).WithClassName(r.className).Do(ctx)
+		).WithClassName(className).Do(ctx)
 		if err != nil {
 			return fmt.Errorf("failed to send delete chunks err:%w", err)
 		}
@@ -1545,6 +2025,7 @@ func (r *weaviateWrapper) UpdateCodeChunksDictionary(ctx context.Context, client
 	if err != nil {
 		return fmt.Errorf("failed to generate tenant name: %w", err)
 	}
+	className, _ := r.tenantClass(ctx, tenantName)
 
 	// 确保路径前缀以/结尾，以便正确匹配子目录和文件
 	if dictionary != "" && !strings.HasSuffix(dictionary, "/") {
@@ -1555,7 +2036,7 @@ func (r *weaviateWrapper) UpdateCodeChunksDictionary(ctx context.Context, client
 	}
 
 	// 获取所有匹配原目录前缀的记录
-	records, err := r.getRecordsByPathPrefix(ctx, dictionary, tenantName)
+	records, err := r.getRecordsByPathPrefix(ctx, dictionary, className, tenantName)
 	if err != nil {
 		return fmt.Errorf("failed to get records by path prefix: %w", err)
 	}
@@ -1571,14 +2052,14 @@ func (r *weaviateWrapper) UpdateCodeChunksDictionary(ctx context.Context, client
 		newFilePath := strings.Replace(record.FilePath, dictionary, newDictionary, 1)
 
 		// 获取对象的ID
-		objectIds, err := r.getObjectIdsByPath(ctx, record.CodebaseId, record.FilePath, tenantName)
+		objectIds, err := r.getObjectIdsByPath(ctx, record.CodebaseId, record.FilePath, className, tenantName)
 		if err != nil {
 			return fmt.Errorf("failed to get object ids by path: %w", err)
 		}
 
 		// 更新每个对象的路径
 		for _, objectId := range objectIds {
-			err = r.updateObjectPath(ctx, objectId, newFilePath, tenantName, record)
+			err = r.updateObjectPath(ctx, objectId, newFilePath, className, tenantName, record)
 			if err != nil {
 				return fmt.Errorf("failed to update object path: %w", err)
 			}
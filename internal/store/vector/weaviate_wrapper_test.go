@@ -0,0 +1,62 @@
+package vector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+func TestKeywordScore(t *testing.T) {
+	assert.Equal(t, float32(0), keywordScore(0, 5))
+	assert.Equal(t, float32(0.5), keywordScore(5, 5))
+	assert.Equal(t, float32(0.5), keywordScore(5, 0))
+	// 弱匹配即使排在第一位分数也较低，能被分数阈值过滤
+	assert.Less(t, keywordScore(1, 5), float32(0.3))
+	assert.Greater(t, keywordScore(20, 5), keywordScore(10, 5))
+}
+
+func TestFetchChunkContents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req CodeSnippetsBatchRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		if assert.Len(t, req.CodeSnippets, 1) {
+			assert.Equal(t, "/repo/a.go", req.CodeSnippets[0].FilePath)
+		}
+		resp := CodeSnippetsBatchResponse{Success: true}
+		resp.Data.List = []CodeSnippetResponse{{FilePath: "/repo/a.go", StartLine: 1, EndLine: 3, Content: "func a() {}"}}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	stored := &types.CodeChunk{FilePath: "b.go", Content: []byte("stored"), Range: []int{1, 0, 2, 0}}
+	missing := &types.CodeChunk{FilePath: "a.go", Range: []int{1, 0, 3, 0}}
+	options := Options{ClientId: "c1", CodebasePath: "/repo", Authorization: "Bearer token"}
+	err := FetchChunkContents(context.Background(), config.VectorStoreConf{BaseURL: server.URL}, options, []*types.CodeChunk{stored, missing})
+	assert.NoError(t, err)
+	assert.Equal(t, "stored", string(stored.Content))
+	assert.Equal(t, "func a() {}", string(missing.Content))
+
+	missing.Content = nil
+	err = FetchChunkContents(context.Background(), config.VectorStoreConf{}, options, []*types.CodeChunk{missing})
+	assert.Error(t, err)
+}
+
+func TestClientFetchable(t *testing.T) {
+	assert.True(t, clientFetchable(map[string]interface{}{}, "src/main.py"))
+	assert.False(t, clientFetchable(map[string]interface{}{MetadataSummaryLevel: types.SummaryLevelFile}, "src/main.py"))
+	// notebook 分块的行号是单元格拼接视图的行号，按行号获取会得到原始 JSON 片段
+	assert.False(t, clientFetchable(map[string]interface{}{MetadataCellIndex: float64(2)}, "analysis.ipynb"))
+	assert.False(t, clientFetchable(map[string]interface{}{}, "analysis.ipynb"))
+
+	notebook := &types.CodeChunk{FilePath: "analysis.ipynb", Range: []int{3, 0, 8, 0}}
+	err := FetchChunkContents(context.Background(), config.VectorStoreConf{}, Options{CodebasePath: "/repo"}, []*types.CodeChunk{notebook})
+	assert.NoError(t, err)
+	assert.Empty(t, notebook.Content)
+}
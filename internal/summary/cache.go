@@ -0,0 +1,45 @@
+package summary
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+const cacheKeyPrefix = "chunk:summary:"
+
+// Cache 按分块内容哈希缓存摘要，重新同步未修改的代码时不再调用大模型
+type Cache interface {
+	Get(ctx context.Context, key string) (string, bool)
+	Set(ctx context.Context, key, summary string)
+}
+
+type redisCache struct {
+	client     *redis.Client
+	expiration time.Duration
+}
+
+// NewRedisCache 创建基于 Redis 的摘要缓存，缓存读写失败只记录日志
+func NewRedisCache(client *redis.Client, expiration time.Duration) Cache {
+	return &redisCache{client: client, expiration: expiration}
+}
+
+func (c *redisCache) Get(ctx context.Context, key string) (string, bool) {
+	summary, err := c.client.Get(ctx, cacheKeyPrefix+key).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logx.WithContext(ctx).Errorf("failed to get chunk summary cache: %v", err)
+		}
+		return "", false
+	}
+	return summary, true
+}
+
+func (c *redisCache) Set(ctx context.Context, key, summary string) {
+	if err := c.client.Set(ctx, cacheKeyPrefix+key, summary, c.expiration).Err(); err != nil {
+		logx.WithContext(ctx).Errorf("failed to set chunk summary cache: %v", err)
+	}
+}
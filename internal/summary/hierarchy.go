@@ -0,0 +1,223 @@
+package summary
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+// DefaultFilePrompt 文件摘要的默认系统提示词
+const DefaultFilePrompt = "You write overviews of source files for engineers who are new to a codebase. " +
+	"Given a file path and summaries or excerpts of the code it contains, describe in one short paragraph of plain English " +
+	"what the file is responsible for, its main types and functions, and how it is used. Do not use lists or markdown."
+
+// DefaultDirectoryPrompt 目录和代码库摘要的默认系统提示词
+const DefaultDirectoryPrompt = "You write overviews of directories for engineers who are new to a codebase. " +
+	"Given a directory path and summaries of the files and subdirectories it contains, describe in one short paragraph " +
+	"of plain English what the directory is responsible for and how its parts fit together. Do not use lists or markdown."
+
+// repoPath 代码库摘要的路径
+const repoPath = "."
+
+// HierarchyOptions 层级摘要的提示词和输入限制
+type HierarchyOptions struct {
+	FilePrompt      string // 为空时使用 DefaultFilePrompt
+	DirectoryPrompt string // 为空时使用 DefaultDirectoryPrompt
+	MaxInputTokens  int    // 单次请求输入的最大 token 数，0 表示不限制
+}
+
+// FileInput 生成文件摘要的输入
+type FileInput struct {
+	Path  string
+	Texts []string // 分块摘要，未生成摘要的分块使用分块内容
+}
+
+// PathSummary 文件、目录或代码库的摘要
+type PathSummary struct {
+	Path    string // 使用 / 分隔的代码库相对路径，代码库为 "."
+	Level   string // types.SummaryLevelFile、SummaryLevelDirectory 或 SummaryLevelRepo
+	Summary string
+}
+
+// BuildHierarchy 自底向上生成层级摘要：由分块生成文件摘要，由子文件和子目录的摘要逐层生成目录摘要，最后生成代码库摘要。
+// 失败的节点被跳过，上层使用其余子节点的摘要；返回已生成的摘要和所有失败的合并错误
+func (s *Summarizer) BuildHierarchy(ctx context.Context, files []FileInput, opts HierarchyOptions) ([]PathSummary, error) {
+	filePrompt := cmp.Or(opts.FilePrompt, DefaultFilePrompt)
+	dirPrompt := cmp.Or(opts.DirectoryPrompt, DefaultDirectoryPrompt)
+
+	var (
+		mu        sync.Mutex
+		errs      []error
+		summaries = make(map[string]string)
+		texts     = make(map[string][]string, len(files))
+		children  = make(map[string][]string) // 目录 -> 子文件和子目录路径
+		filePaths []string
+	)
+	for _, file := range files {
+		p := cleanPath(file.Path)
+		if p == repoPath {
+			continue
+		}
+		if _, ok := texts[p]; !ok {
+			filePaths = append(filePaths, p)
+			addToParents(children, p)
+		}
+		texts[p] = append(texts[p], file.Texts...)
+	}
+	slices.Sort(filePaths)
+
+	generate := func(p, prompt, input string) {
+		summary, err := s.Generate(ctx, prompt, truncateTokens(input, opts.MaxInputTokens))
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p, err))
+			return
+		}
+		summaries[p] = summary
+	}
+
+	s.forEach(filePaths, func(p string) {
+		if len(texts[p]) == 0 {
+			return
+		}
+		generate(p, filePrompt, fmt.Sprintf("File: %s\n\n%s", p, strings.Join(texts[p], "\n\n")))
+	})
+
+	// 按深度由深到浅逐层生成目录摘要，同一层的目录并发生成
+	byDepth := make(map[int][]string)
+	maxDepth := 0
+	for dir := range children {
+		depth := pathDepth(dir)
+		byDepth[depth] = append(byDepth[depth], dir)
+		maxDepth = max(maxDepth, depth)
+	}
+	for depth := maxDepth; depth >= 0; depth-- {
+		dirs := byDepth[depth]
+		slices.Sort(dirs)
+		s.forEach(dirs, func(dir string) {
+			mu.Lock()
+			input, ok := directoryInput(dir, children, summaries)
+			mu.Unlock()
+			if ok {
+				generate(dir, dirPrompt, input)
+			}
+		})
+	}
+
+	result := make([]PathSummary, 0, len(summaries))
+	for _, p := range filePaths {
+		if summary, ok := summaries[p]; ok {
+			result = append(result, PathSummary{Path: p, Level: types.SummaryLevelFile, Summary: summary})
+		}
+	}
+	dirs := make([]string, 0, len(children))
+	for dir := range children {
+		dirs = append(dirs, dir)
+	}
+	slices.Sort(dirs)
+	for _, dir := range dirs {
+		summary, ok := summaries[dir]
+		if !ok {
+			continue
+		}
+		level := types.SummaryLevelDirectory
+		if dir == repoPath {
+			level = types.SummaryLevelRepo
+		}
+		result = append(result, PathSummary{Path: dir, Level: level, Summary: summary})
+	}
+	return result, errors.Join(errs...)
+}
+
+// directoryInput 由子节点摘要构造目录摘要的输入，没有任何子节点摘要时不生成
+func directoryInput(dir string, children map[string][]string, summaries map[string]string) (string, bool) {
+	var sb strings.Builder
+	if dir == repoPath {
+		sb.WriteString("Repository root\n")
+	} else {
+		fmt.Fprintf(&sb, "Directory: %s\n", dir)
+	}
+	summarized := false
+	entries := slices.Sorted(slices.Values(children[dir]))
+	for _, entry := range entries {
+		name := path.Base(entry)
+		if _, isDir := children[entry]; isDir {
+			name += "/"
+		}
+		summary, ok := summaries[entry]
+		if !ok {
+			fmt.Fprintf(&sb, "\n- %s", name)
+			continue
+		}
+		summarized = true
+		fmt.Fprintf(&sb, "\n- %s: %s", name, summary)
+	}
+	return sb.String(), summarized
+}
+
+// forEach 以与并发请求数相同的协程数处理路径，避免为大量文件同时创建协程
+func (s *Summarizer) forEach(paths []string, fn func(p string)) {
+	ch := make(chan string)
+	var wg sync.WaitGroup
+	for range min(cap(s.sem), len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range ch {
+				fn(p)
+			}
+		}()
+	}
+	for _, p := range paths {
+		ch <- p
+	}
+	close(ch)
+	wg.Wait()
+}
+
+// addToParents 将文件及其各级父目录登记到父目录的子节点中
+func addToParents(children map[string][]string, p string) {
+	for p != repoPath {
+		parent := path.Dir(p)
+		_, seen := children[parent]
+		children[parent] = append(children[parent], p)
+		if seen {
+			// 父目录已登记，其上级目录也已登记
+			return
+		}
+		p = parent
+	}
+}
+
+// cleanPath 统一为 / 分隔的相对路径
+func cleanPath(p string) string {
+	p = path.Clean(strings.ReplaceAll(p, "\\", "/"))
+	return cmp.Or(strings.TrimPrefix(p, "/"), repoPath)
+}
+
+func pathDepth(p string) int {
+	if p == repoPath {
+		return 0
+	}
+	return strings.Count(p, "/") + 1
+}
+
+// truncateTokens 按约 4 字符/token 截断输入
+func truncateTokens(text string, maxTokens int) string {
+	if maxTokens <= 0 {
+		return text
+	}
+	runes := []rune(text)
+	if len(runes) <= maxTokens*4 {
+		return text
+	}
+	return string(runes[:maxTokens*4]) + "\n..."
+}
@@ -0,0 +1,229 @@
+package summary
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+	"github.com/zgsm-ai/codebase-indexer/internal/usage"
+)
+
+// DefaultPrompt 默认的系统提示词
+const DefaultPrompt = "You document source code for a code search engine. " +
+	"Describe in one short paragraph of plain English what the given function or class does: its purpose, " +
+	"important inputs and outputs, and notable side effects such as I/O, locking, caching or rate limiting. " +
+	"Use the domain terms a developer would search for. Do not repeat the code, do not use lists or markdown."
+
+// summaryHeaderPrefix 摘要在嵌入上下文头中的前缀
+const summaryHeaderPrefix = "Summary: "
+
+// Summarizer 调用 OpenAI 兼容的对话接口为函数、类分块生成摘要，
+// 所有任务共享 MaxConcurrency 个并发请求，内容相同的分块复用缓存的摘要
+type Summarizer struct {
+	conf   config.SummaryConf
+	prompt string
+	client *http.Client
+	cache  Cache
+	sem    chan struct{}
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Temperature float32       `json:"temperature"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens int `json:"prompt_tokens"`
+		TotalTokens  int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// New 创建摘要生成器，cache 为空时不缓存
+func New(c config.SummaryConf, cache Cache) *Summarizer {
+	return &Summarizer{
+		conf:   c,
+		prompt: cmp.Or(c.Prompt, DefaultPrompt),
+		client: &http.Client{Timeout: c.Timeout},
+		cache:  cache,
+		sem:    make(chan struct{}, max(c.MaxConcurrency, 1)),
+	}
+}
+
+// Enabled 是否开启摘要，未创建摘要生成器时为 false
+func (s *Summarizer) Enabled() bool {
+	return s != nil && s.conf.Enabled
+}
+
+// Summarize 为分块中的函数、类分块生成摘要，写入 Summary 并拼接到嵌入上下文头；
+// 单个分块失败不影响其他分块，返回所有失败的合并错误
+func (s *Summarizer) Summarize(ctx context.Context, chunks []*types.CodeChunk) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, chunk := range chunks {
+		if !s.eligible(chunk) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			summary, err := s.summarize(ctx, chunk)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s:%d: %w", chunk.FilePath, chunkStartLine(chunk), err))
+				mu.Unlock()
+				return
+			}
+			applySummary(chunk, summary)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// eligible 只为由函数、类等声明节点切分出的分块生成摘要，跳过生成代码、第三方代码和过小的分块
+func (s *Summarizer) eligible(chunk *types.CodeChunk) bool {
+	return chunk.NodeKind != "" && !chunk.Generated && !chunk.Vendored && chunk.TokenCount >= s.conf.MinTokens
+}
+
+func (s *Summarizer) summarize(ctx context.Context, chunk *types.CodeChunk) (string, error) {
+	// 缓存键只取决于分块内容，不同文件中相同的代码复用摘要
+	input := fmt.Sprintf("File: %s\n\n%s", chunk.FilePath, chunk.Content)
+	return s.generate(ctx, s.prompt, input, s.cacheKey(s.prompt, string(chunk.Content)))
+}
+
+// Generate 使用指定的系统提示词生成摘要，结果按提示词和输入缓存，与分块摘要共享并发限制
+func (s *Summarizer) Generate(ctx context.Context, prompt, input string) (string, error) {
+	return s.generate(ctx, prompt, input, s.cacheKey(prompt, input))
+}
+
+func (s *Summarizer) generate(ctx context.Context, prompt, input, key string) (string, error) {
+	if s.cache != nil {
+		if summary, ok := s.cache.Get(ctx, key); ok {
+			return summary, nil
+		}
+	}
+
+	select {
+	case s.sem <- struct{}{}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	summary, err := s.complete(ctx, prompt, input)
+	<-s.sem
+	if err != nil {
+		return "", err
+	}
+
+	if s.cache != nil {
+		s.cache.Set(ctx, key, summary)
+	}
+	return summary, nil
+}
+
+// cacheKey 摘要缓存键，由模型、提示词和输入内容共同决定
+func (s *Summarizer) cacheKey(prompt, content string) string {
+	h := sha256.New()
+	h.Write([]byte(s.conf.Model))
+	h.Write([]byte{0})
+	h.Write([]byte(prompt))
+	h.Write([]byte{0})
+	h.Write([]byte(content))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// complete 调用对话接口生成摘要
+func (s *Summarizer) complete(ctx context.Context, prompt, input string) (string, error) {
+	body, err := json.Marshal(&chatRequest{
+		Model: s.conf.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: prompt},
+			{Role: "user", Content: input},
+		},
+		MaxTokens: s.conf.MaxTokens,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal summary request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.conf.APIBase, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create summary request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.conf.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.conf.APIKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send summary request to %s: %w", s.conf.APIBase, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		errorBody := new(bytes.Buffer)
+		_, _ = errorBody.ReadFrom(resp.Body)
+		return "", fmt.Errorf("summary API returned non-OK status %d, body: %s", resp.StatusCode, errorBody.String())
+	}
+
+	var respBody chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return "", fmt.Errorf("failed to decode summary response body: %w", err)
+	}
+	if len(respBody.Choices) == 0 {
+		return "", fmt.Errorf("summary API returned no choices")
+	}
+	summary := strings.Join(strings.Fields(respBody.Choices[0].Message.Content), " ")
+	if summary == "" {
+		return "", fmt.Errorf("summary API returned empty content")
+	}
+
+	if respBody.Usage.TotalTokens > 0 {
+		usage.Report(ctx, usage.TypeSummary, s.conf.Model, respBody.Usage.PromptTokens, respBody.Usage.TotalTokens, false)
+	} else {
+		promptTokens := usage.EstimateTokens(prompt, input)
+		usage.Report(ctx, usage.TypeSummary, s.conf.Model, promptTokens, promptTokens+usage.EstimateTokens(summary), true)
+	}
+	return summary, nil
+}
+
+// applySummary 写入摘要，并将其拼接到上下文头，使自然语言描述参与生成向量
+func applySummary(chunk *types.CodeChunk, summary string) {
+	chunk.Summary = summary
+	if chunk.EmbeddingHeader == "" {
+		chunk.EmbeddingHeader = summaryHeaderPrefix + summary
+		return
+	}
+	chunk.EmbeddingHeader += "\n" + summaryHeaderPrefix + summary
+}
+
+func chunkStartLine(chunk *types.CodeChunk) int {
+	if len(chunk.Range) > 0 {
+		return chunk.Range[0] + 1
+	}
+	return 0
+}
@@ -0,0 +1,154 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/types"
+)
+
+type memoryCache struct {
+	sync.Map
+}
+
+func (c *memoryCache) Get(_ context.Context, key string) (string, bool) {
+	v, ok := c.Load(key)
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+func (c *memoryCache) Set(_ context.Context, key, summary string) {
+	c.Store(key, summary)
+}
+
+// newStubServer 模拟 OpenAI 兼容的对话接口，返回用户消息的长度作为摘要
+func newStubServer(t *testing.T, calls, inflight, peak *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		n := atomic.AddInt32(inflight, 1)
+		defer atomic.AddInt32(inflight, -1)
+		for {
+			p := atomic.LoadInt32(peak)
+			if n <= p || atomic.CompareAndSwapInt32(peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		var req chatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "summary-model", req.Model)
+		require.Len(t, req.Messages, 2)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]any{
+				"role":    "assistant",
+				"content": "Throttles token\n issuance for " + req.Messages[1].Content[len("File: "):len("File: a.go")],
+			}}},
+		})
+	}))
+}
+
+func TestSummarize(t *testing.T) {
+	var calls, inflight, peak int32
+	server := newStubServer(t, &calls, &inflight, &peak)
+	defer server.Close()
+
+	s := New(config.SummaryConf{Enabled: true, APIBase: server.URL, Model: "summary-model", MaxConcurrency: 2, Timeout: time.Second}, &memoryCache{})
+	var chunks []*types.CodeChunk
+	for i := range 6 {
+		chunks = append(chunks, &types.CodeChunk{FilePath: "a.go", NodeKind: "function_declaration", Content: []byte{byte('a' + i)}})
+	}
+	chunks[0].EmbeddingHeader = "File: a.go"
+	remainder := &types.CodeChunk{FilePath: "a.go", Content: []byte("var x = 1")}
+	generated := &types.CodeChunk{FilePath: "a.go", NodeKind: "function_declaration", Content: []byte("g"), Generated: true}
+
+	require.NoError(t, s.Summarize(context.Background(), append(chunks, remainder, generated)))
+	assert.EqualValues(t, 6, calls)
+	assert.LessOrEqual(t, peak, int32(2))
+	assert.Equal(t, "Throttles token issuance for a.go", chunks[0].Summary)
+	assert.Equal(t, "File: a.go\nSummary: Throttles token issuance for a.go", chunks[0].EmbeddingHeader)
+	assert.Equal(t, "Summary: Throttles token issuance for a.go", chunks[1].EmbeddingHeader)
+	assert.Empty(t, remainder.Summary)
+	assert.Empty(t, generated.Summary)
+
+	// 内容相同的分块命中缓存
+	again := &types.CodeChunk{FilePath: "b.go", NodeKind: "method_declaration", Content: []byte("a")}
+	require.NoError(t, s.Summarize(context.Background(), []*types.CodeChunk{again}))
+	assert.EqualValues(t, 6, calls)
+	assert.Equal(t, chunks[0].Summary, again.Summary)
+}
+
+func TestSummarizeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	s := New(config.SummaryConf{Enabled: true, APIBase: server.URL, MaxConcurrency: 1, Timeout: time.Second}, nil)
+	chunk := &types.CodeChunk{FilePath: "a.go", NodeKind: "function_declaration", Content: []byte("func a() {}"), Range: []int{9, 0, 9, 11}}
+	err := s.Summarize(context.Background(), []*types.CodeChunk{chunk})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a.go:10")
+	assert.Empty(t, chunk.Summary)
+	assert.Empty(t, chunk.EmbeddingHeader)
+}
+
+func TestBuildHierarchy(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		inputs = make(map[string]string)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		input := req.Messages[1].Content
+		title, _, _ := strings.Cut(input, "\n")
+		mu.Lock()
+		inputs[title] = input
+		mu.Unlock()
+		if strings.Contains(input, "broken") {
+			http.Error(w, "bad input", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]any{"content": "about " + title}}},
+		})
+	}))
+	defer server.Close()
+
+	s := New(config.SummaryConf{APIBase: server.URL, MaxConcurrency: 3, Timeout: time.Second}, nil)
+	summaries, err := s.BuildHierarchy(context.Background(), []FileInput{
+		{Path: "internal/job/embedding.go", Texts: []string{"Runs the embedding task."}},
+		{Path: "internal\\job\\cleaner.go", Texts: []string{"broken"}},
+		{Path: "internal/job/scheduler.go"},
+		{Path: "main.go", Texts: []string{"func main() {}"}},
+	}, HierarchyOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "internal/job/cleaner.go")
+
+	assert.Equal(t, []PathSummary{
+		{Path: "internal/job/embedding.go", Level: types.SummaryLevelFile, Summary: "about File: internal/job/embedding.go"},
+		{Path: "main.go", Level: types.SummaryLevelFile, Summary: "about File: main.go"},
+		{Path: ".", Level: types.SummaryLevelRepo, Summary: "about Repository root"},
+		{Path: "internal", Level: types.SummaryLevelDirectory, Summary: "about Directory: internal"},
+		{Path: "internal/job", Level: types.SummaryLevelDirectory, Summary: "about Directory: internal/job"},
+	}, summaries)
+	// 目录摘要由子节点摘要生成，失败或没有内容的文件只列出名称
+	assert.Equal(t, "Directory: internal/job\n\n- cleaner.go\n- embedding.go: about File: internal/job/embedding.go\n- scheduler.go",
+		inputs["Directory: internal/job"])
+	assert.Equal(t, "Repository root\n\n- internal/: about Directory: internal\n- main.go: about File: main.go",
+		inputs["Repository root"])
+}
@@ -9,9 +9,14 @@ import (
 	"github.com/zgsm-ai/codebase-indexer/internal/config"
 	"github.com/zgsm-ai/codebase-indexer/internal/dao/query"
 	"github.com/zgsm-ai/codebase-indexer/internal/embedding"
+	"github.com/zgsm-ai/codebase-indexer/internal/preprocess"
+	"github.com/zgsm-ai/codebase-indexer/internal/redact"
 	"github.com/zgsm-ai/codebase-indexer/internal/store/database"
 	redisstore "github.com/zgsm-ai/codebase-indexer/internal/store/redis"
 	"github.com/zgsm-ai/codebase-indexer/internal/store/vector"
+	"github.com/zgsm-ai/codebase-indexer/internal/summary"
+	"github.com/zgsm-ai/codebase-indexer/internal/tokenizer"
+	"github.com/zgsm-ai/codebase-indexer/internal/usage"
 	"gorm.io/gorm"
 )
 
@@ -22,7 +27,12 @@ type ServiceContext struct {
 	Embedder      vector.Embedder
 	VectorStore   vector.Store
 	CodeSplitter  *embedding.CodeSplitter
+	Redactor      *redact.Redactor
+	Summarizer    *summary.Summarizer // 未开启分块摘要和层级摘要时为 nil
+	QueryPipeline *preprocess.Pipeline
 	StatusManager *redisstore.StatusManager
+	IgnoreRules   *redisstore.IgnoreRuleStore // 按代码库保存的忽略规则文件
+	Usage         *usage.Tracker
 	redisClient   *redis.Client // 保存Redis客户端引用以便关闭
 	serverContext context.Context
 	TaskPool      *ants.Pool
@@ -31,6 +41,8 @@ type ServiceContext struct {
 // Close closes the shared Redis client and database connection
 func (s *ServiceContext) Close() {
 	var errs []error
+	// 先写入缓冲的用量记录，再关闭数据库连接
+	s.Usage.Close()
 	if s.redisClient != nil {
 		if err := s.redisClient.Close(); err != nil {
 			errs = append(errs, err)
@@ -67,6 +79,7 @@ func NewServiceContext(ctx context.Context, c config.Config) (*ServiceContext, e
 
 	querier := query.Use(db)
 	svcCtx.Querier = querier
+	svcCtx.Usage = usage.NewTracker(c.Usage, querier)
 
 	// 创建Redis客户端
 	client, err := redisstore.NewRedisClient(c.Redis)
@@ -79,17 +92,57 @@ func NewServiceContext(ctx context.Context, c config.Config) (*ServiceContext, e
 	if err != nil {
 		return nil, err
 	}
-	reranker := vector.NewReranker(c.VectorStore.Reranker)
+	reranker, err := vector.NewReranker(c.VectorStore.Reranker)
+	if err != nil {
+		return nil, err
+	}
+
+	// 分块大小不能超过嵌入模型单条输入的上限，否则超出部分会被服务端截断或拒绝
+	maxTokensPerChunk := c.IndexTask.EmbeddingTask.MaxTokensPerChunk
+	if limit := embedder.MaxInputTokens(); limit > 0 && maxTokensPerChunk > limit {
+		logx.Infof("MaxTokensPerChunk %d exceeds embedder max input tokens, use %d", maxTokensPerChunk, limit)
+		maxTokensPerChunk = limit
+	}
+
+	// 分块和嵌入请求使用同一个与模型一致的分词器，Get 对相同配置只加载一次
+	tok, err := tokenizer.Get(c.VectorStore.Embedder.Tokenizer.Type, c.VectorStore.Embedder.Tokenizer.Path)
+	if err != nil {
+		return nil, err
+	}
 
-	splitter, err := embedding.NewCodeSplitter(embedding.SplitOptions{
-		MaxTokensPerChunk:          c.IndexTask.EmbeddingTask.MaxTokensPerChunk,
+	splitOptions := embedding.SplitOptions{
+		MaxTokensPerChunk:          maxTokensPerChunk,
 		SlidingWindowOverlapTokens: c.IndexTask.EmbeddingTask.OverlapTokens,
 		EnableMarkdownParsing:      c.IndexTask.EmbeddingTask.EnableMarkdownParsing,
 		EnableOpenAPIParsing:       c.IndexTask.EmbeddingTask.EnableOpenAPIParsing,
-	})
+		EnableStructuredParsing:    c.IndexTask.EmbeddingTask.EnableStructuredParsing,
+		ChunkRules:                 toChunkRuleOverrides(c.IndexTask.EmbeddingTask.ChunkRules),
+		ContextTemplate:            contextTemplate(c.IndexTask.EmbeddingTask.ContextHeader),
+		Tokenizer:                  tok,
+	}
+	if c.IndexTask.EmbeddingTask.ChunkMerge.Enabled {
+		splitOptions.MergeSmallTokens = c.IndexTask.EmbeddingTask.ChunkMerge.SmallTokens
+		splitOptions.MergeTargetTokens = c.IndexTask.EmbeddingTask.ChunkMerge.TargetTokens
+	}
+	splitter, err := embedding.NewCodeSplitter(splitOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	redactor, err := newRedactor(c.IndexTask.EmbeddingTask.Redaction)
 	if err != nil {
 		return nil, err
 	}
+	svcCtx.Redactor = redactor
+
+	// 层级摘要与分块摘要共用接口配置和并发限制，分块摘要仍以 Enabled 为准
+	if conf := c.IndexTask.EmbeddingTask.Summary; conf.Enabled || conf.Hierarchy.Enabled {
+		svcCtx.Summarizer = summary.New(conf, summary.NewRedisCache(client, conf.CacheExpiration))
+	}
+
+	if svcCtx.QueryPipeline, err = preprocess.NewPipeline(c.Query); err != nil {
+		return nil, err
+	}
 
 	// 初始化协程池
 	taskPool, err := ants.NewPool(svcCtx.Config.IndexTask.PoolSize, ants.WithOptions(
@@ -107,6 +160,7 @@ func NewServiceContext(ctx context.Context, c config.Config) (*ServiceContext, e
 	svcCtx.CodeSplitter = splitter
 	// 状态管理器 - 使用配置中的默认过期时间
 	svcCtx.StatusManager = redisstore.NewStatusManagerWithExpiration(client, c.Redis.DefaultExpiration)
+	svcCtx.IgnoreRules = redisstore.NewIgnoreRuleStore(client)
 
 	// 向量知识库
 	vectorStore, err := vector.NewVectorStoreWithStatusManager(c.VectorStore, embedder, reranker, svcCtx.StatusManager, "")
@@ -117,3 +171,46 @@ func NewServiceContext(ctx context.Context, c config.Config) (*ServiceContext, e
 
 	return svcCtx, err
 }
+
+// newRedactor 根据配置创建脱敏器，自定义规则追加在内置规则之后
+func newRedactor(c config.RedactionConf) (*redact.Redactor, error) {
+	customRules := make([]redact.Rule, 0, len(c.CustomRules))
+	for _, rc := range c.CustomRules {
+		rule, err := redact.NewRegexRule(rc.Name, rc.Pattern, rc.Group, rc.MinEntropy)
+		if err != nil {
+			return nil, err
+		}
+		customRules = append(customRules, rule)
+	}
+	return redact.New(redact.Options{
+		Enabled:          c.Enabled,
+		EntropyThreshold: c.EntropyThreshold,
+		MinSecretLength:  c.MinSecretLength,
+		DisabledRules:    c.DisabledRules,
+	}, customRules...), nil
+}
+
+// toChunkRuleOverrides 将配置中的切块规则覆盖转换为分割器选项
+func toChunkRuleOverrides(rules []config.ChunkRuleConf) []embedding.ChunkRuleOverride {
+	overrides := make([]embedding.ChunkRuleOverride, 0, len(rules))
+	for _, r := range rules {
+		overrides = append(overrides, embedding.ChunkRuleOverride{
+			Language:  r.Language,
+			Kinds:     r.Kinds,
+			MinTokens: r.MinTokens,
+			Merge:     r.Merge,
+		})
+	}
+	return overrides
+}
+
+// contextTemplate 返回启用时的上下文头模板，未配置模板时使用内置模板
+func contextTemplate(c config.ContextHeaderConf) string {
+	if !c.Enabled {
+		return ""
+	}
+	if c.Template != "" {
+		return c.Template
+	}
+	return embedding.DefaultContextTemplate
+}
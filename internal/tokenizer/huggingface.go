@@ -0,0 +1,189 @@
+package tokenizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"unicode/utf8"
+)
+
+// hfConfig tokenizer.json 中分词需要的部分
+type hfConfig struct {
+	Normalizer    *hfComponent `json:"normalizer"`
+	PreTokenizer  *hfComponent `json:"pre_tokenizer"`
+	PostProcessor *hfComponent `json:"post_processor"`
+	Model         hfModelConf  `json:"model"`
+}
+
+// hfComponent normalizer、pre_tokenizer、post_processor 的配置，按 type 区分，字段为各类型配置的并集
+type hfComponent struct {
+	Type          string        `json:"type"`
+	Normalizers   []hfComponent `json:"normalizers"`
+	Pretokenizers []hfComponent `json:"pretokenizers"`
+	Processors    []hfComponent `json:"processors"`
+	// BertNormalizer
+	CleanText    *bool `json:"clean_text"`
+	Lowercase    *bool `json:"lowercase"`
+	StripAccents *bool `json:"strip_accents"`
+	// ByteLevel、Metaspace
+	AddPrefixSpace *bool `json:"add_prefix_space"`
+	UseRegex       *bool `json:"use_regex"`
+	// Split、Replace
+	Pattern  hfPattern `json:"pattern"`
+	Behavior string    `json:"behavior"`
+	Invert   bool      `json:"invert"`
+	Content  string    `json:"content"`
+	// Metaspace、Prepend
+	Replacement   string `json:"replacement"`
+	PrependScheme string `json:"prepend_scheme"`
+	Split         *bool  `json:"split"`
+	Prepend       string `json:"prepend"`
+	// Digits
+	IndividualDigits bool `json:"individual_digits"`
+	// TemplateProcessing
+	Single []map[string]json.RawMessage `json:"single"`
+}
+
+type hfPattern struct {
+	String *string `json:"String"`
+	Regex  *string `json:"Regex"`
+}
+
+// huggingFace 按 tokenizer.json 配置实现的分词器，流程为：预分词（在原文上切分并记录位置）→ 逐段规范化 → 模型分词。
+// 不支持 added_tokens 的切分，文本中的特殊 token 字面量按普通文本分词。
+type huggingFace struct {
+	normalizer    normalizer
+	preTokenizer  preTokenizer
+	model         model
+	specialTokens int // 后处理添加的特殊 token 数
+}
+
+// LoadHuggingFace 从本地 HuggingFace tokenizer.json 加载分词器，
+// 支持 BPE（含 byte-level 和 byte fallback）、WordPiece、Unigram 模型
+func LoadHuggingFace(path string) (Tokenizer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokenizer file %s: %w", path, err)
+	}
+	t, err := parseHuggingFace(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tokenizer file %s: %w", path, err)
+	}
+	return t, nil
+}
+
+func parseHuggingFace(data []byte) (*huggingFace, error) {
+	var conf hfConfig
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return nil, err
+	}
+	t := &huggingFace{}
+	var err error
+	if t.normalizer, err = newNormalizer(conf.Normalizer); err != nil {
+		return nil, err
+	}
+	if t.preTokenizer, err = newPreTokenizer(conf.PreTokenizer); err != nil {
+		return nil, err
+	}
+	if t.model, err = newModel(&conf.Model); err != nil {
+		return nil, err
+	}
+	if t.specialTokens, err = countSpecialTokens(conf.PostProcessor); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// countSpecialTokens 统计单条输入经后处理添加的特殊 token 数
+func countSpecialTokens(c *hfComponent) (int, error) {
+	if c == nil {
+		return 0, nil
+	}
+	switch c.Type {
+	case "BertProcessing", "RobertaProcessing":
+		return 2, nil
+	case "TemplateProcessing":
+		n := 0
+		for _, piece := range c.Single {
+			if _, ok := piece["SpecialToken"]; ok {
+				n++
+			}
+		}
+		return n, nil
+	case "ByteLevel":
+		return 0, nil
+	case "Sequence":
+		n := 0
+		for i := range c.Processors {
+			m, err := countSpecialTokens(&c.Processors[i])
+			if err != nil {
+				return 0, err
+			}
+			n += m
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("unsupported post processor %q", c.Type)
+	}
+}
+
+func (t *huggingFace) Count(text string) (int, error) {
+	count := t.specialTokens
+	t.tokenize(text, func(_ span, lengths []int) {
+		count += len(lengths)
+	})
+	return count, nil
+}
+
+func (t *huggingFace) Split(text string) ([]string, error) {
+	var (
+		pieces []string
+		prev   int // 已输出片段的结束位置
+	)
+	t.tokenize(text, func(word span, lengths []int) {
+		total := 0
+		for _, n := range lengths {
+			total += n
+		}
+		// 按 token 在分词文本中的位置比例映射回原文，预分词丢弃的空白并入其后的片段
+		consumed := 0
+		for i, n := range lengths {
+			consumed += n
+			end := word.end
+			if i < len(lengths)-1 && total > 0 {
+				end = alignRuneStart(text, word.start+(word.end-word.start)*consumed/total)
+			}
+			end = max(end, prev)
+			pieces = append(pieces, text[prev:end])
+			prev = end
+		}
+	})
+	if prev < len(text) {
+		if len(pieces) == 0 {
+			return []string{text}, nil
+		}
+		pieces[len(pieces)-1] += text[prev:]
+	}
+	return pieces, nil
+}
+
+// tokenize 对文本分词，每个预分词段回调一次，lengths 为各 token 覆盖的分词文本字符数
+func (t *huggingFace) tokenize(text string, yield func(word span, lengths []int)) {
+	for _, word := range t.preTokenizer.split(text, []span{{start: 0, end: len(text)}}) {
+		first := word.start == 0
+		s := t.normalizer.normalize(text[word.start:word.end], first)
+		s = t.preTokenizer.transform(s, first)
+		if s == "" {
+			continue
+		}
+		yield(word, t.model.tokenize([]rune(s)))
+	}
+}
+
+// alignRuneStart 将字节位置向后对齐到字符起始位置
+func alignRuneStart(text string, i int) int {
+	for i < len(text) && !utf8.RuneStart(text[i]) {
+		i++
+	}
+	return i
+}
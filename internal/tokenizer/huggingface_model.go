@@ -0,0 +1,340 @@
+package tokenizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// model 分词模型，返回各 token 覆盖的字符数，字符数之和等于输入长度
+type model interface {
+	tokenize(word []rune) []int
+}
+
+// hfModelConf tokenizer.json 中 model 的配置，字段为 BPE、WordPiece、Unigram 配置的并集
+type hfModelConf struct {
+	Type                    string          `json:"type"`
+	Vocab                   json.RawMessage `json:"vocab"`
+	Merges                  json.RawMessage `json:"merges"`
+	UnkToken                *string         `json:"unk_token"`
+	UnkID                   *int            `json:"unk_id"`
+	FuseUnk                 *bool           `json:"fuse_unk"`
+	ContinuingSubwordPrefix *string         `json:"continuing_subword_prefix"`
+	EndOfWordSuffix         *string         `json:"end_of_word_suffix"`
+	ByteFallback            bool            `json:"byte_fallback"`
+	IgnoreMerges            bool            `json:"ignore_merges"`
+	MaxInputCharsPerWord    int             `json:"max_input_chars_per_word"`
+}
+
+func newModel(c *hfModelConf) (model, error) {
+	typ := c.Type
+	if typ == "" {
+		// 旧版 tokenizer.json 可能不写 type，按 vocab、merges 的形式推断
+		switch {
+		case len(c.Vocab) > 0 && c.Vocab[0] == '[':
+			typ = "Unigram"
+		case len(c.Merges) > 0:
+			typ = "BPE"
+		default:
+			typ = "WordPiece"
+		}
+	}
+	switch typ {
+	case "BPE":
+		return newBPE(c)
+	case "WordPiece":
+		return newWordPiece(c)
+	case "Unigram":
+		return newUnigram(c)
+	default:
+		return nil, fmt.Errorf("unsupported model %q", typ)
+	}
+}
+
+// bpe 按合并规则优先级逐步合并相邻符号
+type bpe struct {
+	vocab        map[string]int
+	ranks        map[[2]string]int
+	hasUnk       bool
+	fuseUnk      bool
+	prefix       string
+	suffix       string
+	byteFallback bool
+	ignoreMerges bool
+}
+
+func newBPE(c *hfModelConf) (*bpe, error) {
+	m := &bpe{
+		ranks:        make(map[[2]string]int),
+		fuseUnk:      boolOr(c.FuseUnk, false),
+		byteFallback: c.ByteFallback,
+		ignoreMerges: c.IgnoreMerges,
+	}
+	if err := json.Unmarshal(c.Vocab, &m.vocab); err != nil {
+		return nil, fmt.Errorf("invalid BPE vocab: %w", err)
+	}
+	if c.UnkToken != nil {
+		_, m.hasUnk = m.vocab[*c.UnkToken]
+	}
+	if c.ContinuingSubwordPrefix != nil {
+		m.prefix = *c.ContinuingSubwordPrefix
+	}
+	if c.EndOfWordSuffix != nil {
+		m.suffix = *c.EndOfWordSuffix
+	}
+	merges, err := parseMerges(c.Merges)
+	if err != nil {
+		return nil, err
+	}
+	for i, pair := range merges {
+		if _, ok := m.ranks[pair]; !ok {
+			m.ranks[pair] = i
+		}
+	}
+	return m, nil
+}
+
+// parseMerges 解析合并规则，兼容 "a b" 字符串和 ["a", "b"] 数组两种格式
+func parseMerges(data json.RawMessage) ([][2]string, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	var pairs [][2]string
+	if err := json.Unmarshal(data, &pairs); err == nil {
+		return pairs, nil
+	}
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err != nil {
+		return nil, fmt.Errorf("invalid BPE merges: %w", err)
+	}
+	pairs = make([][2]string, 0, len(lines))
+	for _, line := range lines {
+		a, b, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("invalid BPE merge %q", line)
+		}
+		pairs = append(pairs, [2]string{a, b})
+	}
+	return pairs, nil
+}
+
+type bpeSymbol struct {
+	text  string // 含前缀、后缀的词表文本
+	runes int    // 覆盖的字符数
+}
+
+func (m *bpe) tokenize(word []rune) []int {
+	if m.ignoreMerges {
+		if _, ok := m.vocab[string(word)]; ok {
+			return []int{len(word)}
+		}
+	}
+	symbols := make([]bpeSymbol, len(word))
+	for i, r := range word {
+		text := string(r)
+		if i > 0 {
+			text = m.prefix + text
+		}
+		if i == len(word)-1 {
+			text += m.suffix
+		}
+		symbols[i] = bpeSymbol{text: text, runes: 1}
+	}
+
+	for len(symbols) > 1 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i+1 < len(symbols); i++ {
+			if rank, ok := m.ranks[[2]string{symbols[i].text, symbols[i+1].text}]; ok && rank < bestRank {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		// 合并后的符号只在词首保留前缀
+		merged := symbols[best].text + strings.TrimPrefix(symbols[best+1].text, m.prefix)
+		symbols[best] = bpeSymbol{text: merged, runes: symbols[best].runes + symbols[best+1].runes}
+		symbols = append(symbols[:best+1], symbols[best+2:]...)
+	}
+
+	lengths := make([]int, 0, len(symbols))
+	unk := false
+	for _, s := range symbols {
+		if _, ok := m.vocab[s.text]; ok {
+			lengths = append(lengths, s.runes)
+			unk = false
+			continue
+		}
+		if m.byteFallback {
+			// 每个字节编码为 <0xXX>，字符数计在第一个字节上
+			for i := 0; i < len(s.text); i++ {
+				if i == 0 {
+					lengths = append(lengths, s.runes)
+				} else {
+					lengths = append(lengths, 0)
+				}
+			}
+			unk = false
+			continue
+		}
+		if !m.hasUnk {
+			// 没有未知 token 时该符号被丢弃，字符数计入前一个 token
+			if len(lengths) > 0 {
+				lengths[len(lengths)-1] += s.runes
+			} else {
+				lengths = append(lengths, s.runes)
+			}
+			continue
+		}
+		if unk && m.fuseUnk {
+			lengths[len(lengths)-1] += s.runes
+			continue
+		}
+		lengths = append(lengths, s.runes)
+		unk = true
+	}
+	return lengths
+}
+
+// wordPiece 从左到右贪心匹配最长的词表项，非词首的词表项带有 ## 前缀
+type wordPiece struct {
+	vocab   map[string]int
+	prefix  string
+	maxRune int
+}
+
+func newWordPiece(c *hfModelConf) (*wordPiece, error) {
+	m := &wordPiece{prefix: "##", maxRune: 100}
+	if err := json.Unmarshal(c.Vocab, &m.vocab); err != nil {
+		return nil, fmt.Errorf("invalid WordPiece vocab: %w", err)
+	}
+	if c.ContinuingSubwordPrefix != nil {
+		m.prefix = *c.ContinuingSubwordPrefix
+	}
+	if c.MaxInputCharsPerWord > 0 {
+		m.maxRune = c.MaxInputCharsPerWord
+	}
+	return m, nil
+}
+
+func (m *wordPiece) tokenize(word []rune) []int {
+	if len(word) > m.maxRune {
+		return []int{len(word)}
+	}
+	var lengths []int
+	for start := 0; start < len(word); {
+		end := len(word)
+		for ; end > start; end-- {
+			piece := string(word[start:end])
+			if start > 0 {
+				piece = m.prefix + piece
+			}
+			if _, ok := m.vocab[piece]; ok {
+				break
+			}
+		}
+		if end == start {
+			// 任一位置无法匹配时整个词为未知 token
+			return []int{len(word)}
+		}
+		lengths = append(lengths, end-start)
+		start = end
+	}
+	return lengths
+}
+
+// unigram 按词表项得分用 Viterbi 求最优切分
+type unigram struct {
+	scores   map[string]float64
+	maxRune  int
+	unkScore float64
+	fuseUnk  bool
+}
+
+func newUnigram(c *hfModelConf) (*unigram, error) {
+	var vocab [][2]json.RawMessage
+	if err := json.Unmarshal(c.Vocab, &vocab); err != nil {
+		return nil, fmt.Errorf("invalid Unigram vocab: %w", err)
+	}
+	m := &unigram{scores: make(map[string]float64, len(vocab)), fuseUnk: boolOr(c.FuseUnk, true)}
+	minScore := math.Inf(1)
+	for i, item := range vocab {
+		var (
+			piece string
+			score float64
+		)
+		if err := json.Unmarshal(item[0], &piece); err != nil {
+			return nil, fmt.Errorf("invalid Unigram piece %d: %w", i, err)
+		}
+		if err := json.Unmarshal(item[1], &score); err != nil {
+			return nil, fmt.Errorf("invalid Unigram score %d: %w", i, err)
+		}
+		if c.UnkID != nil && i == *c.UnkID {
+			continue
+		}
+		m.scores[piece] = score
+		m.maxRune = max(m.maxRune, utf8.RuneCountInString(piece))
+		minScore = min(minScore, score)
+	}
+	if math.IsInf(minScore, 1) {
+		minScore = 0
+	}
+	// 与 sentencepiece 一致，未知字符的得分低于词表中的最低分
+	m.unkScore = minScore - 10
+	return m, nil
+}
+
+func (m *unigram) tokenize(word []rune) []int {
+	n := len(word)
+	type node struct {
+		score float64
+		start int
+		unk   bool
+	}
+	best := make([]node, n+1)
+	for i := 1; i <= n; i++ {
+		best[i].score = math.Inf(-1)
+	}
+	for start := 0; start < n; start++ {
+		if math.IsInf(best[start].score, -1) {
+			continue
+		}
+		matched := false
+		for end := start + 1; end <= n && end-start <= m.maxRune; end++ {
+			score, ok := m.scores[string(word[start:end])]
+			if !ok {
+				continue
+			}
+			matched = matched || end == start+1
+			if s := best[start].score + score; s > best[end].score {
+				best[end] = node{score: s, start: start}
+			}
+		}
+		if !matched {
+			if s := best[start].score + m.unkScore; s > best[start+1].score {
+				best[start+1] = node{score: s, start: start, unk: true}
+			}
+		}
+	}
+
+	var (
+		lengths []int
+		unks    []bool
+	)
+	for end := n; end > 0; end = best[end].start {
+		lengths = append(lengths, end-best[end].start)
+		unks = append(unks, best[end].unk)
+	}
+	// 回溯得到的是逆序结果
+	result := make([]int, 0, len(lengths))
+	for i := len(lengths) - 1; i >= 0; i-- {
+		if m.fuseUnk && unks[i] && i+1 < len(lengths) && unks[i+1] {
+			result[len(result)-1] += lengths[i]
+			continue
+		}
+		result = append(result, lengths[i])
+	}
+	return result
+}
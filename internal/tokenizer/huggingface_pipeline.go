@@ -0,0 +1,429 @@
+package tokenizer
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dlclark/regexp2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// span 原文中的字节范围
+type span struct {
+	start, end int
+}
+
+// normalizer 规范化文本，first 表示是否为输入的第一段
+type normalizer interface {
+	normalize(s string, first bool) string
+}
+
+type normalizerFunc func(s string, first bool) string
+
+func (f normalizerFunc) normalize(s string, first bool) string {
+	return f(s, first)
+}
+
+type normalizerSequence []normalizer
+
+func (seq normalizerSequence) normalize(s string, first bool) string {
+	for _, n := range seq {
+		s = n.normalize(s, first)
+	}
+	return s
+}
+
+func newNormalizer(c *hfComponent) (normalizer, error) {
+	if c == nil {
+		return normalizerSequence(nil), nil
+	}
+	switch c.Type {
+	case "Sequence":
+		seq := make(normalizerSequence, 0, len(c.Normalizers))
+		for i := range c.Normalizers {
+			n, err := newNormalizer(&c.Normalizers[i])
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, n)
+		}
+		return seq, nil
+	case "BertNormalizer":
+		cleanText, lowercase := boolOr(c.CleanText, true), boolOr(c.Lowercase, true)
+		// strip_accents 未设置时跟随 lowercase
+		stripAccents := boolOr(c.StripAccents, lowercase)
+		return normalizerFunc(func(s string, _ bool) string {
+			if cleanText {
+				s = cleanControl(s)
+			}
+			if stripAccents {
+				s = removeAccents(s)
+			}
+			if lowercase {
+				s = strings.ToLower(s)
+			}
+			return s
+		}), nil
+	case "Lowercase":
+		return normalizerFunc(func(s string, _ bool) string { return strings.ToLower(s) }), nil
+	case "StripAccents":
+		return normalizerFunc(func(s string, _ bool) string { return removeAccents(s) }), nil
+	case "NFC":
+		return normalizerFunc(func(s string, _ bool) string { return norm.NFC.String(s) }), nil
+	case "NFD":
+		return normalizerFunc(func(s string, _ bool) string { return norm.NFD.String(s) }), nil
+	case "NFKC", "Precompiled":
+		// Precompiled 为 SentencePiece 的字符映射表，其内容基本等价于 NFKC
+		return normalizerFunc(func(s string, _ bool) string { return norm.NFKC.String(s) }), nil
+	case "NFKD":
+		return normalizerFunc(func(s string, _ bool) string { return norm.NFKD.String(s) }), nil
+	case "Strip":
+		return normalizerFunc(func(s string, _ bool) string { return strings.TrimSpace(s) }), nil
+	case "Prepend":
+		return normalizerFunc(func(s string, first bool) string {
+			if first && s != "" {
+				return c.Prepend + s
+			}
+			return s
+		}), nil
+	case "Replace":
+		re, err := compilePattern(c.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		return normalizerFunc(func(s string, _ bool) string {
+			if out, err := re.Replace(s, c.Content, -1, -1); err == nil {
+				return out
+			}
+			return s
+		}), nil
+	default:
+		return nil, fmt.Errorf("unsupported normalizer %q", c.Type)
+	}
+}
+
+// preTokenizer 预分词：split 在原文上切分并保留位置，transform 在规范化之后转换分词文本（如字节映射、空格替换）
+type preTokenizer interface {
+	split(text string, words []span) []span
+	transform(s string, first bool) string
+}
+
+// splitter 只切分、不转换文本的预分词
+type splitter func(text string, word span) []span
+
+func (f splitter) split(text string, words []span) []span {
+	var result []span
+	for _, w := range words {
+		result = append(result, f(text, w)...)
+	}
+	return result
+}
+
+func (f splitter) transform(s string, _ bool) string {
+	return s
+}
+
+type preTokenizerSequence []preTokenizer
+
+func (seq preTokenizerSequence) split(text string, words []span) []span {
+	for _, p := range seq {
+		words = p.split(text, words)
+	}
+	return words
+}
+
+func (seq preTokenizerSequence) transform(s string, first bool) string {
+	for _, p := range seq {
+		s = p.transform(s, first)
+	}
+	return s
+}
+
+func newPreTokenizer(c *hfComponent) (preTokenizer, error) {
+	if c == nil {
+		return preTokenizerSequence(nil), nil
+	}
+	switch c.Type {
+	case "Sequence":
+		seq := make(preTokenizerSequence, 0, len(c.Pretokenizers))
+		for i := range c.Pretokenizers {
+			p, err := newPreTokenizer(&c.Pretokenizers[i])
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, p)
+		}
+		return seq, nil
+	case "BertPreTokenizer":
+		// 空白处切分并丢弃空白，标点和中日韩字符各自成段
+		return splitter(func(text string, w span) []span {
+			return splitRunes(text, w, unicode.IsSpace, func(r rune) bool { return isBertPunctuation(r) || isCJK(r) })
+		}), nil
+	case "WhitespaceSplit":
+		return splitter(func(text string, w span) []span {
+			return splitRunes(text, w, unicode.IsSpace, nil)
+		}), nil
+	case "Whitespace":
+		return regexSplitter(regexp2.MustCompile(`\w+|[^\w\s]+`, regexp2.None), "Removed", true), nil
+	case "Punctuation":
+		return splitter(func(text string, w span) []span {
+			return splitRunes(text, w, nil, isBertPunctuation)
+		}), nil
+	case "Digits":
+		if c.IndividualDigits {
+			return splitter(func(text string, w span) []span {
+				return splitRunes(text, w, nil, unicode.IsDigit)
+			}), nil
+		}
+		return regexSplitter(regexp2.MustCompile(`\p{N}+`, regexp2.None), "Isolated", false), nil
+	case "Split":
+		re, err := compilePattern(c.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		return regexSplitter(re, c.Behavior, c.Invert), nil
+	case "ByteLevel":
+		return &byteLevel{addPrefixSpace: boolOr(c.AddPrefixSpace, true), useRegex: boolOr(c.UseRegex, true)}, nil
+	case "Metaspace":
+		m := &metaspace{replacement: cmp.Or(c.Replacement, "▁"), prependScheme: c.PrependScheme, splitOnSpace: boolOr(c.Split, true)}
+		if m.prependScheme == "" {
+			m.prependScheme = "always"
+			if !boolOr(c.AddPrefixSpace, true) {
+				m.prependScheme = "never"
+			}
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("unsupported pre tokenizer %q", c.Type)
+	}
+}
+
+// splitRunes 按字符切分：drop 为 true 的字符被丢弃并作为分隔，isolate 为 true 的字符单独成段
+func splitRunes(text string, w span, drop, isolate func(rune) bool) []span {
+	var (
+		result []span
+		start  = -1
+	)
+	flush := func(end int) {
+		if start >= 0 && end > start {
+			result = append(result, span{start: start, end: end})
+		}
+		start = -1
+	}
+	for i := w.start; i < w.end; {
+		r, size := utf8.DecodeRuneInString(text[i:w.end])
+		switch {
+		case drop != nil && drop(r):
+			flush(i)
+		case isolate != nil && isolate(r):
+			flush(i)
+			result = append(result, span{start: i, end: i + size})
+		default:
+			if start < 0 {
+				start = i
+			}
+		}
+		i += size
+	}
+	flush(w.end)
+	return result
+}
+
+// regexSplitter 按正则切分，behavior 决定匹配部分的归属：Isolated 单独成段、Removed 丢弃、
+// MergedWithPrevious 并入前一段、MergedWithNext 并入后一段、Contiguous 连续匹配合并为一段；invert 时切分非匹配部分
+func regexSplitter(re *regexp2.Regexp, behavior string, invert bool) splitter {
+	return func(text string, w span) []span {
+		type part struct {
+			span
+			match bool
+		}
+		s := text[w.start:w.end]
+		// regexp2 的匹配位置以字符计，转换为字节位置
+		offsets := make([]int, 0, len(s)+1)
+		for i := range s {
+			offsets = append(offsets, i)
+		}
+		offsets = append(offsets, len(s))
+
+		var parts []part
+		prev := 0
+		m, _ := re.FindStringMatch(s)
+		for m != nil {
+			start, end := offsets[m.Index], offsets[m.Index+m.Length]
+			if start > prev {
+				parts = append(parts, part{span{w.start + prev, w.start + start}, invert})
+			}
+			if end > start {
+				parts = append(parts, part{span{w.start + start, w.start + end}, !invert})
+			}
+			prev = end
+			m, _ = re.FindNextMatch(m)
+		}
+		if prev < len(s) {
+			parts = append(parts, part{span{w.start + prev, w.end}, invert})
+		}
+
+		var result []span
+		for i, p := range parts {
+			switch {
+			case !p.match:
+				result = append(result, p.span)
+			case behavior == "Removed":
+			case behavior == "MergedWithPrevious" && len(result) > 0 && !parts[i-1].match:
+				result[len(result)-1].end = p.end
+			case behavior == "MergedWithNext" && i+1 < len(parts) && !parts[i+1].match:
+				parts[i+1].start = p.start
+			case behavior == "Contiguous" && i > 0 && parts[i-1].match && len(result) > 0:
+				result[len(result)-1].end = p.end
+			default:
+				result = append(result, p.span)
+			}
+		}
+		return result
+	}
+}
+
+// gpt2Pattern GPT-2 的预分词正则
+var gpt2Pattern = regexp2.MustCompile(`'s|'t|'re|'ve|'m|'ll|'d| ?\p{L}+| ?\p{N}+| ?[^\s\p{L}\p{N}]+|\s+(?!\S)|\s+`, regexp2.None)
+
+// byteLevel 将文本的每个字节映射为一个可见字符，BPE 模型在映射后的字符上合并
+type byteLevel struct {
+	addPrefixSpace bool
+	useRegex       bool
+}
+
+func (b *byteLevel) split(text string, words []span) []span {
+	if !b.useRegex {
+		return words
+	}
+	return regexSplitter(gpt2Pattern, "Isolated", false).split(text, words)
+}
+
+func (b *byteLevel) transform(s string, first bool) string {
+	if first && b.addPrefixSpace && !strings.HasPrefix(s, " ") {
+		s = " " + s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		sb.WriteRune(byteToRune[s[i]])
+	}
+	return sb.String()
+}
+
+// byteToRune GPT-2 的字节到字符映射：可见字节映射为自身，其余映射到 256 之后的字符
+var byteToRune = func() [256]rune {
+	var table [256]rune
+	n := 0
+	for b := 0; b < 256; b++ {
+		if (b >= '!' && b <= '~') || (b >= 0xA1 && b <= 0xAC) || (b >= 0xAE && b <= 0xFF) {
+			table[b] = rune(b)
+		} else {
+			table[b] = rune(256 + n)
+			n++
+		}
+	}
+	return table
+}()
+
+// metaspace SentencePiece 风格的预分词：空格替换为 ▁ 并在空格前切分
+type metaspace struct {
+	replacement   string
+	prependScheme string // always、first、never
+	splitOnSpace  bool
+}
+
+func (m *metaspace) split(text string, words []span) []span {
+	if !m.splitOnSpace {
+		return words
+	}
+	var result []span
+	for _, w := range words {
+		start := w.start
+		for i := w.start; i < w.end; i++ {
+			if text[i] == ' ' && i > start {
+				result = append(result, span{start: start, end: i})
+				start = i
+			}
+		}
+		if w.end > start {
+			result = append(result, span{start: start, end: w.end})
+		}
+	}
+	return result
+}
+
+func (m *metaspace) transform(s string, first bool) string {
+	s = strings.ReplaceAll(s, " ", m.replacement)
+	prepend := m.prependScheme == "always" || (m.prependScheme == "first" && first)
+	if prepend && !strings.HasPrefix(s, m.replacement) {
+		s = m.replacement + s
+	}
+	return s
+}
+
+// compilePattern 编译 Split、Replace 的匹配模式，String 模式按字面量匹配
+func compilePattern(p hfPattern) (*regexp2.Regexp, error) {
+	switch {
+	case p.Regex != nil:
+		re, err := regexp2.Compile(*p.Regex, regexp2.None)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", *p.Regex, err)
+		}
+		return re, nil
+	case p.String != nil:
+		return regexp2.MustCompile(regexp.QuoteMeta(*p.String), regexp2.None), nil
+	default:
+		return nil, fmt.Errorf("missing pattern")
+	}
+}
+
+// cleanControl 去掉控制字符和无效字符，空白字符替换为空格
+func cleanControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == 0 || r == utf8.RuneError:
+			return -1
+		case r == '\t' || r == '\n' || r == '\r' || unicode.IsSpace(r):
+			return ' '
+		case unicode.IsControl(r) || unicode.In(r, unicode.Cf):
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// removeAccents 分解字符后去掉组合附加符号
+func removeAccents(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+		return r
+	}, norm.NFD.String(s))
+}
+
+// isBertPunctuation BERT 的标点判断：ASCII 中的非字母数字可见字符和 Unicode 标点
+func isBertPunctuation(r rune) bool {
+	if (r >= 33 && r <= 47) || (r >= 58 && r <= 64) || (r >= 91 && r <= 96) || (r >= 123 && r <= 126) {
+		return true
+	}
+	return unicode.IsPunct(r)
+}
+
+// isCJK 判断是否为中日韩统一表意文字，BERT 在这些字符两侧加空格使其单独成词
+func isCJK(r rune) bool {
+	return (r >= 0x4E00 && r <= 0x9FFF) || (r >= 0x3400 && r <= 0x4DBF) || (r >= 0x20000 && r <= 0x2A6DF) ||
+		(r >= 0x2A700 && r <= 0x2B73F) || (r >= 0x2B740 && r <= 0x2B81F) || (r >= 0x2B820 && r <= 0x2CEAF) ||
+		(r >= 0xF900 && r <= 0xFAFF) || (r >= 0x2F800 && r <= 0x2FA1F)
+}
+
+func boolOr(b *bool, def bool) bool {
+	if b == nil {
+		return def
+	}
+	return *b
+}
@@ -0,0 +1,82 @@
+// Package tokenizer 提供与嵌入模型一致的分词器，用于按 token 数切分分块和打包嵌入请求。
+// 分词方式与模型不一致时，按 token 数切分的分块可能超出模型单条输入上限而被服务端截断。
+package tokenizer
+
+import (
+	"cmp"
+	"fmt"
+	"sync"
+
+	tiktoken "github.com/tiktoken-go/tokenizer"
+)
+
+const (
+	TypeCl100k      = "cl100k"      // OpenAI cl100k_base（text-embedding-3、ada-002）
+	TypeO200k       = "o200k"       // OpenAI o200k_base
+	TypeHuggingFace = "huggingface" // 从本地 HuggingFace tokenizer.json 加载（BGE、Jina、Qwen 等）
+)
+
+// Tokenizer 分词器
+type Tokenizer interface {
+	// Count 返回文本编码后的 token 数，包含模型自动添加的特殊 token（如 [CLS]、[SEP]）
+	Count(text string) (int, error)
+	// Split 将文本切分为各 token 对应的原文片段，片段按顺序拼接等于原文，不包含特殊 token
+	Split(text string) ([]string, error)
+}
+
+// loaded 已加载的分词器，tokenizer.json 通常有数 MB，相同配置只加载一次
+var loaded sync.Map
+
+// Get 返回指定类型的分词器，类型为空时使用 cl100k；huggingface 类型需要指定 tokenizer.json 的本地路径
+func Get(typ, path string) (Tokenizer, error) {
+	typ = cmp.Or(typ, TypeCl100k)
+	key := typ + ":" + path
+	if t, ok := loaded.Load(key); ok {
+		return t.(Tokenizer), nil
+	}
+
+	var (
+		t   Tokenizer
+		err error
+	)
+	switch typ {
+	case TypeCl100k:
+		t, err = newTiktoken(tiktoken.Cl100kBase)
+	case TypeO200k:
+		t, err = newTiktoken(tiktoken.O200kBase)
+	case TypeHuggingFace:
+		if path == "" {
+			return nil, fmt.Errorf("tokenizer path is required for %s tokenizer", typ)
+		}
+		t, err = LoadHuggingFace(path)
+	default:
+		return nil, fmt.Errorf("unsupported tokenizer type %q", typ)
+	}
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := loaded.LoadOrStore(key, t)
+	return actual.(Tokenizer), nil
+}
+
+// tiktokenTokenizer OpenAI tiktoken 编码
+type tiktokenTokenizer struct {
+	codec tiktoken.Codec
+}
+
+func newTiktoken(encoding tiktoken.Encoding) (Tokenizer, error) {
+	codec, err := tiktoken.Get(encoding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tokenizer %s: %w", encoding, err)
+	}
+	return &tiktokenTokenizer{codec: codec}, nil
+}
+
+func (t *tiktokenTokenizer) Count(text string) (int, error) {
+	return t.codec.Count(text)
+}
+
+func (t *tiktokenTokenizer) Split(text string) ([]string, error) {
+	_, tokens, err := t.codec.Encode(text)
+	return tokens, err
+}
@@ -0,0 +1,135 @@
+package tokenizer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bertTokenizer BERT 风格的 WordPiece 分词器
+const bertTokenizer = `{
+  "normalizer": {"type": "BertNormalizer", "clean_text": true, "lowercase": true, "strip_accents": null},
+  "pre_tokenizer": {"type": "BertPreTokenizer"},
+  "post_processor": {
+    "type": "TemplateProcessing",
+    "single": [{"SpecialToken": {"id": "[CLS]"}}, {"Sequence": {"id": "A"}}, {"SpecialToken": {"id": "[SEP]"}}]
+  },
+  "model": {
+    "type": "WordPiece",
+    "unk_token": "[UNK]",
+    "continuing_subword_prefix": "##",
+    "vocab": {"[UNK]": 0, "[CLS]": 1, "[SEP]": 2, "func": 3, "##tion": 4, "get": 5, "##user": 6, "(": 7, ")": 8, "用": 9, "户": 10, "cafe": 11}
+  }
+}`
+
+// gpt2Tokenizer byte-level BPE 分词器
+const gpt2Tokenizer = `{
+  "pre_tokenizer": {"type": "ByteLevel", "add_prefix_space": false, "use_regex": true},
+  "post_processor": {"type": "ByteLevel"},
+  "model": {
+    "type": "BPE",
+    "vocab": {"r": 0, "e": 1, "t": 2, "u": 3, "n": 4, "Ġ": 5, "x": 6, "re": 7, "ret": 8, "retu": 9, "return": 10, "Ġx": 11, "rn": 12},
+    "merges": ["r e", "re t", "ret u", "r n", "retu rn", "Ġ x"]
+  }
+}`
+
+// sentencePieceTokenizer Metaspace + Unigram 分词器
+const sentencePieceTokenizer = `{
+  "normalizer": {"type": "Sequence", "normalizers": [{"type": "NFKC"}, {"type": "Lowercase"}]},
+  "pre_tokenizer": {"type": "Metaspace", "replacement": "▁", "prepend_scheme": "always"},
+  "post_processor": {"type": "RobertaProcessing"},
+  "model": {
+    "type": "Unigram",
+    "unk_id": 0,
+    "vocab": [["<unk>", 0], ["▁", -2], ["▁hello", -1], ["▁wor", -3], ["ld", -3], ["▁world", -8], ["h", -5], ["e", -5]]
+  }
+}`
+
+func assertSplitJoins(t *testing.T, tok Tokenizer, text string) []string {
+	pieces, err := tok.Split(text)
+	require.NoError(t, err)
+	assert.Equal(t, text, strings.Join(pieces, ""))
+	return pieces
+}
+
+func TestWordPiece(t *testing.T) {
+	tok, err := parseHuggingFace([]byte(bertTokenizer))
+	require.NoError(t, err)
+
+	// [CLS] func ##tion get ##user ( ) [SEP]
+	count, err := tok.Count("Function getUser()")
+	require.NoError(t, err)
+	assert.Equal(t, 8, count)
+
+	// 重音被去掉，中文字符单独成词，未知词整体为 [UNK]
+	count, err = tok.Count("Café 用户 xyz")
+	require.NoError(t, err)
+	assert.Equal(t, 2+4, count)
+
+	count, err = tok.Count("")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	pieces := assertSplitJoins(t, tok, "Function getUser()")
+	assert.Equal(t, []string{"Func", "tion", " get", "User", "(", ")"}, pieces)
+}
+
+func TestByteLevelBPE(t *testing.T) {
+	tok, err := parseHuggingFace([]byte(gpt2Tokenizer))
+	require.NoError(t, err)
+
+	// return | Ġx
+	count, err := tok.Count("return x")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// 同一预分词段内的重复单词分别合并
+	count, err = tok.Count("returnreturn")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.Equal(t, []string{"return", " x"}, assertSplitJoins(t, tok, "return x"))
+}
+
+func TestUnigram(t *testing.T) {
+	tok, err := parseHuggingFace([]byte(sentencePieceTokenizer))
+	require.NoError(t, err)
+
+	// <s> ▁hello ▁wor ld </s>
+	count, err := tok.Count("Hello world")
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+
+	// 连续的未知字符合并为一个 <unk>
+	count, err = tok.Count("zz")
+	require.NoError(t, err)
+	assert.Equal(t, 2+2, count)
+
+	assertSplitJoins(t, tok, "Hello  world\n")
+}
+
+func TestGet(t *testing.T) {
+	tok, err := Get("", "")
+	require.NoError(t, err)
+	same, err := Get(TypeCl100k, "")
+	require.NoError(t, err)
+	assert.Same(t, tok, same)
+	assertSplitJoins(t, tok, "func main() {}\n")
+
+	_, err = Get(TypeHuggingFace, "")
+	assert.Error(t, err)
+	_, err = Get("sentencepiece", "")
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "tokenizer.json")
+	require.NoError(t, os.WriteFile(path, []byte(bertTokenizer), 0o644))
+	hf, err := Get(TypeHuggingFace, path)
+	require.NoError(t, err)
+	count, err := hf.Count("get")
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
@@ -0,0 +1,36 @@
+package types
+
+// 层级摘要记录的级别
+const (
+	SummaryLevelFile      = "file"
+	SummaryLevelDirectory = "directory"
+	SummaryLevelRepo      = "repo"
+)
+
+// SummaryLevels 所有层级摘要级别
+var SummaryLevels = []string{SummaryLevelFile, SummaryLevelDirectory, SummaryLevelRepo}
+
+// CodebaseSummaryRequest 开启或关闭代码库的大模型分块摘要，之后同步的文件生效
+type CodebaseSummaryRequest struct {
+	ClientId     string `json:"clientId"`
+	CodebasePath string `json:"codebasePath"`
+	Enabled      bool   `json:"enabled"`
+}
+
+// CodebaseSummaryResponseData 代码库的摘要开关，服务未开启摘要（IndexTask.EmbeddingTask.Summary）时不会生成摘要
+type CodebaseSummaryResponseData struct {
+	CodebaseId    int32 `json:"codebaseId"`
+	Enabled       bool  `json:"enabled"`
+	ServerEnabled bool  `json:"serverEnabled"`
+}
+
+// HierarchySummaryRequest 为代码库生成文件、目录和代码库的层级摘要
+type HierarchySummaryRequest struct {
+	ClientId     string `json:"clientId"`
+	CodebasePath string `json:"codebasePath"`
+}
+
+// HierarchySummaryResponseData 层级摘要任务提交结果，进度记录在 index_history 中（task_type=summary）
+type HierarchySummaryResponseData struct {
+	CodebaseId int32 `json:"codebaseId"`
+}
@@ -6,6 +6,7 @@ type TreeNode struct {
 	Path     string      `json:"path"`               // 完整路径
 	Type     string      `json:"type"`               // 节点类型: file/directory
 	Children []*TreeNode `json:"children,omitempty"` // 子节点，仅目录节点有效
+	Summary  string      `json:"summary,omitempty"`  // 层级摘要，生成后才有
 }
 
 // CodebaseTreeRequest 目录树查询请求
@@ -20,6 +20,36 @@ type CodeChunk struct {
 	FilePath     string // The BasePath to the file this block came from
 	Range        []int  // start from zero, startLine, startColumn, endLine, endColumn
 	TokenCount   int    // The number of tokens in this block
+	Generated    bool   // Whether the file is generated or minified code
+	Vendored     bool   // Whether the file is vendored third-party code
+	// EmbeddingHeader is the rendered context (file, package, scopes, signature, imports) prepended to Content
+	// only when generating the embedding; the stored content stays the raw code
+	EmbeddingHeader string
+	// Docstring holds the doc comments and docstring of the declaration, stored as a separate searchable field
+	Docstring string
+	// SubRanges holds the ranges of the original small chunks when adjacent chunks were merged
+	SubRanges [][]int
+	// Breadcrumb is the key path of the chunk in structured files (YAML, JSON, TOML, Protobuf, GraphQL), e.g. "services > web",
+	// or the heading path of the chunk in Markdown documents, e.g. "README > Deployment > Redis"
+	Breadcrumb string
+	// FenceLanguages holds the languages of the fenced code blocks in Markdown chunks
+	FenceLanguages []string
+	// Links holds the outbound links of Markdown chunks that point into the codebase, as codebase-relative paths
+	Links []string
+	// APIOperations holds the operations described by chunks of API description documents (OpenAPI, Swagger, AsyncAPI)
+	APIOperations []APIOperation
+	// CellIndex is the zero-based cell index for chunks of Jupyter notebooks; Range then refers to the
+	// cell-concatenated view of the notebook (see embedding.SourceView)
+	CellIndex *int
+	// NodeKind is the syntax node kind of the declaration (function, class, ...) the chunk was split from,
+	// empty for remainder, merged and non-code chunks
+	NodeKind string
+	// Summary is the LLM generated natural-language description of function and class chunks,
+	// stored as a separate searchable field and prepended to the embedding text
+	Summary string
+	// SummaryLevel marks file, directory and repository summary records generated by the hierarchy summary
+	// job (see types.SummaryLevels); FilePath is then the summarized path and Content the summary
+	SummaryLevel string
 }
 
 // CodeChunkPathUpdate represents a request to update a code chunk's file path
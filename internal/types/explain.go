@@ -0,0 +1,86 @@
+package types
+
+import "time"
+
+// 检索各阶段名称
+const (
+	ExplainStagePreprocess  = "preprocess"  // 查询预处理
+	ExplainStageEmbed       = "embed"       // 生成查询向量
+	ExplainStageSearch      = "search"      // 向量库检索
+	ExplainStageRerank      = "rerank"      // 重排及分数融合
+	ExplainStageBoost       = "boost"       // 生成/第三方代码降权
+	ExplainStagePostProcess = "postprocess" // 合并相邻结果、多样性和单文件上限
+	ExplainStageFilter      = "filter"      // 分数阈值过滤
+)
+
+// SearchExplain 检索过程的说明，请求 explain=true 时返回，用于依据实际分数调整 scoreThreshold、MaxDocuments 等参数
+type SearchExplain struct {
+	Mode           string           `json:"mode"`                  // 实际使用的检索模式
+	MaxDocuments   int              `json:"maxDocuments"`          // 向量库召回的候选数上限
+	Candidates     int              `json:"candidates"`            // 实际召回的候选数
+	TopK           int              `json:"topK"`                  // 返回结果数上限
+	ScoreThreshold float32          `json:"scoreThreshold"`        // 分数阈值
+	Reranker       string           `json:"reranker,omitempty"`    // 实际生效的重排提供方，重排失败时为空
+	RerankError    string           `json:"rerankError,omitempty"` // 重排失败的原因，此时保留检索顺序
+	Filters        []*FilterExplain `json:"filters,omitempty"`     // 各过滤条件及其过滤掉的结果数
+	Stages         []*StageTiming   `json:"stages"`                // 各阶段耗时
+}
+
+// FilterExplain 检索时应用的过滤条件
+type FilterExplain struct {
+	Name    string `json:"name"`              // language、api、perFile、topK、scoreThreshold 等
+	Value   string `json:"value"`             // 过滤条件的取值
+	Removed int    `json:"removed,omitempty"` // 过滤掉的结果数，在向量库中执行的过滤为 0
+}
+
+// StageTiming 检索阶段的耗时及该阶段输出的结果数
+type StageTiming struct {
+	Stage      string  `json:"stage"`
+	DurationMs float64 `json:"durationMs"`
+	Count      int     `json:"count,omitempty"`
+}
+
+// ResultExplain 单个结果在各阶段的分数和排名，排名从 1 开始
+type ResultExplain struct {
+	RetrievalRank   int      `json:"retrievalRank"`             // 向量库返回的排名，混合检索时为融合后的排名
+	RetrievalScore  float32  `json:"retrievalScore"`            // 向量库返回的分数：向量检索为 certainty，关键词检索为归一化的 BM25 分数，混合检索为融合分数
+	VectorDistance  *float32 `json:"vectorDistance,omitempty"`  // 向量距离，仅向量检索
+	KeywordScore    *float32 `json:"keywordScore,omitempty"`    // 归一化前的 BM25 分数，仅关键词检索
+	RetrievalDetail string   `json:"retrievalDetail,omitempty"` // 混合检索中关键词和向量检索各自的分数
+	Reranker        string   `json:"reranker,omitempty"`        // 产生重排分数的提供方
+	RerankScore     *float32 `json:"rerankScore,omitempty"`
+	RerankRank      int      `json:"rerankRank,omitempty"`
+	FusionScore     *float32 `json:"fusionScore,omitempty"` // 重排分数与检索分数融合后的分数
+	FusionRank      int      `json:"fusionRank,omitempty"`
+	// 对分数的调整，如生成/第三方代码降权
+	Boosts []*ScoreBoost `json:"boosts,omitempty"`
+	// 合并到该结果中的其他结果的检索排名
+	MergedRanks []int    `json:"mergedRanks,omitempty"`
+	MMRScore    *float32 `json:"mmrScore,omitempty"` // 按多样性选择时的 MMR 分数
+}
+
+// ScoreBoost 对结果分数的调整
+type ScoreBoost struct {
+	Name   string  `json:"name"`
+	Factor float32 `json:"factor"` // 分数乘以的系数
+}
+
+// AddStage 记录阶段耗时，explain 为 nil（未开启）时不记录
+func (e *SearchExplain) AddStage(stage string, start time.Time, count int) {
+	if e == nil {
+		return
+	}
+	e.Stages = append(e.Stages, &StageTiming{
+		Stage:      stage,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		Count:      count,
+	})
+}
+
+// AddFilter 记录过滤条件，explain 为 nil（未开启）时不记录
+func (e *SearchExplain) AddFilter(name, value string, removed int) {
+	if e == nil {
+		return
+	}
+	e.Filters = append(e.Filters, &FilterExplain{Name: name, Value: value, Removed: removed})
+}
@@ -0,0 +1,22 @@
+package types
+
+import "time"
+
+// EndpointHealth 外部服务端点的健康状态
+type EndpointHealth struct {
+	Endpoint            string     `json:"endpoint"`
+	Healthy             bool       `json:"healthy"`
+	State               string     `json:"state"` // 熔断状态：closed/open/half_open
+	Weight              int        `json:"weight"`
+	InFlight            int        `json:"inFlight"`
+	MaxConcurrency      int        `json:"maxConcurrency"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	LastError           string     `json:"lastError,omitempty"`
+	LastErrorAt         *time.Time `json:"lastErrorAt,omitempty"`
+}
+
+// ReadinessResponse 就绪检查响应
+type ReadinessResponse struct {
+	Ready    bool             `json:"ready"`
+	Embedder []EndpointHealth `json:"embedder,omitempty"` // 嵌入服务各端点状态
+}
@@ -19,6 +19,8 @@ type CodebaseSyncMessage struct {
 const (
 	TaskTypeCodegraph = "codegraph"
 	TaskTypeEmbedding = "embedding"
+	TaskTypeReembed   = "reembed" // 更换嵌入模型后为新向量槽位重新嵌入
+	TaskTypeSummary   = "summary" // 生成文件、目录和代码库的层级摘要
 )
 
 // IndexMessage 索引任务消息
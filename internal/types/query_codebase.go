@@ -34,6 +34,8 @@ type CodebaseRecord struct {
 	CodebasePath string    `json:"codebasePath"`
 	CodebaseName string    `json:"codebaseName"`
 	SyncId       int32     `json:"syncId"`
+	Summary      string    `json:"summary,omitempty"`      // 函数、类分块的摘要
+	SummaryLevel string    `json:"summaryLevel,omitempty"` // 层级摘要记录的级别，为空表示代码分块
 }
 
 // CodebaseSummary 代码库摘要信息
@@ -50,11 +50,68 @@ type RelationRequest struct {
 }
 
 type SemanticFileItem struct {
-	Content   string  `json:"content"`   // 代码片段
-	FilePath  string  `json:"filePath"`  // 文件相对路径
-	Score     float32 `json:"score"`     // 匹配得分
-	StartLine int     `json:"startLine"` // 代码片段起始行
-	EndLine   int     `json:"endLine"`   // 代码片段结束行
+	Content   string  `json:"content"`             // 代码片段
+	FilePath  string  `json:"filePath"`            // 文件相对路径
+	Score     float32 `json:"score"`               // 匹配得分
+	StartLine int     `json:"startLine"`           // 代码片段起始行
+	EndLine   int     `json:"endLine"`             // 代码片段结束行
+	Generated bool    `json:"generated,omitempty"` // 是否为生成/压缩代码
+	Vendored  bool    `json:"vendored,omitempty"`  // 是否为第三方代码
+	// 由多个相邻小分块合并而成时，各原始分块（符号）的行范围
+	SubRanges []LineRange `json:"subRanges,omitempty"`
+	// 结构化文件（YAML、JSON、TOML、Protobuf、GraphQL）中分块的键路径，或 Markdown 文档中分块的标题路径
+	Breadcrumb string `json:"breadcrumb,omitempty"`
+	// Markdown 分块中代码围栏的语言
+	FenceLanguages []string `json:"fenceLanguages,omitempty"`
+	// Markdown 分块中指向代码库内文件的链接（代码库相对路径），可作为相关代码展示
+	Links []string `json:"links,omitempty"`
+	// API 描述文档（OpenAPI、Swagger、AsyncAPI）分块中的操作
+	APIOperations []APIOperation `json:"apiOperations,omitempty"`
+	// Jupyter notebook 分块所在的单元格序号（从 0 开始），此时行号对应各单元格源码拼接后的视图
+	CellIndex *int `json:"cellIndex,omitempty"`
+	// 大模型生成的函数、类分块的自然语言描述
+	Summary string `json:"summary,omitempty"`
+	// 层级摘要记录的级别（file、directory、repo），此时 filePath 为被摘要的路径，content 为摘要
+	SummaryLevel string `json:"summaryLevel,omitempty"`
+	// 各阶段的分数和排名，请求 explain=true 时返回
+	Explain *ResultExplain `json:"explain,omitempty"`
+}
+
+// APIOperation API 描述文档中的操作
+type APIOperation struct {
+	Method      string   `json:"method"`                // HTTP 方法（GET、POST 等），AsyncAPI 为 PUBLISH、SUBSCRIBE、SEND、RECEIVE
+	Path        string   `json:"path"`                  // 路径模板，AsyncAPI 为通道地址
+	OperationID string   `json:"operationId,omitempty"` // 操作 ID
+	Tags        []string `json:"tags,omitempty"`        // 标签
+	Schemas     []string `json:"schemas,omitempty"`     // 引用的具名 schema
+}
+
+// LineRange 代码行范围
+type LineRange struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine"`
+}
+
+// 检索模式
+const (
+	SearchModeVector  = "vector"  // 向量检索
+	SearchModeKeyword = "keyword" // BM25 关键词检索
+	SearchModeHybrid  = "hybrid"  // 向量与关键词混合检索
+)
+
+// QueryDebug 查询预处理的调试信息
+type QueryDebug struct {
+	Original string             `json:"original"` // 原始查询
+	Query    string             `json:"query"`    // 实际用于检索的查询
+	Mode     string             `json:"mode"`     // 实际使用的检索模式
+	Stages   []*QueryStageTrace `json:"stages"`   // 各预处理阶段的输出
+}
+
+// QueryStageTrace 单个预处理阶段的输出
+type QueryStageTrace struct {
+	Stage string `json:"stage"`
+	Query string `json:"query"`
+	Mode  string `json:"mode"`
 }
 
 type SemanticSearchRequest struct {
@@ -63,10 +120,19 @@ type SemanticSearchRequest struct {
 	Query          string  `json:"query"`                               // 查询内容
 	TopK           int     `json:"topK,optional,default=10"`            // 结果返回数量（默认10）
 	ScoreThreshold float32 `json:"scoreThreshold,optional,default=0.3"` // 分数阈值，默认0.3
+	// 结果后处理，不传时使用服务端配置：合并同一文件中重叠或相邻的结果、MMR 多样性权重[0,1]、每个文件最多返回的结果数
+	MergeAdjacent *bool    `json:"mergeAdjacent,optional"`
+	Diversity     *float32 `json:"diversity,optional"`
+	MaxPerFile    *int     `json:"maxPerFile,optional"`
+	Mode          string   `json:"mode,optional"`    // 检索模式 vector、keyword、hybrid，不传时按查询预处理的识别结果
+	Debug         bool     `json:"debug,optional"`   // 返回查询预处理各阶段的输出
+	Explain       bool     `json:"explain,optional"` // 返回每个结果各阶段的分数和各阶段耗时
 }
 
 type SemanticSearchResponseData struct {
-	List []*SemanticFileItem `json:"list"` // 检索结果列表
+	List    []*SemanticFileItem `json:"list"`              // 检索结果列表
+	Debug   *QueryDebug         `json:"debug,omitempty"`   // 查询预处理的调试信息，请求 debug=true 时返回
+	Explain *SearchExplain      `json:"explain,omitempty"` // 检索过程的说明，请求 explain=true 时返回
 }
 
 type DocumentSearchRequest struct {
@@ -75,10 +141,36 @@ type DocumentSearchRequest struct {
 	Query          string  `json:"query"`                               // 查询内容
 	TopK           int     `json:"topK,optional,default=10"`            // 结果返回数量（默认10）
 	ScoreThreshold float32 `json:"scoreThreshold,optional,default=0.3"` // 分数阈值，默认0.3
+	// 结果后处理、检索模式、调试和检索说明，同 SemanticSearchRequest
+	MergeAdjacent *bool    `json:"mergeAdjacent,optional"`
+	Diversity     *float32 `json:"diversity,optional"`
+	MaxPerFile    *int     `json:"maxPerFile,optional"`
+	Mode          string   `json:"mode,optional"`
+	Debug         bool     `json:"debug,optional"`
+	Explain       bool     `json:"explain,optional"`
 }
 
 type DocumentSearchResponseData struct {
-	List []*SemanticFileItem `json:"list"` // 检索结果列表
+	List    []*SemanticFileItem `json:"list"`              // 检索结果列表
+	Debug   *QueryDebug         `json:"debug,omitempty"`   // 查询预处理的调试信息，请求 debug=true 时返回
+	Explain *SearchExplain      `json:"explain,omitempty"` // 检索过程的说明，请求 explain=true 时返回
+}
+
+type APIOperationSearchRequest struct {
+	ClientId       string   `json:"clientId"`                          // 用户机器ID（如MAC地址）
+	CodebasePath   string   `json:"codebasePath"`                      // 项目绝对路径
+	Query          string   `json:"query,optional"`                    // 查询内容，为空时按过滤条件生成
+	Method         string   `json:"method,optional"`                   // HTTP 方法，AsyncAPI 为 publish、subscribe、send、receive
+	Path           string   `json:"path,optional"`                     // 路径模板（AsyncAPI 为通道地址），可使用 * 通配
+	OperationId    string   `json:"operationId,optional"`              // 操作 ID
+	Tags           []string `json:"tags,optional"`                     // 包含任一标签
+	Schema         string   `json:"schema,optional"`                   // 引用的 schema 名
+	TopK           int      `json:"topK,optional,default=10"`          // 结果返回数量（默认10）
+	ScoreThreshold float32  `json:"scoreThreshold,optional,default=0"` // 分数阈值，默认不过滤
+}
+
+type APIOperationSearchResponseData struct {
+	List []*SemanticFileItem `json:"list"` // 检索结果列表，apiOperations 只包含满足过滤条件的操作
 }
 
 // ListOption 定义List方法的可选参数
@@ -19,7 +19,18 @@ type FileStatusResponseData struct {
 
 // FileStatusItem 单个文件状态项
 type FileStatusItem struct {
-	Path    string `json:"path"`    // 文件路径
-	Status  string `json:"status"`  // 文件状态（如：pending/processing/complete/failed）
-	Operate string `json:"operate"` // 文件操作类型（如：add/modify/delete）
+	Path    string `json:"path"`             // 文件路径
+	Status  string `json:"status"`           // 文件状态（如：pending/processing/complete/failed）
+	Operate string `json:"operate"`          // 文件操作类型（如：add/modify/delete）
+	Reason  string `json:"reason,omitempty"` // 失败原因
+	// 嵌入前被脱敏的敏感信息，仅记录规则和行号
+	Redactions []RedactionFinding `json:"redactions,omitempty"`
+	// 分块覆盖的非空行比例（0-1），仅切分成功的文件记录
+	Coverage *float64 `json:"coverage,omitempty"`
+}
+
+// RedactionFinding 单条脱敏记录
+type RedactionFinding struct {
+	Rule string `json:"rule"` // 命中的规则名称
+	Line int    `json:"line"` // 文件中的行号（从 1 开始）
 }
@@ -0,0 +1,31 @@
+package types
+
+// UsageRequest 用量查询请求，时间为 RFC3339 或 2006-01-02 格式，默认为本月
+type UsageRequest struct {
+	StartTime  string `form:"startTime,optional"`
+	EndTime    string `form:"endTime,optional"`
+	UserId     string `form:"userId,optional"`
+	CodebaseId int32  `form:"codebaseId,optional"`
+	UsageType  string `form:"usageType,optional,options=embedding|rerank|summary"`
+	GroupBy    string `form:"groupBy,optional,default=user,options=user|codebase|request|model|day|month"`
+}
+
+// UsageItem 按维度聚合的用量
+type UsageItem struct {
+	Key          string `json:"key"`
+	UsageType    string `json:"usageType"`
+	PromptTokens int64  `json:"promptTokens"`
+	TotalTokens  int64  `json:"totalTokens"`
+	Calls        int64  `json:"calls"`
+	// 含服务端未返回用量、按字符数估算的调用次数
+	EstimatedCalls int64 `json:"estimatedCalls"`
+}
+
+// UsageResponseData 用量查询响应
+type UsageResponseData struct {
+	StartTime   string       `json:"startTime"`
+	EndTime     string       `json:"endTime"`
+	GroupBy     string       `json:"groupBy"`
+	TotalTokens int64        `json:"totalTokens"`
+	List        []*UsageItem `json:"list"`
+}
@@ -0,0 +1,21 @@
+package types
+
+// VectorMigrationRequest 迁移代码库到当前嵌入模型的向量槽位，不指定代码库时迁移所有未切换的代码库
+type VectorMigrationRequest struct {
+	ClientId     string `json:"clientId,optional"`
+	CodebasePath string `json:"codebasePath,optional"`
+}
+
+// VectorMigrationItem 已提交迁移任务的代码库
+type VectorMigrationItem struct {
+	CodebaseId   int32  `json:"codebaseId"`
+	ClientId     string `json:"clientId"`
+	CodebasePath string `json:"codebasePath"`
+	FromSlot     string `json:"fromSlot"`
+}
+
+// VectorMigrationResponseData 迁移任务提交结果，进度记录在 index_history 中（task_type=reembed）
+type VectorMigrationResponseData struct {
+	TargetSlot string                 `json:"targetSlot"`
+	Submitted  []*VectorMigrationItem `json:"submitted"`
+}
@@ -0,0 +1,175 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zgsm-ai/codebase-indexer/internal/config"
+	"github.com/zgsm-ai/codebase-indexer/internal/dao/model"
+	"github.com/zgsm-ai/codebase-indexer/internal/dao/query"
+)
+
+// ErrQuotaExceeded 用户本月用量已达到配额
+var ErrQuotaExceeded = errors.New("monthly embedding token quota exceeded")
+
+const flushBatchSize = 100
+
+// Tracker 异步批量写入用量记录，并提供按月配额检查
+type Tracker struct {
+	conf    config.UsageConf
+	querier *query.Query
+	quotas  map[string]int64
+	records chan *model.EmbeddingUsage
+	done    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+// NewTracker 创建用量记录器，并启动后台写入协程
+func NewTracker(conf config.UsageConf, querier *query.Query) *Tracker {
+	if conf.BufferSize <= 0 {
+		conf.BufferSize = 1000
+	}
+	if conf.FlushInterval <= 0 {
+		conf.FlushInterval = 5 * time.Second
+	}
+	t := &Tracker{
+		conf:    conf,
+		querier: querier,
+		quotas:  make(map[string]int64, len(conf.UserQuotas)),
+		records: make(chan *model.EmbeddingUsage, conf.BufferSize),
+		done:    make(chan struct{}),
+	}
+	for _, q := range conf.UserQuotas {
+		t.quotas[q.UserID] = q.MonthlyTokens
+	}
+	t.wg.Add(1)
+	go t.run()
+	return t
+}
+
+// Record 实现 Recorder，缓冲区满时丢弃并记录日志，不阻塞模型调用
+func (t *Tracker) Record(ctx context.Context, record Record) {
+	if t == nil || !t.conf.Enabled {
+		return
+	}
+	m := &model.EmbeddingUsage{
+		RequestID:    record.RequestID,
+		Source:       record.Source,
+		UsageType:    record.UsageType,
+		CodebaseID:   record.CodebaseID,
+		CodebasePath: record.CodebasePath,
+		ClientID:     record.ClientID,
+		UserID:       record.UserID,
+		Model:        record.Model,
+		PromptTokens: record.PromptTokens,
+		TotalTokens:  record.TotalTokens,
+		Estimated:    record.Estimated,
+		CreatedAt:    time.Now(),
+	}
+	select {
+	case t.records <- m:
+	default:
+		logx.WithContext(ctx).Errorf("usage buffer is full, drop usage record, request %s, tokens %d", record.RequestID, record.TotalTokens)
+	}
+}
+
+func (t *Tracker) run() {
+	defer t.wg.Done()
+	ticker := time.NewTicker(t.conf.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*model.EmbeddingUsage, 0, flushBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := t.querier.EmbeddingUsage.WithContext(ctx).CreateInBatches(batch, flushBatchSize); err != nil {
+			logx.Errorf("failed to save %d usage records: %v", len(batch), err)
+		}
+		batch = make([]*model.EmbeddingUsage, 0, flushBatchSize)
+	}
+
+	for {
+		select {
+		case m := <-t.records:
+			batch = append(batch, m)
+			if len(batch) >= flushBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.done:
+			// 退出前写入缓冲区中剩余的记录
+			for {
+				select {
+				case m := <-t.records:
+					batch = append(batch, m)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Close 停止后台协程并写入剩余记录
+func (t *Tracker) Close() {
+	if t == nil {
+		return
+	}
+	t.once.Do(func() {
+		close(t.done)
+		t.wg.Wait()
+	})
+}
+
+// MonthlyQuota 返回用户的每月 token 配额，0 表示不限制
+func (t *Tracker) MonthlyQuota(userID string) int64 {
+	if quota, ok := t.quotas[userID]; ok {
+		return quota
+	}
+	return t.conf.DefaultMonthlyTokenQuota
+}
+
+// MonthlyTokens 返回用户自然月内已使用的 token 数
+func (t *Tracker) MonthlyTokens(ctx context.Context, userID string, now time.Time) (int64, error) {
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	u := t.querier.EmbeddingUsage
+	var result struct {
+		Total int64
+	}
+	err := u.WithContext(ctx).
+		Select(u.TotalTokens.Sum().IfNull(0).As("total")).
+		Where(u.UserID.Eq(userID), u.CreatedAt.Gte(monthStart)).
+		Scan(&result)
+	if err != nil {
+		return 0, err
+	}
+	return result.Total, nil
+}
+
+// CheckQuota 检查用户本月用量是否超过配额，未配置配额或用户未知时不限制
+func (t *Tracker) CheckQuota(ctx context.Context, userID string) error {
+	if t == nil || !t.conf.Enabled || userID == "" {
+		return nil
+	}
+	quota := t.MonthlyQuota(userID)
+	if quota <= 0 {
+		return nil
+	}
+	used, err := t.MonthlyTokens(ctx, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	if used >= quota {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package usage
+
+import (
+	"context"
+	"unicode/utf8"
+)
+
+const (
+	SourceIndex = "index" // 索引任务
+	SourceQuery = "query" // 检索请求
+
+	TypeEmbedding = "embedding"
+	TypeRerank    = "rerank"
+	TypeSummary   = "summary"
+)
+
+// Scope 用量归属：请求/任务、代码库和用户
+type Scope struct {
+	RequestID    string
+	Source       string
+	CodebaseID   int32
+	CodebasePath string
+	ClientID     string
+	UserID       string
+}
+
+// Record 一次模型调用的 token 用量
+type Record struct {
+	Scope
+	UsageType    string
+	Model        string
+	PromptTokens int64
+	TotalTokens  int64
+	Estimated    bool // 服务端未返回用量，按字符数估算
+}
+
+// Recorder 记录用量
+type Recorder interface {
+	Record(ctx context.Context, record Record)
+}
+
+type scopeKey struct{}
+
+type scoped struct {
+	recorder Recorder
+	scope    Scope
+}
+
+// WithScope 返回携带用量归属的 context，模型客户端通过 Report 将用量记录到 recorder
+func WithScope(ctx context.Context, recorder Recorder, scope Scope) context.Context {
+	if recorder == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, scopeKey{}, &scoped{recorder: recorder, scope: scope})
+}
+
+// ScopeFrom 返回 context 中的用量归属
+func ScopeFrom(ctx context.Context) (Scope, bool) {
+	s, ok := ctx.Value(scopeKey{}).(*scoped)
+	if !ok {
+		return Scope{}, false
+	}
+	return s.scope, true
+}
+
+// Report 记录一次模型调用的用量，context 未携带用量归属时忽略
+func Report(ctx context.Context, usageType, model string, promptTokens, totalTokens int, estimated bool) {
+	s, ok := ctx.Value(scopeKey{}).(*scoped)
+	if !ok {
+		return
+	}
+	if totalTokens < promptTokens {
+		totalTokens = promptTokens
+	}
+	s.recorder.Record(ctx, Record{
+		Scope:        s.scope,
+		UsageType:    usageType,
+		Model:        model,
+		PromptTokens: int64(promptTokens),
+		TotalTokens:  int64(totalTokens),
+		Estimated:    estimated,
+	})
+}
+
+// EstimateTokens 服务端不返回用量时按约 4 字符/token 估算
+func EstimateTokens(texts ...string) int {
+	total := 0
+	for _, text := range texts {
+		total += (utf8.RuneCountInString(text) + 3) / 4
+	}
+	return total
+}
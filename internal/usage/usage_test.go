@@ -0,0 +1,46 @@
+package usage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryRecorder struct {
+	records []Record
+}
+
+func (m *memoryRecorder) Record(_ context.Context, record Record) {
+	m.records = append(m.records, record)
+}
+
+func TestReport(t *testing.T) {
+	recorder := &memoryRecorder{}
+
+	// 未携带用量归属时忽略
+	Report(context.Background(), TypeEmbedding, "m", 10, 10, false)
+	assert.Empty(t, recorder.records)
+
+	scope := Scope{RequestID: "req-1", Source: SourceIndex, CodebaseID: 3, UserID: "alice"}
+	ctx := WithScope(context.Background(), recorder, scope)
+	Report(ctx, TypeEmbedding, "bge-m3", 12, 0, false)
+	Report(ctx, TypeRerank, "reranker", 5, 8, true)
+
+	assert.Len(t, recorder.records, 2)
+	assert.Equal(t, scope, recorder.records[0].Scope)
+	assert.Equal(t, int64(12), recorder.records[0].TotalTokens)
+	assert.Equal(t, TypeRerank, recorder.records[1].UsageType)
+	assert.Equal(t, int64(8), recorder.records[1].TotalTokens)
+	assert.True(t, recorder.records[1].Estimated)
+
+	got, ok := ScopeFrom(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "alice", got.UserID)
+}
+
+func TestEstimateTokens(t *testing.T) {
+	assert.Equal(t, 0, EstimateTokens())
+	assert.Equal(t, 1, EstimateTokens("abc"))
+	assert.Equal(t, 3, EstimateTokens("abcdefgh", "中文"))
+}
@@ -0,0 +1,2 @@
+-- Embedding, rerank and summary token usage table
+DROP TABLE embedding_usage;
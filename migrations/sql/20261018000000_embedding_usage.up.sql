@@ -0,0 +1,62 @@
+-- Embedding, rerank and summary token usage table
+CREATE TABLE embedding_usage
+(
+    id             integer      NOT NULL,
+    request_id     VARCHAR(255) NOT NULL,           -- upload request id or query trace id
+    source         VARCHAR(50)  NOT NULL,           -- index, query
+    usage_type     VARCHAR(50)  NOT NULL,           -- embedding, rerank, summary
+    codebase_id    INTEGER      NOT NULL DEFAULT 0, -- codebase.id
+    codebase_path  TEXT         NOT NULL DEFAULT '',
+    client_id      VARCHAR(255) NOT NULL DEFAULT '',
+    user_id        VARCHAR(255) NOT NULL DEFAULT '',
+    model          VARCHAR(255) NOT NULL DEFAULT '',
+    prompt_tokens  BIGINT       NOT NULL DEFAULT 0,
+    total_tokens   BIGINT       NOT NULL DEFAULT 0,
+    estimated      BOOLEAN      NOT NULL DEFAULT FALSE,
+    created_at     TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT
+    ON TABLE embedding_usage IS 'Records token usage of embedding, rerank and summary model calls';
+COMMENT
+    ON COLUMN embedding_usage.id IS 'Unique identifier for the usage record';
+COMMENT
+    ON COLUMN embedding_usage.request_id IS 'Upload request id of the index task, or trace id of the query';
+COMMENT
+    ON COLUMN embedding_usage.source IS 'Usage source: index, query';
+COMMENT
+    ON COLUMN embedding_usage.usage_type IS 'Usage type: embedding, rerank, summary';
+COMMENT
+    ON COLUMN embedding_usage.codebase_id IS 'ID of the associated project repository';
+COMMENT
+    ON COLUMN embedding_usage.codebase_path IS 'Path of the project repository';
+COMMENT
+    ON COLUMN embedding_usage.client_id IS 'User client identifier';
+COMMENT
+    ON COLUMN embedding_usage.user_id IS 'User identifier';
+COMMENT
+    ON COLUMN embedding_usage.model IS 'Model name';
+COMMENT
+    ON COLUMN embedding_usage.prompt_tokens IS 'Number of prompt tokens';
+COMMENT
+    ON COLUMN embedding_usage.total_tokens IS 'Number of total tokens';
+COMMENT
+    ON COLUMN embedding_usage.estimated IS 'Whether the token count is estimated because the provider does not report usage';
+COMMENT
+    ON COLUMN embedding_usage.created_at IS 'Time when the record was created';
+
+CREATE SEQUENCE embedding_usage_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE CACHE 1;
+
+ALTER SEQUENCE embedding_usage_id_seq OWNED BY embedding_usage.id;
+ALTER TABLE ONLY embedding_usage
+    ALTER COLUMN id SET DEFAULT nextval('embedding_usage_id_seq'::regclass);
+ALTER TABLE ONLY embedding_usage
+    ADD CONSTRAINT embedding_usage_pkey PRIMARY KEY (id);
+
+CREATE INDEX idx_embedding_usage_user_created ON embedding_usage (user_id, created_at);
+CREATE INDEX idx_embedding_usage_codebase_created ON embedding_usage (codebase_id, created_at);
+CREATE INDEX idx_embedding_usage_request ON embedding_usage (request_id);
@@ -0,0 +1,2 @@
+ALTER TABLE codebase
+    DROP COLUMN vector_slot;
@@ -0,0 +1,6 @@
+-- Named vector slot used to query the codebase's tenant
+ALTER TABLE codebase
+    ADD COLUMN vector_slot VARCHAR(255) NOT NULL DEFAULT '';
+
+COMMENT
+    ON COLUMN codebase.vector_slot IS 'Named vector slot used for queries, switched after re-embedding with a new model';
@@ -0,0 +1,2 @@
+ALTER TABLE codebase
+    DROP COLUMN enable_summary;
@@ -0,0 +1,6 @@
+-- Per-codebase opt-in for LLM generated chunk summaries
+ALTER TABLE codebase
+    ADD COLUMN enable_summary BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT
+    ON COLUMN codebase.enable_summary IS 'Whether to generate natural-language summaries of function and class chunks with an LLM';
@@ -37,21 +37,14 @@ type TestReport struct {
 
 // TestSummary 测试摘要
 type TestSummary struct {
-	AveragePrecision        float64                           `json:"average_precision"`
-	AverageRecall           float64                           `json:"average_recall"`
-	AverageF1Score          float64                           `json:"average_f1_score"`
-	AverageMAP              float64                           `json:"average_map"`
-	AverageNDCG             float64                           `json:"average_ndcg"`
-	AverageResponseTime     float64                           `json:"average_response_time"`
-	AverageContentPrecision float64                           `json:"average_content_precision"`
-	AverageContentRecall    float64                           `json:"average_content_recall"`
-	AverageContentF1Score   float64                           `json:"average_content_f1_score"`
-	TotalMatchedContents    int                               `json:"total_matched_contents"`
-	TotalContents           int                               `json:"total_contents"`
-	TotalQueries            int                               `json:"total_queries"`
-	BestScenario            string                            `json:"best_scenario"`
-	WorstScenario           string                            `json:"worst_scenario"`
-	ScenarioDimensionStats  map[string]ScenarioDimensionStats `json:"scenario_dimension_stats"`
+	AveragePrecision       float64                           `json:"average_precision"`
+	AverageRecall          float64                           `json:"average_recall"`
+	AverageF1Score         float64                           `json:"average_f1_score"`
+	AverageResponseTime    float64                           `json:"average_response_time"`
+	TotalQueries           int                               `json:"total_queries"`
+	BestScenario           string                            `json:"best_scenario"`
+	WorstScenario          string                            `json:"worst_scenario"`
+	ScenarioDimensionStats map[string]ScenarioDimensionStats `json:"scenario_dimension_stats"`
 }
 
 // ScenarioDimensionStats 场景维度统计
@@ -288,9 +281,7 @@ func generateTestReport(result *TestResult, testName string, startTime time.Time
 	// 统计成功和失败的场景
 	successCount := 0
 	failedCount := 0
-	var totalPrecision, totalRecall, totalF1, totalMAP, totalNDCG, totalResponseTime float64
-	var totalContentPrecision, totalContentRecall, totalContentF1Score float64
-	var totalMatchedContents, totalContents int
+	var totalPrecision, totalRecall, totalF1, totalResponseTime float64
 	var totalQueries int
 	var scenarioScores []ScenarioScore
 
@@ -309,44 +300,28 @@ func generateTestReport(result *TestResult, testName string, startTime time.Time
 		totalPrecision += metrics.Precision
 		totalRecall += metrics.Recall
 		totalF1 += metrics.F1Score
-		totalMAP += metrics.MAP
-		totalNDCG += metrics.NDCG
 		totalResponseTime += metrics.ResponseTime
-		totalContentPrecision += metrics.ContentPrecision
-		totalContentRecall += metrics.ContentRecall
-		totalContentF1Score += metrics.ContentF1Score
-		totalMatchedContents += metrics.MatchedContents
-		totalContents += metrics.TotalContents
 		totalQueries += len(scenarioResult.QueryResults)
 
 		// 计算综合得分 (F1分数权重最高)
-		compositeScore := (metrics.Precision*0.2 + metrics.Recall*0.2 + metrics.F1Score*0.3 + metrics.ContentF1Score*0.3)
+		compositeScore := (metrics.Precision*0.3 + metrics.Recall*0.3 + metrics.F1Score*0.4)
 		scenarioScores = append(scenarioScores, ScenarioScore{
 			Name:           name,
 			CompositeScore: compositeScore,
 			Precision:      metrics.Precision,
 			Recall:         metrics.Recall,
 			F1Score:        metrics.F1Score,
-			MAP:            metrics.MAP,
-			NDCG:           metrics.NDCG,
 			ResponseTime:   metrics.ResponseTime,
 		})
 
 		report.Results[name] = map[string]interface{}{
-			"status":            "success",
-			"precision":         metrics.Precision,
-			"recall":            metrics.Recall,
-			"f1_score":          metrics.F1Score,
-			"map":               metrics.MAP,
-			"ndcg":              metrics.NDCG,
-			"response_time":     metrics.ResponseTime,
-			"content_precision": metrics.ContentPrecision,
-			"content_recall":    metrics.ContentRecall,
-			"content_f1_score":  metrics.ContentF1Score,
-			"matched_contents":  metrics.MatchedContents,
-			"total_contents":    metrics.TotalContents,
-			"query_count":       len(scenarioResult.QueryResults),
-			"duration":          scenarioResult.EndTime.Sub(scenarioResult.StartTime),
+			"status":        "success",
+			"precision":     metrics.Precision,
+			"recall":        metrics.Recall,
+			"f1_score":      metrics.F1Score,
+			"response_time": metrics.ResponseTime,
+			"query_count":   len(scenarioResult.QueryResults),
+			"duration":      scenarioResult.EndTime.Sub(scenarioResult.StartTime),
 		}
 	}
 
@@ -356,18 +331,11 @@ func generateTestReport(result *TestResult, testName string, startTime time.Time
 	// 计算平均指标
 	if successCount > 0 {
 		report.Summary = TestSummary{
-			AveragePrecision:        totalPrecision / float64(successCount),
-			AverageRecall:           totalRecall / float64(successCount),
-			AverageF1Score:          totalF1 / float64(successCount),
-			AverageMAP:              totalMAP / float64(successCount),
-			AverageNDCG:             totalNDCG / float64(successCount),
-			AverageResponseTime:     totalResponseTime / float64(successCount),
-			AverageContentPrecision: totalContentPrecision / float64(successCount),
-			AverageContentRecall:    totalContentRecall / float64(successCount),
-			AverageContentF1Score:   totalContentF1Score / float64(successCount),
-			TotalMatchedContents:    totalMatchedContents,
-			TotalContents:           totalContents,
-			TotalQueries:            totalQueries,
+			AveragePrecision:    totalPrecision / float64(successCount),
+			AverageRecall:       totalRecall / float64(successCount),
+			AverageF1Score:      totalF1 / float64(successCount),
+			AverageResponseTime: totalResponseTime / float64(successCount),
+			TotalQueries:        totalQueries,
 		}
 
 		// 找出最佳和最差场景
@@ -390,16 +358,14 @@ type ScenarioScore struct {
 	Precision      float64 `json:"precision"`
 	Recall         float64 `json:"recall"`
 	F1Score        float64 `json:"f1_score"`
-	MAP            float64 `json:"map"`
-	NDCG           float64 `json:"ndcg"`
 	ResponseTime   float64 `json:"response_time"`
 }
 
 // printEnhancedTestSummary 打印增强的测试摘要
 func printEnhancedTestSummary(report *TestReport) {
-	fmt.Printf("\n" + strings.Repeat("=", 60) + "\n")
+	fmt.Print("\n" + strings.Repeat("=", 60) + "\n")
 	fmt.Printf("=== %s ===\n", report.TestName)
-	fmt.Printf(strings.Repeat("=", 60) + "\n")
+	fmt.Print(strings.Repeat("=", 60) + "\n")
 	fmt.Printf("测试描述: %s\n", report.TestDescription)
 	fmt.Printf("开始时间: %s\n", report.StartTime.Format("2006-01-02 15:04:05"))
 	fmt.Printf("结束时间: %s\n", report.EndTime.Format("2006-01-02 15:04:05"))
@@ -412,14 +378,7 @@ func printEnhancedTestSummary(report *TestReport) {
 		fmt.Printf("准确率: %.4f\n", report.Summary.AveragePrecision)
 		fmt.Printf("召回率: %.4f\n", report.Summary.AverageRecall)
 		fmt.Printf("F1分数: %.4f\n", report.Summary.AverageF1Score)
-		fmt.Printf("MAP:    %.4f\n", report.Summary.AverageMAP)
-		fmt.Printf("NDCG:   %.4f\n", report.Summary.AverageNDCG)
 		fmt.Printf("响应时间: %.2fms\n", report.Summary.AverageResponseTime)
-		fmt.Printf("\n--- 内容片段匹配指标 ---\n")
-		fmt.Printf("内容准确率: %.4f\n", report.Summary.AverageContentPrecision)
-		fmt.Printf("内容召回率: %.4f\n", report.Summary.AverageContentRecall)
-		fmt.Printf("内容F1分数: %.4f\n", report.Summary.AverageContentF1Score)
-		fmt.Printf("匹配内容片段: %d/%d\n", report.Summary.TotalMatchedContents, report.Summary.TotalContents)
 		fmt.Printf("总查询数: %d\n", report.Summary.TotalQueries)
 
 		fmt.Printf("\n--- 场景排名 ---\n")
@@ -438,7 +397,7 @@ func printEnhancedTestSummary(report *TestReport) {
 		}
 	}
 
-	fmt.Printf(strings.Repeat("=", 60) + "\n")
+	fmt.Print(strings.Repeat("=", 60) + "\n")
 }
 
 // generateHTMLReport 生成HTML格式报告
@@ -475,17 +434,8 @@ func generateHTMLReport(report *TestReport, filePath string) error {
             <div class="metric">准确率: %.4f</div>
             <div class="metric">召回率: %.4f</div>
             <div class="metric">F1分数: %.4f</div>
-            <div class="metric">MAP: %.4f</div>
-            <div class="metric">NDCG: %.4f</div>
             <div class="metric">响应时间: %.2fms</div>
         </div>
-        <h2>内容片段匹配指标</h2>
-        <div class="metrics">
-            <div class="metric">内容准确率: %.4f</div>
-            <div class="metric">内容召回率: %.4f</div>
-            <div class="metric">内容F1分数: %.4f</div>
-            <div class="metric">匹配内容片段: %d/%d</div>
-        </div>
     </div>
     
     <div>
@@ -495,10 +445,7 @@ func generateHTMLReport(report *TestReport, filePath string) error {
 		report.EndTime.Format("2006-01-02 15:04:05"),
 		report.Duration, report.TotalScenarios, report.SuccessScenarios, report.FailedScenarios,
 		report.Summary.AveragePrecision, report.Summary.AverageRecall,
-		report.Summary.AverageF1Score, report.Summary.AverageMAP, report.Summary.AverageNDCG,
-		report.Summary.AverageResponseTime, report.Summary.AverageContentPrecision,
-		report.Summary.AverageContentRecall, report.Summary.AverageContentF1Score,
-		report.Summary.TotalMatchedContents, report.Summary.TotalContents)
+		report.Summary.AverageF1Score, report.Summary.AverageResponseTime)
 
 	for name, result := range report.Results {
 		if resultMap, ok := result.(map[string]interface{}); ok {
@@ -520,13 +467,11 @@ func generateHTMLReport(report *TestReport, filePath string) error {
                 <div class="metric">准确率: %.4f</div>
                 <div class="metric">召回率: %.4f</div>
                 <div class="metric">F1分数: %.4f</div>
-                <div class="metric">MAP: %.4f</div>
-                <div class="metric">NDCG: %.4f</div>
                 <div class="metric">响应时间: %.2fms</div>
                 <div class="metric">查询数: %d</div>
             </div>
 `, resultMap["precision"], resultMap["recall"], resultMap["f1_score"],
-					resultMap["map"], resultMap["ndcg"], resultMap["response_time"], resultMap["query_count"])
+					resultMap["response_time"], resultMap["query_count"])
 			}
 
 			html += `        </div>
@@ -544,22 +489,20 @@ func generateHTMLReport(report *TestReport, filePath string) error {
 // generateCSVSummary 生成CSV格式摘要
 func generateCSVSummary(result *TestResult, filePath string) error {
 	var csvContent strings.Builder
-	csvContent.WriteString("Scenario,Status,Precision,Recall,F1Score,MAP,NDCG,ContentPrecision,ContentRecall,ContentF1Score,MatchedContents,TotalContents,ResponseTime,QueryCount,Duration\n")
+	csvContent.WriteString("Scenario,Status,Precision,Recall,F1Score,ResponseTime,QueryCount,Duration\n")
 
 	for name, scenarioResult := range result.Results {
 		status := "success"
 		if scenarioResult.Error != nil {
 			status = "failed"
-			csvContent.WriteString(fmt.Sprintf("%s,%s,,,,,,,\n", name, status))
+			csvContent.WriteString(fmt.Sprintf("%s,%s,,,,,,\n", name, status))
 			continue
 		}
 
 		metrics := scenarioResult.AverageMetrics
 		duration := scenarioResult.EndTime.Sub(scenarioResult.StartTime)
-		csvContent.WriteString(fmt.Sprintf("%s,%s,%.4f,%.4f,%.4f,%.4f,%.4f,%.4f,%.4f,%.4f,%d,%d,%.2f,%d,%v\n",
+		csvContent.WriteString(fmt.Sprintf("%s,%s,%.4f,%.4f,%.4f,%.2f,%d,%v\n",
 			name, status, metrics.Precision, metrics.Recall, metrics.F1Score,
-			metrics.MAP, metrics.NDCG, metrics.ContentPrecision, metrics.ContentRecall,
-			metrics.ContentF1Score, metrics.MatchedContents, metrics.TotalContents,
 			metrics.ResponseTime, len(scenarioResult.QueryResults), duration))
 	}
 
@@ -1,6 +1,7 @@
 package test
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -246,7 +247,11 @@ func (tr *TestRunner) runSingleTest(
 		APIKey:     rerankConfig.APIKey,
 		APIBase:    rerankConfig.APIBase,
 	}
-	reranker := vector.NewReranker(rerankConf)
+	reranker, err := vector.NewReranker(rerankConf)
+	if err != nil {
+		tr.logger.Printf("创建重排器失败: %v", err)
+		return ScenarioResult{}, fmt.Errorf("创建重排器失败: %w", err)
+	}
 
 	// 创建向量存储
 	tr.logger.Printf("创建向量存储: %s", vectorStoreConfig.Name)
@@ -378,10 +383,15 @@ func (tr *TestRunner) runSingleTest(
 // prepareCodeChunksOptimized 优化后的代码块准备函数
 func (tr *TestRunner) prepareCodeChunksOptimized() ([]*types.CodeChunk, error) {
 	// 创建代码分割器
+	var contextTemplate string
+	if tr.config.Chunking.ContextHeader {
+		contextTemplate = cmp.Or(tr.config.Chunking.ContextTemplate, embedding.DefaultContextTemplate)
+	}
 	splitter, err := embedding.NewCodeSplitter(embedding.SplitOptions{
 		MaxTokensPerChunk:          1000,
 		SlidingWindowOverlapTokens: 200,
 		EnableMarkdownParsing:      true,
+		ContextTemplate:            contextTemplate,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("创建代码分割器失败: %w", err)
@@ -29,7 +29,11 @@ type TestConfig struct {
 	Embedders    []EmbedderConf    `yaml:"embedders"`     // 嵌入模型配置列表
 	Rerank       RerankerConf      `yaml:"rerank"`        // 重排序模型配置
 	VectorStores []VectorStoreConf `yaml:"vector_stores"` // 向量数据库配置列表
-	Scenarios    struct {
+	Chunking     struct {
+		ContextHeader   bool   `yaml:"context_header"`   // 嵌入时是否拼接上下文头，分别开启和关闭运行以对比召回效果
+		ContextTemplate string `yaml:"context_template"` // 上下文头模板，为空时使用内置模板
+	} `yaml:"chunking"`
+	Scenarios struct {
 		EmbedderComparison    ScenarioConfig `yaml:"embedder_comparison"`     // 嵌入模型对比测试配置
 		VectorStoreComparison ScenarioConfig `yaml:"vector_store_comparison"` // 向量数据库对比测试配置
 	} `yaml:"scenarios"`